@@ -0,0 +1,191 @@
+package client
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	proto "github.com/ipfs/go-delegated-routing/gen/proto"
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+// CacheTransport is an http.RoundTripper which caches the responses of cachable FindProviders calls.
+// Responses are keyed by the multihash of the requested key, the endpoint and the encodings accepted by the request,
+// so that endpoints sharing a CacheTransport do not share responses, and are served from the cache until their TTL expires.
+// Expired responses are revalidated with the server using their ETag; when the server answers
+// 304 Not Modified the cached response is reused for another TTL.
+//
+// CacheTransport is installed in the HTTP client used by the protocol client, e.g.
+//
+//	hc := &http.Client{Transport: client.NewCacheTransport(http.DefaultTransport, 1024, time.Minute)}
+//	q, err := proto.New_DelegatedRouting_Client(endpoint, proto.DelegatedRouting_Client_WithHTTPClient(hc))
+type CacheTransport struct {
+	next http.RoundTripper
+	size int
+	ttl  time.Duration
+
+	lk      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key     string
+	etag    string
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// NewCacheTransport creates a transport holding at most size responses for the duration ttl.
+// If next is nil, http.DefaultTransport is used.
+func NewCacheTransport(next http.RoundTripper, size int, ttl time.Duration) *CacheTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &CacheTransport{
+		next:    next,
+		size:    size,
+		ttl:     ttl,
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+func (ct *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, ok := findProvidersCacheKey(req)
+	if !ok {
+		return ct.next.RoundTrip(req)
+	}
+
+	entry, fresh := ct.lookup(key)
+	if fresh {
+		recordCacheMetrics(req.Context(), "hit")
+		return entry.response(req), nil
+	}
+
+	if entry != nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", entry.etag)
+	}
+	resp, err := ct.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		resp.Body.Close()
+		ct.store(entry)
+		recordCacheMetrics(req.Context(), "revalidated")
+		return entry.response(req), nil
+	}
+
+	recordCacheMetrics(req.Context(), "miss")
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+	// cachable responses are buffered by the server before they are sent, so reading them whole does not delay results
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	ct.store(&cacheEntry{key: key, etag: etag, header: resp.Header.Clone(), body: body})
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// lookup returns the cache entry for key, if any, and whether it has not expired yet.
+func (ct *CacheTransport) lookup(key string) (*cacheEntry, bool) {
+	ct.lk.Lock()
+	defer ct.lk.Unlock()
+	elem, ok := ct.entries[key]
+	if !ok {
+		return nil, false
+	}
+	ct.lru.MoveToFront(elem)
+	entry := elem.Value.(*cacheEntry)
+	return entry, time.Now().Before(entry.expires)
+}
+
+// store adds or refreshes entry, evicting the least recently used entries beyond the size of the cache.
+func (ct *CacheTransport) store(entry *cacheEntry) {
+	ct.lk.Lock()
+	defer ct.lk.Unlock()
+	if ct.size <= 0 {
+		return
+	}
+	entry.expires = time.Now().Add(ct.ttl)
+	if elem, ok := ct.entries[entry.key]; ok {
+		elem.Value = entry
+		ct.lru.MoveToFront(elem)
+	} else {
+		ct.entries[entry.key] = ct.lru.PushFront(entry)
+	}
+	for ct.lru.Len() > ct.size {
+		oldest := ct.lru.Back()
+		ct.lru.Remove(oldest)
+		delete(ct.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// findProvidersCacheKey returns the cache key of a cachable FindProviders call: the multihash of the requested key,
+// the request URL without the query which carries the call, and the Accept and Accept-Encoding headers,
+// which select the encodings of the response body.
+func findProvidersCacheKey(req *http.Request) (string, bool) {
+	if req.Method != http.MethodGet {
+		return "", false
+	}
+	n, err := ipld.Decode([]byte(req.URL.Query().Get("q")), dagcbor.Decode)
+	if err != nil {
+		return "", false
+	}
+	env := &proto.AnonInductive4{}
+	if err = env.Parse(n); err != nil || env.FindProviders == nil {
+		return "", false
+	}
+	endpoint := *req.URL
+	query := endpoint.Query()
+	query.Del("q")
+	endpoint.RawQuery = query.Encode()
+	return strings.Join([]string{
+		endpoint.String(),
+		req.Header.Get("Accept"),
+		req.Header.Get("Accept-Encoding"),
+		string(cid.Cid(env.FindProviders.Key).Hash()),
+	}, "\x00"), true
+}
+
+func recordCacheMetrics(ctx context.Context, result string) {
+	stats.RecordWithTags(ctx,
+		[]tag.Mutator{
+			tag.Upsert(keyName, "FindProviders"),
+			tag.Upsert(keyCacheResult, result),
+		},
+		measureCacheRequests.M(1),
+	)
+}
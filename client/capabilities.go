@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ipld/edelweiss/services"
+)
+
+// identifyTimeout bounds the Identify call made by NewClient when capabilities are negotiated eagerly.
+const identifyTimeout = 10 * time.Second
+
+// UnsupportedMethodError is returned without contacting the server when a method is called
+// which the server does not advertise in its Identify response.
+// It matches services.ErrSchema, which the protocol client returns when the server rejects an unknown method.
+type UnsupportedMethodError struct {
+	Method string
+}
+
+func (e *UnsupportedMethodError) Error() string {
+	return fmt.Sprintf("method %s is not supported by the delegated routing server", e.Method)
+}
+
+func (e *UnsupportedMethodError) Is(target error) bool {
+	return target == services.ErrSchema
+}
+
+type capabilities struct {
+	eager   bool
+	refresh time.Duration
+	checker *HealthChecker
+
+	lk         sync.Mutex
+	refreshing chan struct{} // closed when the identification in flight completes, nil if there is none
+}
+
+// WithCapabilityNegotiation makes the client learn the methods supported by the server with Identify,
+// and fail calls to any other method with an UnsupportedMethodError.
+// If eager is set, the server is identified by NewClient, otherwise it is identified before the first call.
+// The capabilities are identified again when they are older than refresh, so that server upgrades are noticed.
+// Servers which do not implement Identify are assumed to support every method.
+func WithCapabilityNegotiation(eager bool, refresh time.Duration) ClientOption {
+	return func(c *Client) error {
+		if refresh <= 0 {
+			return errors.New("capabilities refresh interval must be positive")
+		}
+		c.caps = &capabilities{eager: eager, refresh: refresh}
+		return nil
+	}
+}
+
+// Capabilities returns the methods advertised by the server, identifying it if needed.
+// The result is nil if the server does not implement Identify.
+func (fp *Client) Capabilities(ctx context.Context) ([]string, error) {
+	if fp.caps == nil {
+		methods, err := fp.Identify(ctx)
+		if errors.Is(err, services.ErrSchema) {
+			return nil, nil
+		}
+		return methods, err
+	}
+	if err := fp.refreshCapabilities(ctx); err != nil {
+		return nil, err
+	}
+	return fp.caps.checker.Methods(), nil
+}
+
+// refreshCapabilities identifies the server if its capabilities are unknown or out of date.
+// Concurrent callers wait for a single identification, and stop waiting when their ctx is done.
+func (fp *Client) refreshCapabilities(ctx context.Context) error {
+	for {
+		fp.caps.lk.Lock()
+		if last := fp.caps.checker.lastChecked(); !last.IsZero() && time.Since(last) < fp.caps.refresh {
+			fp.caps.lk.Unlock()
+			return nil
+		}
+		refreshing := fp.caps.refreshing
+		if refreshing == nil {
+			break
+		}
+		fp.caps.lk.Unlock()
+		// the identification may be aborted by the context of its caller, in which case it is made again
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-refreshing:
+		}
+	}
+	refreshing := make(chan struct{})
+	fp.caps.refreshing = refreshing
+	fp.caps.lk.Unlock()
+
+	err := fp.caps.checker.Check(ctx)
+
+	fp.caps.lk.Lock()
+	fp.caps.refreshing = nil
+	fp.caps.lk.Unlock()
+	close(refreshing)
+	return err
+}
+
+// checkCapability returns an UnsupportedMethodError if the server is known not to support method.
+// If the server cannot be identified, the call is let through.
+func (fp *Client) checkCapability(ctx context.Context, method string) error {
+	if fp.caps == nil || method == "Identify" {
+		return nil
+	}
+	if err := fp.refreshCapabilities(ctx); err != nil {
+		logger.Infof("cannot identify delegated routing server capabilities (%v)", err)
+	}
+	if !fp.caps.checker.Supports(method) {
+		return &UnsupportedMethodError{Method: method}
+	}
+	return nil
+}
@@ -19,6 +19,8 @@ var logger = logging.Logger("service/client/delegatedrouting")
 type DelegatedRoutingClient interface {
 	FindProviders(ctx context.Context, key cid.Cid) ([]peer.AddrInfo, error)
 	FindProvidersAsync(ctx context.Context, key cid.Cid) (<-chan FindProvidersAsyncResult, error)
+	FindPeer(ctx context.Context, id peer.ID) (peer.AddrInfo, error)
+	FindPeerAsync(ctx context.Context, id peer.ID) (<-chan FindPeerAsyncResult, error)
 	GetIPNS(ctx context.Context, id []byte) ([]byte, error)
 	GetIPNSAsync(ctx context.Context, id []byte) (<-chan GetIPNSAsyncResult, error)
 	PutIPNS(ctx context.Context, id []byte, record []byte) error
@@ -33,21 +35,89 @@ type Client struct {
 
 	provider *Provider
 	identity crypto.PrivKey
+
+	retry   RetryPolicy
+	breaker *circuitBreaker
+	caps    *capabilities
+	limits  Limits
 }
 
 var _ DelegatedRoutingClient = (*Client)(nil)
 
+// ClientOption configures optional behavior of a Client.
+type ClientOption func(*Client) error
+
+// WithRetryPolicy makes the client retry idempotent calls which fail with a transient error.
+func WithRetryPolicy(p RetryPolicy) ClientOption {
+	return func(c *Client) error {
+		if p.MaxAttempts < 1 {
+			return errors.New("retry policy must allow at least one attempt")
+		}
+		c.retry = p
+		return nil
+	}
+}
+
+// WithCircuitBreaker makes the client stop calling the endpoint while it is failing.
+func WithCircuitBreaker(p CircuitBreakerPolicy) ClientOption {
+	return func(c *Client) error {
+		if p.FailureThreshold < 1 {
+			return errors.New("circuit breaker failure threshold must be positive")
+		}
+		c.breaker = newCircuitBreaker(p)
+		return nil
+	}
+}
+
 // NewClient creates a client.
 // The Provider and identity parameters are option. If they are nil, the `Provide` method will not function.
-func NewClient(c proto.DelegatedRouting_Client, p *Provider, identity crypto.PrivKey) (*Client, error) {
+func NewClient(c proto.DelegatedRouting_Client, p *Provider, identity crypto.PrivKey, opts ...ClientOption) (*Client, error) {
 	if p != nil && !p.Peer.ID.MatchesPublicKey(identity.GetPublic()) {
 		return nil, errors.New("identity does not match provider")
 	}
 
-	return &Client{
+	fp := &Client{
 		client:    c,
 		validator: ipns.Validator{},
 		provider:  p,
 		identity:  identity,
-	}, nil
+	}
+	for _, o := range opts {
+		if err := o(fp); err != nil {
+			return nil, err
+		}
+	}
+	if fp.caps != nil {
+		fp.caps.checker = NewHealthChecker(fp, fp.caps.refresh)
+		if fp.caps.eager {
+			ctx, cancel := context.WithTimeout(context.Background(), identifyTimeout)
+			defer cancel()
+			if err := fp.refreshCapabilities(ctx); err != nil {
+				logger.Infof("cannot identify delegated routing server capabilities (%v)", err)
+			}
+		}
+	}
+	return fp, nil
+}
+
+// Identify returns the names of the methods supported by the server.
+func (fp *Client) Identify(ctx context.Context) (methods []string, err error) {
+	ctx, endSpan := startSpan(ctx, "Client.Identify")
+	defer func() { endSpan(err) }()
+
+	var resps []*proto.DelegatedRouting_IdentifyResult
+	err = fp.call(ctx, "Identify", true, func(ctx context.Context) (err error) {
+		resps, err = fp.client.Identify(ctx, &proto.DelegatedRouting_IdentifyArg{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	methods = []string{}
+	for _, resp := range resps {
+		for _, m := range resp.Methods {
+			methods = append(methods, string(m))
+		}
+	}
+	return methods, nil
 }
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+// CompressionTransport is an http.RoundTripper which asks the server for zstd or gzip compressed responses
+// and decompresses them as they are streamed, so that results are delivered as soon as they are received.
+// The number of bytes received before and after decompression is recorded in metrics.
+//
+// CompressionTransport is installed in the HTTP client used by the protocol client, e.g.
+//
+//	hc := &http.Client{Transport: client.NewCompressionTransport(http.DefaultTransport)}
+//	q, err := proto.New_DelegatedRouting_Client(endpoint, proto.DelegatedRouting_Client_WithHTTPClient(hc))
+type CompressionTransport struct {
+	next http.RoundTripper
+}
+
+// NewCompressionTransport creates a transport negotiating compressed responses with the server.
+// If next is nil, http.DefaultTransport is used.
+func NewCompressionTransport(next http.RoundTripper) *CompressionTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &CompressionTransport{next: next}
+}
+
+func (t *CompressionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	// setting Accept-Encoding disables the transparent gzip support of http.Transport, so responses are decompressed below
+	req.Header.Set("Accept-Encoding", "zstd, gzip")
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	encoding := resp.Header.Get("Content-Encoding")
+	if encoding != "zstd" && encoding != "gzip" {
+		return resp, nil
+	}
+	resp.Body = &decompressingBody{ctx: req.Context(), encoding: encoding, orig: &countingReader{r: resp.Body}, closer: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// decompressingBody decompresses a response body. The decompressor is created on the first read,
+// since creating it reads the compression header, which would otherwise block the round trip.
+type decompressingBody struct {
+	ctx      context.Context
+	encoding string
+	orig     *countingReader
+	closer   io.Closer
+
+	lk           sync.Mutex // held while reading, as the body may be closed concurrently
+	r            io.ReadCloser
+	err          error
+	uncompressed int64
+	closeOnce    sync.Once
+}
+
+func (b *decompressingBody) Read(p []byte) (int, error) {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+	if b.r == nil && b.err == nil {
+		switch b.encoding {
+		case "zstd":
+			var dec *zstd.Decoder
+			if dec, b.err = zstd.NewReader(b.orig, zstd.WithDecoderConcurrency(1)); b.err == nil {
+				b.r = dec.IOReadCloser()
+			}
+		case "gzip":
+			var gz *gzip.Reader
+			if gz, b.err = gzip.NewReader(b.orig); b.err == nil {
+				b.r = gz
+			}
+		}
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	n, err := b.r.Read(p)
+	b.uncompressed += int64(n)
+	return n, err
+}
+
+func (b *decompressingBody) Close() error {
+	// closing the original body first unblocks a concurrent read
+	err := b.closer.Close()
+	b.closeOnce.Do(func() {
+		b.lk.Lock()
+		defer b.lk.Unlock()
+		if b.r != nil {
+			b.r.Close()
+		}
+		stats.RecordWithTags(b.ctx,
+			[]tag.Mutator{tag.Upsert(keyEncoding, b.encoding)},
+			measureCompressedBytes.M(b.orig.n),
+			measureUncompressedBytes.M(b.uncompressed),
+		)
+	})
+	return err
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
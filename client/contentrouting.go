@@ -2,28 +2,55 @@ package client
 
 import (
 	"context"
-	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/routing"
 	"github.com/multiformats/go-multihash"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type ContentRoutingClient struct {
-	client DelegatedRoutingClient
+	client     DelegatedRoutingClient
+	health     *HealthChecker
+	reprovider *Reprovider
 }
 
 var _ routing.ContentRouting = (*ContentRoutingClient)(nil)
 
-func NewContentRoutingClient(c DelegatedRoutingClient) *ContentRoutingClient {
-	return &ContentRoutingClient{client: c}
+// ContentRoutingOption configures optional behavior of a ContentRoutingClient.
+type ContentRoutingOption func(*ContentRoutingClient)
+
+// WithHealthChecker makes Ready report whether the endpoint probed by h is reachable and supports Provide.
+// The caller is responsible for starting and closing h.
+func WithHealthChecker(h *HealthChecker) ContentRoutingOption {
+	return func(c *ContentRoutingClient) {
+		c.health = h
+	}
+}
+
+// WithReprovider makes Provide and ProvideMany announce keys through r, which reprovides them
+// ahead of the AdvisoryTTL granted by the endpoint. The caller is responsible for starting and closing r.
+func WithReprovider(r *Reprovider) ContentRoutingOption {
+	return func(c *ContentRoutingClient) {
+		c.reprovider = r
+	}
+}
+
+func NewContentRoutingClient(c DelegatedRoutingClient, opts ...ContentRoutingOption) *ContentRoutingClient {
+	crc := &ContentRoutingClient{client: c}
+	for _, o := range opts {
+		o(crc)
+	}
+	return crc
 }
 
 func (c *ContentRoutingClient) Provide(ctx context.Context, key cid.Cid, announce bool) error {
 	var err error
 	recordMetrics := startMetrics(ctx, "ContentRoutingClient.Provide")
 	defer recordMetrics(err)
+	ctx, endSpan := startSpan(ctx, "ContentRoutingClient.Provide", attribute.Stringer("key", key), attribute.Bool("announce", announce))
+	defer func() { endSpan(err) }()
 
 	// If 'true' is
 	// passed, it also announces it, otherwise it is just kept in the local
@@ -32,7 +59,11 @@ func (c *ContentRoutingClient) Provide(ctx context.Context, key cid.Cid, announc
 		return nil
 	}
 
-	_, err = c.client.Provide(ctx, []cid.Cid{key}, 24*time.Hour)
+	if c.reprovider != nil {
+		err = c.reprovider.Provide(ctx, key)
+		return err
+	}
+	_, err = c.client.Provide(ctx, []cid.Cid{key}, DefaultReprovideTTL)
 	return err
 }
 
@@ -40,43 +71,61 @@ func (c *ContentRoutingClient) ProvideMany(ctx context.Context, keys []multihash
 	var err error
 	recordMetrics := startMetrics(ctx, "ContentRoutingClient.ProvideMany")
 	defer recordMetrics(err)
+	ctx, endSpan := startSpan(ctx, "ContentRoutingClient.ProvideMany", attribute.Int("keys", len(keys)))
+	defer func() { endSpan(err) }()
 
 	keysAsCids := make([]cid.Cid, 0, len(keys))
 	for _, m := range keys {
 		keysAsCids = append(keysAsCids, cid.NewCidV1(cid.Raw, m))
 	}
-	_, err = c.client.Provide(ctx, keysAsCids, 24*time.Hour)
+	if c.reprovider != nil {
+		err = c.reprovider.Provide(ctx, keysAsCids...)
+		return err
+	}
+	_, err = c.client.Provide(ctx, keysAsCids, DefaultReprovideTTL)
 	return err
 }
 
 // Ready is part of the existing `ProvideMany` interface, but can be used more generally to determine if the routing client
 // has a working connection.
 func (c *ContentRoutingClient) Ready() bool {
-	// TODO: currently codegen does not expose a way to access the state of the connection
-	// Once either that is exposed, or the `Identify` portion of the reframe spec is implemented,
-	// a more nuanced response for this method will be possible.
-	return true
+	// Clients with a circuit breaker report whether their endpoint is currently failing.
+	if r, ok := c.client.(interface{ Ready() bool }); ok && !r.Ready() {
+		return false
+	}
+	// Without a health checker, the state of the connection is unknown and assumed to be working.
+	if c.health == nil {
+		return true
+	}
+	return c.health.Reachable() && c.health.Supports("Provide")
 }
 
 func (c *ContentRoutingClient) FindProvidersAsync(ctx context.Context, key cid.Cid, numResults int) <-chan peer.AddrInfo {
 	var err error
 	recordMetrics := startMetrics(ctx, "ContentRoutingClient.FindProvidersAsync")
+	ctx, endSpan := startSpan(ctx, "ContentRoutingClient.FindProvidersAsync", attribute.Stringer("key", key), attribute.Int("num_results", numResults))
 
 	addrInfoCh := make(chan peer.AddrInfo)
 	resultCh, err := c.client.FindProvidersAsync(ctx, key)
 	if err != nil {
 		close(addrInfoCh)
 		recordMetrics(err)
+		endSpan(err)
 		return addrInfoCh
 	}
+	streamMetrics := startStreamMetrics(ctx, "ContentRoutingClient.FindProvidersAsync")
 	go func() {
 		defer recordMetrics(nil)
+		defer endSpan(nil)
+		defer streamMetrics.done()
 		numProcessed := 0
 		closed := false
 		for asyncResult := range resultCh {
 			if asyncResult.Err != nil {
 				logger.Infof("find providers async emitted a transient error (%v)", asyncResult.Err)
+				streamMetrics.result(0, asyncResult.Err)
 			} else {
+				streamMetrics.result(deliverable(len(asyncResult.AddrInfo), numProcessed, numResults), nil)
 				for _, peerAddr := range asyncResult.AddrInfo {
 					if numResults <= 0 || numProcessed < numResults {
 						addrInfoCh <- peerAddr
@@ -95,3 +144,14 @@ func (c *ContentRoutingClient) FindProvidersAsync(ctx context.Context, key cid.C
 	}()
 	return addrInfoCh
 }
+
+// deliverable returns how many of n providers are delivered after processed ones, given the requested number of results.
+func deliverable(n, processed, numResults int) int {
+	if numResults <= 0 || processed+n <= numResults {
+		return n
+	}
+	if processed >= numResults {
+		return 0
+	}
+	return numResults - processed
+}
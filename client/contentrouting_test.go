@@ -30,6 +30,14 @@ func (t TestDelegatedRoutingClient) FindProvidersAsync(ctx context.Context, key
 	return ch, nil
 }
 
+func (t TestDelegatedRoutingClient) FindPeer(ctx context.Context, id peer.ID) (peer.AddrInfo, error) {
+	panic("not supported")
+}
+
+func (t TestDelegatedRoutingClient) FindPeerAsync(ctx context.Context, id peer.ID) (<-chan FindPeerAsyncResult, error) {
+	panic("not supported")
+}
+
 func (t TestDelegatedRoutingClient) GetIPNS(ctx context.Context, id []byte) ([]byte, error) {
 	panic("not supported")
 }
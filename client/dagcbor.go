@@ -0,0 +1,307 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/codec/dagjson"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+)
+
+const (
+	// MediaTypeDagJSON is the media type of DAG-JSON encoded messages, in which results are separated by new lines.
+	MediaTypeDagJSON = "application/vnd.ipfs.rpc+dag-json"
+	// MediaTypeDagCBOR is the media type of DAG-CBOR encoded messages, in which results are concatenated.
+	MediaTypeDagCBOR = "application/vnd.ipfs.rpc+dag-cbor"
+	// mediaTypeVersion is the protocol version parameter of the media types.
+	mediaTypeVersion = "version=1"
+)
+
+// AcceptsMediaType reports whether the Accept header value accept lists mediaType with a non-zero quality.
+func AcceptsMediaType(accept string, mediaType string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(part)
+		if err != nil || mt != mediaType {
+			continue
+		}
+		if q, ok := params["q"]; ok {
+			if v, err := strconv.ParseFloat(q, 64); err != nil || v == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// IsMediaType reports whether the Content-Type header value contentType is mediaType.
+func IsMediaType(contentType string, mediaType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mt == mediaType
+}
+
+// DagCBORTransport is an http.RoundTripper which asks the server for DAG-CBOR encoded responses
+// and transcodes them to the DAG-JSON expected by the protocol client.
+// Servers which do not support DAG-CBOR keep answering in DAG-JSON.
+// Once the server has answered in DAG-CBOR, request bodies are sent DAG-CBOR encoded as well.
+//
+// DagCBORTransport is installed in the HTTP client used by the protocol client, e.g.
+//
+//	hc := &http.Client{Transport: client.NewDagCBORTransport(http.DefaultTransport)}
+//	q, err := proto.New_DelegatedRouting_Client(endpoint, proto.DelegatedRouting_Client_WithHTTPClient(hc))
+//
+// When it is assembled by NewTransport with limits, DAG-CBOR values larger than MaxResultBytes are not decoded:
+// the stream fails with a LimitError instead, as it would once transcoded.
+type DagCBORTransport struct {
+	next           http.RoundTripper
+	maxResultBytes int64
+
+	lk         sync.Mutex
+	serverCBOR bool // whether the server is known to speak DAG-CBOR
+}
+
+// NewDagCBORTransport creates a transport negotiating DAG-CBOR with the server.
+// If next is nil, http.DefaultTransport is used.
+func NewDagCBORTransport(next http.RoundTripper) *DagCBORTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &DagCBORTransport{next: next}
+}
+
+func (t *DagCBORTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept", MediaTypeDagCBOR+"; "+mediaTypeVersion+", "+MediaTypeDagJSON+"; "+mediaTypeVersion)
+	if req.Body != nil && req.Method == http.MethodPost && t.knownCBOR() {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if body, err = transcodeDagJSONToDagCBOR(body); err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+		req.ContentLength = int64(len(body))
+		req.Header.Set("Content-Type", MediaTypeDagCBOR+"; "+mediaTypeVersion)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if !IsMediaType(resp.Header.Get("Content-Type"), MediaTypeDagCBOR) {
+		return resp, nil
+	}
+	t.lk.Lock()
+	t.serverCBOR = true
+	t.lk.Unlock()
+
+	pr, pw := io.Pipe()
+	go func(body io.ReadCloser) {
+		pw.CloseWithError(transcodeDagCBORStream(pw, body, t.maxResultBytes))
+	}(resp.Body)
+	resp.Body = &transcodedBody{PipeReader: pr, orig: resp.Body}
+	resp.Header.Set("Content-Type", MediaTypeDagJSON+"; "+mediaTypeVersion)
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	return resp, nil
+}
+
+func (t *DagCBORTransport) knownCBOR() bool {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	return t.serverCBOR
+}
+
+// transcodedBody closes the original response body along with the transcoded stream read from it.
+type transcodedBody struct {
+	*io.PipeReader
+	orig io.ReadCloser
+}
+
+func (b *transcodedBody) Close() error {
+	b.PipeReader.Close()
+	return b.orig.Close()
+}
+
+// transcodeDagCBORStream writes the concatenated DAG-CBOR values read from r to w as new line separated DAG-JSON.
+// Values larger than maxBytes, unless it is zero, fail the stream with a LimitError before they are decoded.
+func transcodeDagCBORStream(w io.Writer, r io.Reader, maxBytes int64) error {
+	sc := &cborScanner{r: bufio.NewReader(r), max: maxBytes}
+	opts := dagcbor.DecodeOptions{AllowLinks: true}
+	for {
+		if _, err := sc.r.Peek(1); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		sc.buf = sc.buf[:0]
+		if err := sc.item(); err != nil {
+			return err
+		}
+		nb := basicnode.Prototype.Any.NewBuilder()
+		if err := opts.Decode(nb, bytes.NewReader(sc.buf)); err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := dagjson.Encode(nb.Build(), &buf); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+}
+
+// cborScanner reads the bytes of a CBOR data item into buf, following the lengths in the item heads
+// without decoding the item, so that an item larger than max is refused before its declared lengths are allocated.
+type cborScanner struct {
+	r   *bufio.Reader
+	max int64
+	buf []byte
+}
+
+const cborBreak = 0xff
+
+// item reads a complete data item.
+func (sc *cborScanner) item() error {
+	major, info, arg, err := sc.head()
+	if err != nil {
+		return err
+	}
+	indefinite := info == 31
+	switch major {
+	case 0, 1:
+		return nil
+	case 2, 3: // byte and text strings
+		if !indefinite {
+			return sc.read(arg)
+		}
+		return sc.untilBreak(func() error {
+			chunkMajor, chunkInfo, n, err := sc.head()
+			if err != nil {
+				return err
+			}
+			if chunkMajor != major || chunkInfo == 31 {
+				return errors.New("cbor: invalid indefinite length string chunk")
+			}
+			return sc.read(n)
+		})
+	case 4, 5: // arrays and maps
+		items := arg
+		if major == 5 {
+			items *= 2
+		}
+		if indefinite {
+			return sc.untilBreak(sc.item)
+		}
+		for i := uint64(0); i < items; i++ {
+			if err := sc.item(); err != nil {
+				return err
+			}
+		}
+		return nil
+	case 6: // tags
+		return sc.item()
+	default: // simple values and floats, whose argument is their value
+		if indefinite {
+			return errors.New("cbor: unexpected break")
+		}
+		return nil
+	}
+}
+
+// untilBreak reads items with next until the break which ends an indefinite length item.
+func (sc *cborScanner) untilBreak(next func() error) error {
+	for {
+		b, err := sc.r.Peek(1)
+		if err != nil {
+			return noEOF(err)
+		}
+		if b[0] == cborBreak {
+			return sc.read(1)
+		}
+		if err := next(); err != nil {
+			return err
+		}
+	}
+}
+
+// head reads the head of a data item, returning its major type, additional information and argument.
+func (sc *cborScanner) head() (major byte, info byte, arg uint64, err error) {
+	if err = sc.read(1); err != nil {
+		return 0, 0, 0, err
+	}
+	b := sc.buf[len(sc.buf)-1]
+	major, info = b>>5, b&0x1f
+	var size uint64
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		size = 1 << (info - 24)
+	case info == 31 && major >= 2 && major != 6:
+		return major, info, 0, nil
+	default:
+		return 0, 0, 0, errors.New("cbor: invalid additional information")
+	}
+	if err = sc.read(size); err != nil {
+		return 0, 0, 0, err
+	}
+	for _, b := range sc.buf[uint64(len(sc.buf))-size:] {
+		arg = arg<<8 | uint64(b)
+	}
+	return major, info, arg, nil
+}
+
+// read appends the next n bytes to buf.
+func (sc *cborScanner) read(n uint64) error {
+	if sc.max > 0 && n > uint64(sc.max)-uint64(len(sc.buf)) {
+		return &LimitError{Limit: "result bytes", Max: sc.max}
+	}
+	// the buffer grows with the bytes actually received, whatever the declared length
+	for n > 0 {
+		chunk := n
+		if chunk > cborChunkSize {
+			chunk = cborChunkSize
+		}
+		start := len(sc.buf)
+		sc.buf = append(sc.buf, make([]byte, chunk)...)
+		if _, err := io.ReadFull(sc.r, sc.buf[start:]); err != nil {
+			return noEOF(err)
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+const cborChunkSize = 32 << 10
+
+// noEOF reports the end of the stream within a data item as unexpected.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func transcodeDagJSONToDagCBOR(msg []byte) ([]byte, error) {
+	n, err := ipld.Decode(msg, dagjson.Decode)
+	if err != nil {
+		return nil, err
+	}
+	return ipld.Encode(n, dagcbor.Encode)
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTranscodeDagCBORStream(t *testing.T) {
+	// a definite length map, followed by an indefinite length array holding an indefinite length string
+	stream := []byte{
+		0xa1, 0x61, 'a', 0x01,
+		0x9f, 0x7f, 0x61, 'b', 0x61, 'c', 0xff, 0x02, 0xff,
+	}
+	var out bytes.Buffer
+	if err := transcodeDagCBORStream(&out, bytes.NewReader(stream), 16); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "{\"a\":1}\n[\"bc\",2]\n"; out.String() != expect {
+		t.Errorf("expecting %q, got %q", expect, out.String())
+	}
+}
+
+func TestTranscodeDagCBORStreamLimits(t *testing.T) {
+	// a byte string declaring 2^62 bytes, of which only a few are sent
+	hostile := []byte{0x5b, 0x40, 0, 0, 0, 0, 0, 0, 0, 'x', 'y', 'z'}
+
+	var limitErr *LimitError
+	err := transcodeDagCBORStream(io.Discard, bytes.NewReader(hostile), 1024)
+	if !errors.As(err, &limitErr) || limitErr.Limit != "result bytes" {
+		t.Errorf("expecting a result bytes limit error, got %v", err)
+	}
+	// without a limit, the buffer only grows with the bytes received
+	if err := transcodeDagCBORStream(io.Discard, bytes.NewReader(hostile), 0); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expecting the truncated value to fail, got %v", err)
+	}
+
+	// the values before the one exceeding the limit are passed on
+	var out bytes.Buffer
+	long := append([]byte{0x78, 64}, strings.Repeat("x", 64)...)
+	err = transcodeDagCBORStream(&out, bytes.NewReader(append([]byte{0x01}, long...)), 32)
+	if !errors.As(err, &limitErr) || out.String() != "1\n" {
+		t.Errorf("expecting the first value and a limit error, got %q and %v", out.String(), err)
+	}
+}
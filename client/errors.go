@@ -0,0 +1,32 @@
+package client
+
+import (
+	"errors"
+
+	"github.com/ipld/edelweiss/services"
+)
+
+// errorCause returns the LimitError reported by the protocol client as the cause of a protocol error
+// or by the server for a request too large, the RateLimitError of a throttled call,
+// or the RejectionError carried by a service error, or err itself otherwise.
+func errorCause(err error) error {
+	var protoErr services.ErrProto
+	var limitErr *LimitError
+	if errors.As(err, &protoErr) && errors.As(protoErr.Cause, &limitErr) {
+		return limitErr
+	}
+	if limitErr, ok := parseRequestLimit(err); ok {
+		return limitErr
+	}
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return rateLimitErr
+	}
+	if rateLimitErr, ok := parseRateLimit(err); ok {
+		return rateLimitErr
+	}
+	if rejection, ok := parseRejection(err); ok {
+		return rejection
+	}
+	return err
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+
+	proto "github.com/ipfs/go-delegated-routing/gen/proto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/routing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// FindPeer returns the addresses of the peer with the given ID, merged across all results.
+// It returns routing.ErrNotFound if the server knows no addresses for the peer.
+func (fp *Client) FindPeer(ctx context.Context, id peer.ID) (info peer.AddrInfo, err error) {
+	ctx, endSpan := startSpan(ctx, "Client.FindPeer", attribute.Stringer("peer", id))
+	defer func() { endSpan(err) }()
+
+	var resps []*proto.FindPeerResponse
+	err = fp.call(ctx, "FindPeer", true, func(ctx context.Context) (err error) {
+		resps, err = fp.client.FindPeer(ctx, &proto.FindPeerRequest{ID: []byte(id)})
+		return err
+	})
+	if err != nil {
+		return peer.AddrInfo{}, err
+	}
+	merger := newAddrMerger()
+	for _, resp := range resps {
+		if err := fp.checkProviders(len(resp.Peers)); err != nil {
+			return peer.AddrInfo{}, err
+		}
+		merger.add(parseFindPeerResponse(id, resp))
+	}
+	infos := merger.infos()
+	if len(infos) == 0 {
+		return peer.AddrInfo{}, routing.ErrNotFound
+	}
+	return infos[0], nil
+}
+
+type FindPeerAsyncResult struct {
+	AddrInfo []peer.AddrInfo
+	Err      error
+}
+
+// FindPeerAsync processes the stream of raw protocol async results into a stream of parsed results.
+// Results for peers other than the requested one are dropped.
+func (fp *Client) FindPeerAsync(ctx context.Context, id peer.ID) (<-chan FindPeerAsyncResult, error) {
+	ctx, endSpan := startSpan(ctx, "Client.FindPeerAsync", attribute.Stringer("peer", id))
+	var ch0 <-chan proto.DelegatedRouting_FindPeer_AsyncResult
+	err := fp.call(ctx, "FindPeer", true, func(ctx context.Context) (err error) {
+		ch0, err = fp.client.FindPeer_Async(ctx, &proto.FindPeerRequest{ID: []byte(id)})
+		return err
+	})
+	if err != nil {
+		endSpan(err)
+		return nil, err
+	}
+	ch1 := make(chan FindPeerAsyncResult, 1)
+	go func() {
+		defer endSpan(nil)
+		defer close(ch1)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case r0, ok := <-ch0:
+				if !ok {
+					return
+				}
+
+				var r1 FindPeerAsyncResult
+
+				r1.Err = errorCause(r0.Err)
+				if r0.Resp != nil {
+					if err := fp.checkProviders(len(r0.Resp.Peers)); err != nil {
+						r1.Err = err
+					} else {
+						r1.AddrInfo = parseFindPeerResponse(id, r0.Resp)
+					}
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case ch1 <- r1:
+				}
+			}
+		}
+	}()
+	return ch1, nil
+}
+
+func parseFindPeerResponse(id peer.ID, resp *proto.FindPeerResponse) []peer.AddrInfo {
+	infos := []peer.AddrInfo{}
+	for i := range resp.Peers {
+		info := ParseNodeAddresses(&resp.Peers[i])
+		if info.ID != id {
+			logger.Infof("dropping find peer result for unrequested peer %v", info.ID)
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
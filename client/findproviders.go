@@ -8,16 +8,27 @@ import (
 	"github.com/ipld/edelweiss/values"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/multiformats/go-multiaddr"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-func (fp *Client) FindProviders(ctx context.Context, key cid.Cid) ([]peer.AddrInfo, error) {
-	resps, err := fp.client.FindProviders(ctx, cidsToFindProvidersRequest(key))
+func (fp *Client) FindProviders(ctx context.Context, key cid.Cid) (infos []peer.AddrInfo, err error) {
+	ctx, endSpan := startSpan(ctx, "Client.FindProviders", attribute.Stringer("key", key))
+	defer func() { endSpan(err) }()
+
+	var resps []*proto.FindProvidersResponse
+	err = fp.call(ctx, "FindProviders", true, func(ctx context.Context) (err error) {
+		resps, err = fp.client.FindProviders(ctx, cidsToFindProvidersRequest(key))
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	infos := []peer.AddrInfo{}
+	infos = []peer.AddrInfo{}
 	for _, resp := range resps {
-		infos = append(infos, parseFindProvidersResponse(resp)...)
+		if err := fp.checkProviders(len(resp.Providers)); err != nil {
+			return nil, err
+		}
+		infos = append(infos, parseFindProvidersResponse(ctx, resp)...)
 	}
 	return infos, nil
 }
@@ -30,13 +41,22 @@ type FindProvidersAsyncResult struct {
 // FindProvidersAsync processes the stream of raw protocol async results into a stream of parsed results.
 // Specifically, FindProvidersAsync converts protocol-level provider descriptions into peer address infos.
 func (fp *Client) FindProvidersAsync(ctx context.Context, key cid.Cid) (<-chan FindProvidersAsyncResult, error) {
-	protoRespCh, err := fp.client.FindProviders_Async(ctx, cidsToFindProvidersRequest(key))
+	ctx, endSpan := startSpan(ctx, "Client.FindProvidersAsync", attribute.Stringer("key", key))
+	var protoRespCh <-chan proto.DelegatedRouting_FindProviders_AsyncResult
+	err := fp.call(ctx, "FindProviders", true, func(ctx context.Context) (err error) {
+		protoRespCh, err = fp.client.FindProviders_Async(ctx, cidsToFindProvidersRequest(key))
+		return err
+	})
 	if err != nil {
+		endSpan(err)
 		return nil, err
 	}
 
+	metrics := startStreamMetrics(ctx, "Client.FindProvidersAsync")
 	parsedRespCh := make(chan FindProvidersAsyncResult, 1)
 	go func() {
+		defer endSpan(nil)
+		defer metrics.done()
 		defer close(parsedRespCh)
 		for {
 			select {
@@ -49,10 +69,15 @@ func (fp *Client) FindProvidersAsync(ctx context.Context, key cid.Cid) (<-chan F
 
 				var parsedAsyncResp FindProvidersAsyncResult
 
-				parsedAsyncResp.Err = par.Err
+				parsedAsyncResp.Err = errorCause(par.Err)
 				if par.Resp != nil {
-					parsedAsyncResp.AddrInfo = parseFindProvidersResponse(par.Resp)
+					if err := fp.checkProviders(len(par.Resp.Providers)); err != nil {
+						parsedAsyncResp.Err = err
+					} else {
+						parsedAsyncResp.AddrInfo = parseFindProvidersResponse(ctx, par.Resp)
+					}
 				}
+				metrics.result(len(parsedAsyncResp.AddrInfo), parsedAsyncResp.Err)
 
 				select {
 				case <-ctx.Done():
@@ -73,14 +98,25 @@ func cidsToFindProvidersRequest(cid cid.Cid) *proto.FindProvidersRequest {
 	}
 }
 
-func parseFindProvidersResponse(resp *proto.FindProvidersResponse) []peer.AddrInfo {
+// parseFindProvidersResponse returns the addresses of the Bitswap providers in resp,
+// recording the providers and addresses dropped in metrics.
+func parseFindProvidersResponse(ctx context.Context, resp *proto.FindProvidersResponse) []peer.AddrInfo {
 	infos := []peer.AddrInfo{}
+	var noBitswap, badAddrs int
 	for _, prov := range resp.Providers {
 		if !providerSupportsBitswap(prov.ProviderProto) {
+			noBitswap++
+			continue
+		}
+		if prov.ProviderNode.Peer == nil { // ignore non-peer nodes
 			continue
 		}
-		infos = append(infos, parseProtoNodeToAddrInfo(prov.ProviderNode)...)
+		info, dropped := parseNodeAddresses(prov.ProviderNode.Peer)
+		badAddrs += dropped
+		infos = append(infos, info)
 	}
+	recordDropped(ctx, "Bitswap", noBitswap)
+	recordDropped(ctx, "Multiaddr", badAddrs)
 	return infos
 }
 
@@ -104,30 +140,39 @@ func parseProtoNodeToAddrInfo(n proto.Node) []peer.AddrInfo {
 
 // ParseNodeAddresses parses peer node addresses from the protocol structure Peer.
 func ParseNodeAddresses(n *proto.Peer) peer.AddrInfo {
+	info, _ := parseNodeAddresses(n)
+	return info
+}
+
+// parseNodeAddresses parses peer node addresses, also returning the number of addresses dropped.
+func parseNodeAddresses(n *proto.Peer) (peer.AddrInfo, int) {
 	peerID := peer.ID(n.ID)
 	info := peer.AddrInfo{ID: peerID}
+	dropped := 0
 	for _, addrBytes := range n.Multiaddresses {
 		ma, err := multiaddr.NewMultiaddrBytes(addrBytes)
 		if err != nil {
 			logger.Infof("cannot parse multiaddress (%v)", err)
+			dropped++
 			continue
 		}
 		// drop multiaddrs that end in /p2p/peerID
 		_, last := multiaddr.SplitLast(ma)
 		if last != nil && last.Protocol().Code == multiaddr.P_P2P {
 			logger.Infof("dropping provider multiaddress %v ending in /p2p/peerid", ma)
+			dropped++
 			continue
 		}
 		info.Addrs = append(info.Addrs, ma)
 	}
-	return info
+	return info, dropped
 }
 
 // ToProtoPeer creates a protocol Peer structure from address info.
 func ToProtoPeer(ai peer.AddrInfo) *proto.Peer {
 	p := proto.Peer{
 		ID:             values.Bytes(ai.ID),
-		Multiaddresses: make(proto.AnonList21, 0),
+		Multiaddresses: make(proto.AnonList27, 0),
 	}
 
 	for _, addr := range ai.Addrs {
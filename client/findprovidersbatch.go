@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ipfs/go-cid"
+	proto "github.com/ipfs/go-delegated-routing/gen/proto"
+	"github.com/ipld/edelweiss/services"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// FindProvidersBatchAsyncResult is a result of a batched FindProviders call.
+// Key is the key the result answers; it is undefined for errors which are not specific to a key,
+// such as the rejection of the whole batch.
+type FindProvidersBatchAsyncResult struct {
+	Key      cid.Cid
+	AddrInfo []peer.AddrInfo
+	Err      error
+}
+
+// FindProvidersBatch looks up the providers of several keys in a single request.
+// Results are streamed as the server produces them, in no particular order, and are tagged with the key they answer.
+// If the server does not support batched lookups, the keys are looked up one request at a time.
+func (fp *Client) FindProvidersBatch(ctx context.Context, keys []cid.Cid) (<-chan FindProvidersBatchAsyncResult, error) {
+	ctx, endSpan := startSpan(ctx, "Client.FindProvidersBatch", attribute.Int("keys", len(keys)))
+	var ch0 <-chan proto.DelegatedRouting_FindProvidersBatch_AsyncResult
+	err := fp.call(ctx, "FindProvidersBatch", true, func(ctx context.Context) (err error) {
+		ch0, err = fp.client.FindProvidersBatch_Async(ctx, cidsToFindProvidersBatchRequest(keys))
+		return err
+	})
+	if errors.Is(err, services.ErrSchema) {
+		logger.Infof("server does not support batched find providers, looking up %d keys one at a time", len(keys))
+		return fp.findProvidersEach(ctx, keys, endSpan), nil
+	}
+	if err != nil {
+		endSpan(err)
+		return nil, err
+	}
+	metrics := startStreamMetrics(ctx, "Client.FindProvidersBatch")
+	ch1 := make(chan FindProvidersBatchAsyncResult, 1)
+	go func() {
+		defer endSpan(nil)
+		defer metrics.done()
+		defer close(ch1)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case r0, ok := <-ch0:
+				if !ok {
+					return
+				}
+
+				var r1 FindProvidersBatchAsyncResult
+
+				r1.Err = errorCause(r0.Err)
+				if r0.Resp != nil {
+					r1.Key = cid.Cid(r0.Resp.Key)
+					if len(r0.Resp.Error) > 0 {
+						r1.Err = errorCause(services.ErrService{Cause: errors.New(string(r0.Resp.Error[0]))})
+					} else if err := fp.checkProviders(len(r0.Resp.Providers)); err != nil {
+						r1.Err = err
+					} else {
+						r1.AddrInfo = parseFindProvidersResponse(ctx, &proto.FindProvidersResponse{Providers: r0.Resp.Providers})
+					}
+				}
+				metrics.result(len(r1.AddrInfo), r1.Err)
+
+				select {
+				case <-ctx.Done():
+					return
+				case ch1 <- r1:
+				}
+			}
+		}
+	}()
+	return ch1, nil
+}
+
+// findProvidersEach emulates a batched lookup with one FindProviders request per key.
+// endSpan is called once the lookups are done.
+func (fp *Client) findProvidersEach(ctx context.Context, keys []cid.Cid, endSpan func(error)) <-chan FindProvidersBatchAsyncResult {
+	ch := make(chan FindProvidersBatchAsyncResult, 1)
+	go func() {
+		defer endSpan(nil)
+		defer close(ch)
+		for _, key := range keys {
+			send := func(r FindProvidersBatchAsyncResult) bool {
+				select {
+				case <-ctx.Done():
+					return false
+				case ch <- r:
+					return true
+				}
+			}
+			rch, err := fp.FindProvidersAsync(ctx, key)
+			if err != nil {
+				if !send(FindProvidersBatchAsyncResult{Key: key, Err: err}) {
+					return
+				}
+				continue
+			}
+			for r := range rch {
+				if !send(FindProvidersBatchAsyncResult{Key: key, AddrInfo: r.AddrInfo, Err: r.Err}) {
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+func cidsToFindProvidersBatchRequest(keys []cid.Cid) *proto.FindProvidersBatchRequest {
+	links := make(proto.LinksList, len(keys))
+	for i, key := range keys {
+		links[i] = proto.LinkToAny(key)
+	}
+	return &proto.FindProvidersBatchRequest{Keys: links}
+}
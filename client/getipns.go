@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"errors"
 
 	ipns "github.com/ipfs/boxo/ipns"
 	"github.com/ipfs/go-delegated-routing/gen/proto"
@@ -9,18 +10,28 @@ import (
 	"github.com/libp2p/go-libp2p/core/routing"
 )
 
-func (fp *Client) GetIPNS(ctx context.Context, id []byte) ([]byte, error) {
+func (fp *Client) GetIPNS(ctx context.Context, id []byte) (_ []byte, err error) {
+	ctx, endSpan := startSpan(ctx, "Client.GetIPNS")
+	defer func() { endSpan(err) }()
+
 	resps, err := fp.GetIPNSAsync(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	records := [][]byte{}
+	var rejection *RejectionError
 	for resp := range resps {
 		if resp.Err == nil {
 			records = append(records, resp.Record)
+		} else if rejection == nil {
+			errors.As(resp.Err, &rejection)
 		}
 	}
 	if len(records) == 0 {
+		// a rejection of the call is told apart from a missing record
+		if rejection != nil {
+			return nil, rejection
+		}
 		return nil, routing.ErrNotFound
 	}
 	best, err := fp.validator.Select(ipns.RecordKey(peer.ID(id)), records)
@@ -36,12 +47,19 @@ type GetIPNSAsyncResult struct {
 }
 
 func (fp *Client) GetIPNSAsync(ctx context.Context, id []byte) (<-chan GetIPNSAsyncResult, error) {
-	ch0, err := fp.client.GetIPNS_Async(ctx, &proto.GetIPNSRequest{ID: id})
+	ctx, endSpan := startSpan(ctx, "Client.GetIPNSAsync")
+	var ch0 <-chan proto.DelegatedRouting_GetIPNS_AsyncResult
+	err := fp.call(ctx, "GetIPNS", true, func(ctx context.Context) (err error) {
+		ch0, err = fp.client.GetIPNS_Async(ctx, &proto.GetIPNSRequest{ID: id})
+		return err
+	})
 	if err != nil {
+		endSpan(err)
 		return nil, err
 	}
 	ch1 := make(chan GetIPNSAsyncResult, 1)
 	go func() {
+		defer endSpan(nil)
 		defer close(ch1)
 		for {
 			select {
@@ -55,7 +73,7 @@ func (fp *Client) GetIPNSAsync(ctx context.Context, id []byte) (<-chan GetIPNSAs
 				var r1 GetIPNSAsyncResult
 
 				if r0.Err != nil {
-					r1.Err = r0.Err
+					r1.Err = errorCause(r0.Err)
 					select {
 					case <-ctx.Done():
 						return
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ipld/edelweiss/services"
+)
+
+// Identifier is implemented by clients which can ask their endpoint for the methods it supports.
+type Identifier interface {
+	Identify(ctx context.Context) ([]string, error)
+}
+
+var _ Identifier = (*Client)(nil)
+
+// HealthChecker probes a delegated routing endpoint with Identify at a regular interval,
+// tracking whether the endpoint is reachable and which methods it advertises.
+type HealthChecker struct {
+	identifier Identifier
+	interval   time.Duration
+
+	lk        sync.RWMutex
+	checked   time.Time
+	reachable bool
+	methods   map[string]bool // nil if the endpoint does not support Identify
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewHealthChecker creates a health checker probing the endpoint of id every interval once started.
+func NewHealthChecker(id Identifier, interval time.Duration) *HealthChecker {
+	return &HealthChecker{
+		identifier: id,
+		interval:   interval,
+		done:       make(chan struct{}),
+	}
+}
+
+// Start begins probing the endpoint in the background. The first probe is made immediately.
+func (h *HealthChecker) Start() {
+	h.startOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		h.cancel = cancel
+		go h.run(ctx)
+	})
+}
+
+// Close stops probing the endpoint and waits for the background probe to return.
+func (h *HealthChecker) Close() {
+	h.closeOnce.Do(func() {
+		h.startOnce.Do(func() { close(h.done) })
+		if h.cancel != nil {
+			h.cancel()
+			<-h.done
+		}
+	})
+}
+
+func (h *HealthChecker) run(ctx context.Context) {
+	defer close(h.done)
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, h.interval)
+		err := h.Check(checkCtx)
+		cancel()
+		if err != nil {
+			logger.Infof("delegated routing health check failed (%v)", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check probes the endpoint once and updates the health state.
+func (h *HealthChecker) Check(ctx context.Context) error {
+	methods, err := h.identifier.Identify(ctx)
+	if errors.Is(err, context.Canceled) {
+		// the check was aborted, which says nothing about the endpoint
+		return err
+	}
+
+	h.lk.Lock()
+	defer h.lk.Unlock()
+	h.checked = time.Now()
+	switch {
+	case err == nil:
+		h.reachable = true
+		h.methods = make(map[string]bool, len(methods))
+		for _, m := range methods {
+			h.methods[m] = true
+		}
+	case errors.Is(err, services.ErrSchema):
+		// the endpoint answered, but does not implement Identify
+		h.reachable = true
+		h.methods = nil
+		err = nil
+	default:
+		h.reachable = false
+	}
+	return err
+}
+
+// Reachable reports whether the last probe reached the endpoint.
+// It is false until the first probe completes.
+func (h *HealthChecker) Reachable() bool {
+	h.lk.RLock()
+	defer h.lk.RUnlock()
+	return !h.checked.IsZero() && h.reachable
+}
+
+// lastChecked returns the time of the last completed probe, or the zero time if there was none.
+func (h *HealthChecker) lastChecked() time.Time {
+	h.lk.RLock()
+	defer h.lk.RUnlock()
+	return h.checked
+}
+
+// Methods returns the methods advertised by the endpoint at the last successful probe,
+// or nil if they are not known.
+func (h *HealthChecker) Methods() []string {
+	h.lk.RLock()
+	defer h.lk.RUnlock()
+	if h.methods == nil {
+		return nil
+	}
+	methods := make([]string, 0, len(h.methods))
+	for m := range h.methods {
+		methods = append(methods, m)
+	}
+	return methods
+}
+
+// Supports reports whether the endpoint advertises method.
+// Endpoints which do not implement Identify are assumed to support every method.
+func (h *HealthChecker) Supports(method string) bool {
+	h.lk.RLock()
+	defer h.lk.RUnlock()
+	return h.methods == nil || h.methods[method]
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipld/edelweiss/services"
+)
+
+// testIdentifier is an Identifier answering with the methods and error it is currently set to.
+type testIdentifier struct {
+	lk      sync.Mutex
+	methods []string
+	err     error
+}
+
+func (t *testIdentifier) Identify(ctx context.Context) ([]string, error) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	return t.methods, t.err
+}
+
+func (t *testIdentifier) set(methods []string, err error) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	t.methods, t.err = methods, err
+}
+
+func TestContentRoutingReadyFollowsHealthChecks(t *testing.T) {
+	id := &testIdentifier{}
+	h := NewHealthChecker(id, time.Hour)
+	defer h.Close()
+	c := NewContentRoutingClient(TestDelegatedRoutingClient{}, WithHealthChecker(h))
+	ctx := context.Background()
+
+	if c.Ready() {
+		t.Errorf("expecting not ready before the first check")
+	}
+
+	id.set([]string{"FindProviders", "Provide"}, nil)
+	if err := h.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Ready() {
+		t.Errorf("expecting ready when the endpoint supports Provide")
+	}
+
+	id.set([]string{"FindProviders"}, nil)
+	if err := h.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if c.Ready() {
+		t.Errorf("expecting not ready when the endpoint does not support Provide")
+	}
+
+	id.set(nil, errors.New("connection refused"))
+	if err := h.Check(ctx); err == nil {
+		t.Fatal("expecting an error")
+	}
+	if h.Reachable() || c.Ready() {
+		t.Errorf("expecting not ready when the endpoint is unreachable")
+	}
+
+	id.set(nil, services.ErrSchema)
+	if err := h.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Ready() || h.Methods() != nil {
+		t.Errorf("expecting ready with unknown methods when the endpoint does not implement Identify")
+	}
+}
+
+func TestHealthCheckerStartAndClose(t *testing.T) {
+	id := &testIdentifier{methods: []string{"Provide"}}
+	h := NewHealthChecker(id, time.Millisecond)
+	h.Start()
+	deadline := time.Now().Add(time.Second)
+	for !h.Reachable() {
+		if time.Now().After(deadline) {
+			t.Fatal("health checker did not probe the endpoint")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	h.Close()
+
+	// closing a health checker which was never started must not block
+	NewHealthChecker(id, time.Millisecond).Close()
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"container/heap"
+	"context"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+)
+
+// ledgerPrefix namespaces the records of provided keys in the ledger datastore.
+var ledgerPrefix = datastore.NewKey("/delegated-routing/provided")
+
+// ledgerRecord is the persisted state of a key tracked by a Reprovider.
+type ledgerRecord struct {
+	LastProvided int64 // unix nanoseconds, zero if the key was never provided successfully
+	GrantedTTL   int64 // nanoseconds
+	Failed       bool
+}
+
+var ledgerSchema, ledgerSchemaErr = ipld.LoadSchemaBytes([]byte(`
+		type LedgerRecord struct {
+			LastProvided Int
+			GrantedTTL   Int
+			Failed       Bool
+		}
+	`))
+
+func init() {
+	if ledgerSchemaErr != nil {
+		panic(ledgerSchemaErr)
+	}
+}
+
+// WithLedger makes the reprovider persist the keys it tracks to ds, recording the time each key was last provided
+// and the AdvisoryTTL granted for it. Call Load when starting up to resume reprovides on schedule.
+func WithLedger(ds datastore.Datastore) ReproviderOption {
+	return func(r *Reprovider) {
+		r.ledger = ds
+	}
+}
+
+// Load restores the keys recorded in the ledger. Keys which were provided are scheduled to be reprovided
+// ahead of the TTL granted at their last provide, the others are provided as soon as possible.
+// Keys which are already tracked keep their schedule. Load does nothing if the reprovider has no ledger.
+func (r *Reprovider) Load(ctx context.Context) error {
+	if r.ledger == nil {
+		return nil
+	}
+	results, err := r.ledger.Query(ctx, query.Query{Prefix: ledgerPrefix.String()})
+	if err != nil {
+		return err
+	}
+	defer results.Close()
+
+	r.lk.Lock()
+	now := time.Now()
+	for result := range results.Next() {
+		if result.Error != nil {
+			r.lk.Unlock()
+			return result.Error
+		}
+		key, err := cid.Decode(datastore.RawKey(result.Key).BaseNamespace())
+		if err != nil {
+			logger.Infof("ignoring ledger entry with invalid key %s (%v)", result.Key, err)
+			continue
+		}
+		var rec ledgerRecord
+		if _, err := ipld.Unmarshal(result.Value, dagcbor.Decode, &rec, ledgerSchema.TypeByName("LedgerRecord")); err != nil {
+			logger.Infof("ignoring invalid ledger entry for key %v (%v)", key, err)
+			continue
+		}
+		if _, ok := r.entries[key]; ok {
+			continue
+		}
+		e := &reprovideEntry{
+			key:        key,
+			grantedTTL: time.Duration(rec.GrantedTTL),
+			failed:     rec.Failed,
+			next:       now,
+		}
+		if rec.LastProvided != 0 {
+			e.lastProvided = time.Unix(0, rec.LastProvided)
+			if next := e.lastProvided.Add(r.reprovideAfter(e.grantedTTL)); !rec.Failed && next.After(now) {
+				e.next = next
+			}
+		}
+		r.entries[key] = e
+		heap.Push(&r.queue, e)
+	}
+	r.lk.Unlock()
+	r.notify()
+	return nil
+}
+
+// persist records the state of entries in the ledger, or deletes their records if remove is set.
+// It must be called with the lock held, so that the records of a key are written in order.
+func (r *Reprovider) persist(remove bool, entries ...*reprovideEntry) {
+	if r.ledger == nil || len(entries) == 0 {
+		return
+	}
+	ctx := context.Background()
+	var w datastore.Write = r.ledger
+	var batch datastore.Batch
+	if b, ok := r.ledger.(datastore.Batching); ok {
+		var err error
+		if batch, err = b.Batch(ctx); err != nil {
+			logger.Errorf("cannot batch provide ledger writes (%v)", err)
+		} else {
+			w = batch
+		}
+	}
+	for _, e := range entries {
+		dsKey := ledgerPrefix.ChildString(e.key.String())
+		if remove {
+			if err := w.Delete(ctx, dsKey); err != nil {
+				logger.Errorf("cannot delete provide ledger entry for key %v (%v)", e.key, err)
+			}
+			continue
+		}
+		rec := ledgerRecord{GrantedTTL: int64(e.grantedTTL), Failed: e.failed}
+		if !e.lastProvided.IsZero() {
+			rec.LastProvided = e.lastProvided.UnixNano()
+		}
+		value, err := ipld.Marshal(dagcbor.Encode, &rec, ledgerSchema.TypeByName("LedgerRecord"))
+		if err != nil {
+			logger.Errorf("cannot encode provide ledger entry for key %v (%v)", e.key, err)
+			continue
+		}
+		if err := w.Put(ctx, dsKey, value); err != nil {
+			logger.Errorf("cannot write provide ledger entry for key %v (%v)", e.key, err)
+		}
+	}
+	if batch != nil {
+		if err := batch.Commit(ctx); err != nil {
+			logger.Errorf("cannot commit provide ledger writes (%v)", err)
+		}
+	}
+}
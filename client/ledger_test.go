@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+)
+
+func TestReproviderLedgerResumesSchedule(t *testing.T) {
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	ctx := context.Background()
+	keys := testReprovideCids(t, 4)
+
+	c1 := &testProvideClient{granted: time.Hour}
+	r1 := NewReprovider(c1, WithLedger(ds))
+	if err := r1.Provide(ctx, keys[:2]...); err != nil {
+		t.Fatal(err)
+	}
+	c1.setErr(errors.New("provide failed"))
+	if err := r1.Provide(ctx, keys[2]); err == nil {
+		t.Fatal("expecting provide to fail")
+	}
+	r1.Add(keys[3])
+	r1.Close()
+
+	// a restarted reprovider picks up the keys where the previous one left them
+	c2 := &testProvideClient{granted: time.Hour}
+	r2 := NewReprovider(c2, WithLedger(ds))
+	if err := r2.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s := r2.Stats(); s != (ReproviderStats{Pending: 1, Provided: 2, Failed: 1}) {
+		t.Fatalf("unexpected stats after loading the ledger %v", s)
+	}
+	r2.Start()
+	defer r2.Close()
+
+	// only the failed and pending keys are due, the provided ones are reprovided ahead of their TTL
+	waitFor(t, "failed and pending keys to be provided", func() bool { return r2.Stats().Provided == len(keys) })
+	counts, _ := c2.provided()
+	if counts[keys[0]] != 0 || counts[keys[1]] != 0 || counts[keys[2]] != 1 || counts[keys[3]] != 1 {
+		t.Errorf("unexpected provides after restart %v", counts)
+	}
+
+	r2.Remove(keys[0])
+	r3 := NewReprovider(c2, WithLedger(ds))
+	if err := r3.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s := r3.Stats(); s != (ReproviderStats{Provided: len(keys) - 1}) {
+		t.Errorf("expecting removed key to be dropped from the ledger, got %v", s)
+	}
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ipld/edelweiss/services"
+)
+
+// ErrLimitExceeded is matched by every LimitError.
+var ErrLimitExceeded = errors.New("delegated routing limit exceeded")
+
+// LimitError is returned when a message exceeds one of the configured Limits.
+type LimitError struct {
+	// Limit names the exceeded limit: "result bytes", "results", "request bytes" or "providers".
+	Limit string
+	// Max is the configured value of the limit.
+	Max int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v: %s (max %d)", ErrLimitExceeded, e.Limit, e.Max)
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// RequestLimitCode is sent by the server in the Error header of its 413 Request Entity Too Large responses,
+// followed by the maximum request size, e.g. "request-limit-exceeded: 1048576".
+const RequestLimitCode = "request-limit-exceeded"
+
+// parseRequestLimit returns the LimitError of a request rejected by the server for its size, if err is one.
+func parseRequestLimit(err error) (*LimitError, bool) {
+	var serviceErr services.ErrService
+	if !errors.As(err, &serviceErr) || serviceErr.Cause == nil {
+		return nil, false
+	}
+	code, limit, _ := strings.Cut(serviceErr.Cause.Error(), ": ")
+	if code != RequestLimitCode {
+		return nil, false
+	}
+	limitErr := &LimitError{Limit: "request bytes"}
+	limitErr.Max, _ = strconv.ParseInt(limit, 10, 64)
+	return limitErr, true
+}
+
+// Limits bounds the size of delegated routing messages. Zero values mean no limit.
+//
+// On the client, LimitTransport enforces MaxResultBytes and MaxResults on response streams
+// and WithLimits enforces MaxProviders on the results.
+// On the server, the handler option of the server package rejects requests larger than MaxRequestBytes
+// and splits results with more than MaxProviders providers.
+type Limits struct {
+	// MaxResultBytes is the maximum size of a single result in a response stream.
+	MaxResultBytes int64
+	// MaxResults is the maximum number of results in a response stream.
+	MaxResults int
+	// MaxRequestBytes is the maximum size of a request body.
+	MaxRequestBytes int64
+	// MaxProviders is the maximum number of providers or peers in a single result.
+	MaxProviders int
+}
+
+// DefaultLimits are generous limits which protect against resource exhaustion by hostile peers.
+var DefaultLimits = Limits{
+	MaxResultBytes:  1 << 20,
+	MaxResults:      10000,
+	MaxRequestBytes: 1 << 20,
+	MaxProviders:    1000,
+}
+
+// WithLimits makes the client reject results with more than l.MaxProviders providers or peers with a LimitError.
+// The other limits are enforced on the wire by LimitTransport.
+func WithLimits(l Limits) ClientOption {
+	return func(c *Client) error {
+		c.limits = l
+		return nil
+	}
+}
+
+// checkProviders returns a LimitError if n providers exceed the configured limit.
+func (fp *Client) checkProviders(n int) error {
+	if fp.limits.MaxProviders > 0 && n > fp.limits.MaxProviders {
+		return &LimitError{Limit: "providers", Max: int64(fp.limits.MaxProviders)}
+	}
+	return nil
+}
+
+// LimitTransport is an http.RoundTripper which bounds the size of the response streams read by the protocol client.
+// A stream exceeding the limits is cut short, and the protocol client reports a LimitError as the last result.
+//
+// LimitTransport is installed in the HTTP client used by the protocol client, e.g.
+//
+//	hc := &http.Client{Transport: client.NewLimitTransport(http.DefaultTransport, client.DefaultLimits)}
+//	q, err := proto.New_DelegatedRouting_Client(endpoint, proto.DelegatedRouting_Client_WithHTTPClient(hc))
+//
+// Limits are counted on the DAG-JSON stream read by the protocol client, so LimitTransport must wrap
+// the transports which decode responses, such as DagCBORTransport and CompressionTransport,
+// which NewTransport does when it assembles them.
+// Responses which are still compressed or not DAG-JSON encoded fail with ErrUndecodedResponse.
+type LimitTransport struct {
+	next   http.RoundTripper
+	limits Limits
+}
+
+// NewLimitTransport creates a transport enforcing the MaxResultBytes and MaxResults limits of l.
+// If next is nil, http.DefaultTransport is used.
+func NewLimitTransport(next http.RoundTripper, l Limits) *LimitTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &LimitTransport{next: next, limits: l}
+}
+
+// ErrUndecodedResponse is returned by LimitTransport for responses it cannot count results in,
+// because the transports decoding them are installed outside of it.
+var ErrUndecodedResponse = errors.New("response is not decoded to DAG-JSON before limits are enforced")
+
+func (t *LimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if resp.Header.Get("Content-Encoding") != "" || (contentType != "" && !IsMediaType(contentType, MediaTypeDagJSON)) {
+		resp.Body.Close()
+		return nil, ErrUndecodedResponse
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, r: bufio.NewReader(resp.Body), limits: t.limits}
+	return resp, nil
+}
+
+// limitedBody reads a new line separated stream of results, passing on one complete result at a time.
+// A result exceeding the limits is not passed on. Instead the body fails once with a LimitError,
+// and reports the end of the stream after that, so that the protocol client stops decoding the stream.
+// Limits are only reported between results, where the decoder of the protocol client does not mask the error.
+type limitedBody struct {
+	io.ReadCloser
+	r      *bufio.Reader
+	limits Limits
+
+	results  int
+	pending  []byte
+	err      error
+	reported bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if len(b.pending) == 0 && b.err == nil {
+		b.pending, b.err = b.readResult()
+	}
+	if len(b.pending) > 0 {
+		n := copy(p, b.pending)
+		b.pending = b.pending[n:]
+		return n, nil
+	}
+	if b.reported {
+		return 0, io.EOF
+	}
+	b.reported = true
+	return 0, b.err
+}
+
+// readResult reads the next result, up to and including its new line.
+func (b *limitedBody) readResult() ([]byte, error) {
+	if b.limits.MaxResults > 0 && b.results >= b.limits.MaxResults {
+		if _, err := b.r.Peek(1); err != nil {
+			return nil, err
+		}
+		return nil, &LimitError{Limit: "results", Max: int64(b.limits.MaxResults)}
+	}
+	var result []byte
+	for {
+		frag, err := b.r.ReadSlice('\n')
+		result = append(result, frag...)
+		if b.limits.MaxResultBytes > 0 && int64(len(bytes.TrimSuffix(result, []byte{'\n'}))) > b.limits.MaxResultBytes {
+			return nil, &LimitError{Limit: "result bytes", Max: b.limits.MaxResultBytes}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == nil {
+			b.results++
+		}
+		// a last result without a new line is passed on before the error
+		return result, err
+	}
+}
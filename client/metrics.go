@@ -18,8 +18,25 @@ var (
 	measureDuration = stats.Float64("delegated_routing/duration", "The time to complete an entire request", stats.UnitMilliseconds)
 	measureRequests = stats.Float64("delegated_routing/requests", "The number of requests made", stats.UnitDimensionless)
 
-	keyName  = tag.MustNewKey("name")
-	keyError = tag.MustNewKey("error")
+	measureCacheRequests = stats.Int64("delegated_routing/cache_requests", "The number of cachable requests looked up in the client cache", stats.UnitDimensionless)
+	measureCircuitState  = stats.Int64("delegated_routing/circuit_state", "The state of the circuit breaker of an endpoint (0 closed, 1 half-open, 2 open)", stats.UnitDimensionless)
+
+	measureCompressedBytes   = stats.Int64("delegated_routing/compressed_bytes", "The number of compressed response bytes received", stats.UnitBytes)
+	measureUncompressedBytes = stats.Int64("delegated_routing/uncompressed_bytes", "The number of response bytes received after decompression", stats.UnitBytes)
+
+	measureTimeToFirstResult = stats.Float64("delegated_routing/time_to_first_result", "The time from a streaming request to its first result", stats.UnitMilliseconds)
+	measureProviders         = stats.Int64("delegated_routing/providers", "The number of providers returned by a streaming request", stats.UnitDimensionless)
+	measureDroppedResults    = stats.Int64("delegated_routing/dropped_results", "The number of providers and addresses dropped from results", stats.UnitDimensionless)
+	measureStreamErrors      = stats.Int64("delegated_routing/stream_errors", "The number of transient errors received in the middle of a result stream", stats.UnitDimensionless)
+
+	defaultCountDistribution = view.Distribution(0, 1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000)
+
+	keyName        = tag.MustNewKey("name")
+	keyError       = tag.MustNewKey("error")
+	keyCacheResult = tag.MustNewKey("cache_result")
+	keyEndpoint    = tag.MustNewKey("endpoint")
+	keyEncoding    = tag.MustNewKey("encoding")
+	keyReason      = tag.MustNewKey("reason")
 
 	durationView = &view.View{
 		Measure:     measureDuration,
@@ -31,10 +48,66 @@ var (
 		TagKeys:     []tag.Key{keyName, keyError},
 		Aggregation: view.Sum(),
 	}
+	cacheRequestsView = &view.View{
+		Measure:     measureCacheRequests,
+		TagKeys:     []tag.Key{keyName, keyCacheResult},
+		Aggregation: view.Sum(),
+	}
+	circuitStateView = &view.View{
+		Measure:     measureCircuitState,
+		TagKeys:     []tag.Key{keyEndpoint},
+		Aggregation: view.LastValue(),
+	}
+	compressedBytesView = &view.View{
+		Measure:     measureCompressedBytes,
+		TagKeys:     []tag.Key{keyEncoding},
+		Aggregation: view.Sum(),
+	}
+	uncompressedBytesView = &view.View{
+		Measure:     measureUncompressedBytes,
+		TagKeys:     []tag.Key{keyEncoding},
+		Aggregation: view.Sum(),
+	}
+
+	timeToFirstResultView = &view.View{
+		Measure:     measureTimeToFirstResult,
+		TagKeys:     []tag.Key{keyName},
+		Aggregation: defaultDurationDistribution,
+	}
+	providersView = &view.View{
+		Measure:     measureProviders,
+		TagKeys:     []tag.Key{keyName},
+		Aggregation: defaultCountDistribution,
+	}
+	droppedResultsView = &view.View{
+		Measure:     measureDroppedResults,
+		TagKeys:     []tag.Key{keyReason},
+		Aggregation: view.Sum(),
+	}
+	streamErrorsView = &view.View{
+		Measure:     measureStreamErrors,
+		TagKeys:     []tag.Key{keyName, keyError},
+		Aggregation: view.Sum(),
+	}
 
 	DefaultViews = []*view.View{
 		durationView,
 		requestsView,
+		cacheRequestsView,
+		circuitStateView,
+		compressedBytesView,
+		uncompressedBytesView,
+	}
+
+	// StreamViews describe the results of streaming requests: the time to their first result,
+	// the number of providers they return, the providers and addresses dropped from their results,
+	// and the transient errors received in the middle of their streams.
+	// They can be registered alongside DefaultViews.
+	StreamViews = []*view.View{
+		timeToFirstResultView,
+		providersView,
+		droppedResultsView,
+		streamErrorsView,
 	}
 )
 
@@ -49,7 +122,7 @@ func startMetrics(ctx context.Context, name string) (done func(err error)) {
 		errStr := "None"
 		if err != nil {
 			logger.Warnw("received delegated routing error", "Error", err)
-			errStr = metricsErrStr(err)
+			errStr = MetricsErrStr(err)
 		}
 
 		stats.RecordWithTags(ctx,
@@ -63,10 +136,63 @@ func startMetrics(ctx context.Context, name string) (done func(err error)) {
 	}
 }
 
-// metricsErrStr returns a string to use for recording metrics from an error.
+// streamMetrics records the metrics of the results of a streaming request.
+type streamMetrics struct {
+	ctx       context.Context
+	name      string
+	start     time.Time
+	results   int
+	providers int
+}
+
+// startStreamMetrics begins recording the metrics of the results of a streaming request.
+func startStreamMetrics(ctx context.Context, name string) *streamMetrics {
+	return &streamMetrics{ctx: ctx, name: name, start: time.Now()}
+}
+
+// result records a result carrying n providers. A failed result is recorded as a transient error.
+func (m *streamMetrics) result(n int, err error) {
+	if err != nil {
+		stats.RecordWithTags(m.ctx,
+			[]tag.Mutator{tag.Upsert(keyName, m.name), tag.Upsert(keyError, MetricsErrStr(err))},
+			measureStreamErrors.M(1),
+		)
+		return
+	}
+	if m.results == 0 {
+		stats.RecordWithTags(m.ctx,
+			[]tag.Mutator{tag.Upsert(keyName, m.name)},
+			measureTimeToFirstResult.M(float64(time.Since(m.start).Milliseconds())),
+		)
+	}
+	m.results++
+	m.providers += n
+}
+
+// done records the number of providers returned once the stream has ended.
+func (m *streamMetrics) done() {
+	stats.RecordWithTags(m.ctx,
+		[]tag.Mutator{tag.Upsert(keyName, m.name)},
+		measureProviders.M(int64(m.providers)),
+	)
+}
+
+// recordDropped records n providers or addresses dropped from results for the given reason.
+func recordDropped(ctx context.Context, reason string, n int) {
+	if n == 0 {
+		return
+	}
+	stats.RecordWithTags(ctx,
+		[]tag.Mutator{tag.Upsert(keyReason, reason)},
+		measureDroppedResults.M(int64(n)),
+	)
+}
+
+// MetricsErrStr returns a string to use for recording metrics from an error.
 // We shouldn't use the error string itself as that can result in high-cardinality metrics.
+// The same error classes are used by the metrics of the server package.
 // For more specific root causing, check the logs.
-func metricsErrStr(err error) string {
+func MetricsErrStr(err error) string {
 	if errors.Is(err, context.DeadlineExceeded) {
 		return "DeadlineExceeded"
 	}
@@ -76,14 +202,30 @@ func metricsErrStr(err error) string {
 	if errors.Is(err, services.ErrSchema) {
 		return "Schema"
 	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return "CircuitOpen"
+	}
+	if errors.Is(err, ErrLimitExceeded) {
+		return "LimitExceeded"
+	}
+	if errors.Is(err, ErrRateLimited) {
+		return "RateLimited"
+	}
+	var rejection *RejectionError
+	if errors.As(err, &rejection) {
+		return "Rejected"
+	}
 
+	// the generated client returns service and protocol errors by value
 	var serviceErr *services.ErrService
-	if errors.As(err, &serviceErr) {
+	var serviceErrVal services.ErrService
+	if errors.As(err, &serviceErr) || errors.As(err, &serviceErrVal) {
 		return "Service"
 	}
 
 	var protoErr *services.ErrProto
-	if errors.As(err, &protoErr) {
+	var protoErrVal services.ErrProto
+	if errors.As(err, &protoErr) || errors.As(err, &protoErrVal) {
 		return "Proto"
 	}
 
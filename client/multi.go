@@ -0,0 +1,493 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	ipns "github.com/ipfs/boxo/ipns"
+	"github.com/ipfs/go-cid"
+	record "github.com/libp2p/go-libp2p-record"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/routing"
+	"github.com/multiformats/go-multiaddr"
+	"go.uber.org/multierr"
+)
+
+// MultiClient is a DelegatedRoutingClient which fans out every call to several delegated routing endpoints in parallel.
+// Results are merged as they arrive. Providers are deduplicated by peer ID and their addresses are merged.
+// A failing endpoint does not fail the whole call; its errors are reported as EndpointError values.
+type MultiClient struct {
+	clients   []DelegatedRoutingClient
+	validator record.Validator
+}
+
+var _ DelegatedRoutingClient = (*MultiClient)(nil)
+
+// NewMultiClient creates a client which queries all of the given clients.
+// Endpoints are identified in errors by their index in clients.
+func NewMultiClient(clients ...DelegatedRoutingClient) *MultiClient {
+	return &MultiClient{
+		clients:   clients,
+		validator: ipns.Validator{},
+	}
+}
+
+// Ready reports whether any of the endpoints is ready.
+// Endpoints whose clients do not report readiness are assumed to be ready.
+func (mc *MultiClient) Ready() bool {
+	for _, c := range mc.clients {
+		if r, ok := c.(interface{ Ready() bool }); !ok || r.Ready() {
+			return true
+		}
+	}
+	return false
+}
+
+// EndpointError is an error returned by one of the endpoints of a MultiClient.
+type EndpointError struct {
+	Endpoint int
+	Err      error
+}
+
+func (e *EndpointError) Error() string {
+	return fmt.Sprintf("endpoint %d: %v", e.Endpoint, e.Err)
+}
+
+func (e *EndpointError) Unwrap() error {
+	return e.Err
+}
+
+// forEach calls fn on every endpoint in parallel and returns the errors of the endpoints that failed,
+// wrapped in EndpointError, together with an error combining all of them if no endpoint succeeded.
+func (mc *MultiClient) forEach(fn func(i int, c DelegatedRoutingClient) error) (failed []error, err error) {
+	var wg sync.WaitGroup
+	errs := make([]error, len(mc.clients))
+	for i, c := range mc.clients {
+		wg.Add(1)
+		go func(i int, c DelegatedRoutingClient) {
+			defer wg.Done()
+			if err := fn(i, c); err != nil {
+				errs[i] = &EndpointError{Endpoint: i, Err: err}
+			}
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			logger.Infof("delegated routing endpoint failed (%v)", err)
+			failed = append(failed, err)
+		}
+	}
+	if len(mc.clients) > 0 && len(failed) == len(mc.clients) {
+		return failed, multierr.Combine(failed...)
+	}
+	return failed, nil
+}
+
+// addrMerger deduplicates providers by peer ID and merges their addresses.
+type addrMerger struct {
+	order []peer.ID
+	addrs map[peer.ID][]multiaddr.Multiaddr
+	seen  map[peer.ID]map[string]struct{}
+}
+
+func newAddrMerger() *addrMerger {
+	return &addrMerger{
+		addrs: map[peer.ID][]multiaddr.Multiaddr{},
+		seen:  map[peer.ID]map[string]struct{}{},
+	}
+}
+
+// add merges infos into the set of known providers.
+// It returns the providers which are either new or gained new addresses, carrying their full merged address list.
+func (m *addrMerger) add(infos []peer.AddrInfo) []peer.AddrInfo {
+	var updated []peer.AddrInfo
+	for _, info := range infos {
+		seenAddrs, known := m.seen[info.ID]
+		if !known {
+			seenAddrs = map[string]struct{}{}
+			m.seen[info.ID] = seenAddrs
+			m.order = append(m.order, info.ID)
+		}
+		changed := !known
+		for _, addr := range info.Addrs {
+			if _, ok := seenAddrs[string(addr.Bytes())]; ok {
+				continue
+			}
+			seenAddrs[string(addr.Bytes())] = struct{}{}
+			m.addrs[info.ID] = append(m.addrs[info.ID], addr)
+			changed = true
+		}
+		if changed {
+			updated = append(updated, m.info(info.ID))
+		}
+	}
+	return updated
+}
+
+// addNew merges infos into the set of known providers, like add.
+// It returns only the providers which were not known before, carrying the addresses known so far.
+func (m *addrMerger) addNew(infos []peer.AddrInfo) []peer.AddrInfo {
+	var added []peer.AddrInfo
+	for _, info := range infos {
+		_, known := m.seen[info.ID]
+		m.add([]peer.AddrInfo{info})
+		if !known {
+			added = append(added, m.info(info.ID))
+		}
+	}
+	return added
+}
+
+func (m *addrMerger) info(id peer.ID) peer.AddrInfo {
+	addrs := make([]multiaddr.Multiaddr, len(m.addrs[id]))
+	copy(addrs, m.addrs[id])
+	return peer.AddrInfo{ID: id, Addrs: addrs}
+}
+
+func (m *addrMerger) infos() []peer.AddrInfo {
+	infos := make([]peer.AddrInfo, 0, len(m.order))
+	for _, id := range m.order {
+		infos = append(infos, m.info(id))
+	}
+	return infos
+}
+
+func (mc *MultiClient) FindProviders(ctx context.Context, key cid.Cid) ([]peer.AddrInfo, error) {
+	var lk sync.Mutex
+	merger := newAddrMerger()
+	_, err := mc.forEach(func(i int, c DelegatedRoutingClient) error {
+		infos, err := c.FindProviders(ctx, key)
+		lk.Lock()
+		merger.add(infos)
+		lk.Unlock()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return merger.infos(), nil
+}
+
+// FindProvidersAsync queries all endpoints in parallel and streams their merged results.
+// Each provider is emitted once, with the addresses of the first result reporting it.
+// Use FindProviders to merge the addresses reported by all endpoints.
+func (mc *MultiClient) FindProvidersAsync(ctx context.Context, key cid.Cid) (<-chan FindProvidersAsyncResult, error) {
+	chans := make([]<-chan FindProvidersAsyncResult, len(mc.clients))
+	failed, err := mc.forEach(func(i int, c DelegatedRoutingClient) error {
+		ch, err := c.FindProvidersAsync(ctx, key)
+		chans[i] = ch
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ch0 := fanIn(ctx, chans)
+	ch1 := make(chan FindProvidersAsyncResult, len(failed)+1)
+	for _, err := range failed {
+		ch1 <- FindProvidersAsyncResult{Err: err}
+	}
+	go func() {
+		defer close(ch1)
+		merger := newAddrMerger()
+		for r0 := range ch0 {
+			var r1 FindProvidersAsyncResult
+			if r0.value.Err != nil {
+				r1.Err = &EndpointError{Endpoint: r0.endpoint, Err: r0.value.Err}
+			} else if r1.AddrInfo = merger.addNew(r0.value.AddrInfo); len(r1.AddrInfo) == 0 {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case ch1 <- r1:
+			}
+		}
+	}()
+	return ch1, nil
+}
+
+func (mc *MultiClient) FindPeer(ctx context.Context, id peer.ID) (peer.AddrInfo, error) {
+	var lk sync.Mutex
+	merger := newAddrMerger()
+	failed, err := mc.forEach(func(i int, c DelegatedRoutingClient) error {
+		info, err := c.FindPeer(ctx, id)
+		if err != nil {
+			return err
+		}
+		lk.Lock()
+		merger.add([]peer.AddrInfo{info})
+		lk.Unlock()
+		return nil
+	})
+	infos := merger.infos()
+	if len(infos) == 0 {
+		for _, e := range failed {
+			if !errors.Is(e, routing.ErrNotFound) {
+				return peer.AddrInfo{}, err
+			}
+		}
+		return peer.AddrInfo{}, routing.ErrNotFound
+	}
+	return infos[0], nil
+}
+
+// FindPeerAsync queries all endpoints in parallel and streams the peer's addresses as new ones are found.
+func (mc *MultiClient) FindPeerAsync(ctx context.Context, id peer.ID) (<-chan FindPeerAsyncResult, error) {
+	chans := make([]<-chan FindPeerAsyncResult, len(mc.clients))
+	failed, err := mc.forEach(func(i int, c DelegatedRoutingClient) error {
+		ch, err := c.FindPeerAsync(ctx, id)
+		chans[i] = ch
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ch0 := fanIn(ctx, chans)
+	ch1 := make(chan FindPeerAsyncResult, len(failed)+1)
+	for _, err := range failed {
+		ch1 <- FindPeerAsyncResult{Err: err}
+	}
+	go func() {
+		defer close(ch1)
+		merger := newAddrMerger()
+		for r0 := range ch0 {
+			var r1 FindPeerAsyncResult
+			if r0.value.Err != nil {
+				r1.Err = &EndpointError{Endpoint: r0.endpoint, Err: r0.value.Err}
+			} else if r1.AddrInfo = merger.add(r0.value.AddrInfo); len(r1.AddrInfo) == 0 {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case ch1 <- r1:
+			}
+		}
+	}()
+	return ch1, nil
+}
+
+func (mc *MultiClient) GetIPNS(ctx context.Context, id []byte) ([]byte, error) {
+	var lk sync.Mutex
+	records := [][]byte{}
+	failed, err := mc.forEach(func(i int, c DelegatedRoutingClient) error {
+		rec, err := c.GetIPNS(ctx, id)
+		if err != nil {
+			return err
+		}
+		lk.Lock()
+		records = append(records, rec)
+		lk.Unlock()
+		return nil
+	})
+	if len(records) == 0 {
+		for _, e := range failed {
+			if !errors.Is(e, routing.ErrNotFound) {
+				return nil, err
+			}
+		}
+		return nil, routing.ErrNotFound
+	}
+	best, err := mc.validator.Select(ipns.RecordKey(peer.ID(id)), records)
+	if err != nil {
+		return nil, err
+	}
+	return records[best], nil
+}
+
+// GetIPNSAsync queries all endpoints in parallel and streams the distinct records they return.
+func (mc *MultiClient) GetIPNSAsync(ctx context.Context, id []byte) (<-chan GetIPNSAsyncResult, error) {
+	chans := make([]<-chan GetIPNSAsyncResult, len(mc.clients))
+	failed, err := mc.forEach(func(i int, c DelegatedRoutingClient) error {
+		ch, err := c.GetIPNSAsync(ctx, id)
+		chans[i] = ch
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ch0 := fanIn(ctx, chans)
+	ch1 := make(chan GetIPNSAsyncResult, len(failed)+1)
+	for _, err := range failed {
+		ch1 <- GetIPNSAsyncResult{Err: err}
+	}
+	go func() {
+		defer close(ch1)
+		var seen [][]byte
+	results:
+		for r0 := range ch0 {
+			r1 := r0.value
+			if r1.Err != nil {
+				r1.Err = &EndpointError{Endpoint: r0.endpoint, Err: r1.Err}
+			} else {
+				for _, s := range seen {
+					if bytes.Equal(s, r1.Record) {
+						continue results
+					}
+				}
+				seen = append(seen, r1.Record)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case ch1 <- r1:
+			}
+		}
+	}()
+	return ch1, nil
+}
+
+func (mc *MultiClient) PutIPNS(ctx context.Context, id []byte, record []byte) error {
+	_, err := mc.forEach(func(i int, c DelegatedRoutingClient) error {
+		return c.PutIPNS(ctx, id, record)
+	})
+	return err
+}
+
+func (mc *MultiClient) PutIPNSAsync(ctx context.Context, id []byte, record []byte) (<-chan PutIPNSAsyncResult, error) {
+	chans := make([]<-chan PutIPNSAsyncResult, len(mc.clients))
+	failed, err := mc.forEach(func(i int, c DelegatedRoutingClient) error {
+		ch, err := c.PutIPNSAsync(ctx, id, record)
+		chans[i] = ch
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ch0 := fanIn(ctx, chans)
+	ch1 := make(chan PutIPNSAsyncResult, len(failed)+1)
+	for _, err := range failed {
+		ch1 <- PutIPNSAsyncResult{Err: err}
+	}
+	go func() {
+		defer close(ch1)
+		for r0 := range ch0 {
+			r1 := r0.value
+			if r1.Err != nil {
+				r1.Err = &EndpointError{Endpoint: r0.endpoint, Err: r1.Err}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case ch1 <- r1:
+			}
+		}
+	}()
+	return ch1, nil
+}
+
+// Provide announces keys to all endpoints in parallel.
+// It returns the shortest advisory TTL granted by the endpoints which accepted the request,
+// since the keys must be provided again before the earliest of them expires.
+// The errors of the endpoints which failed are logged as a warning.
+func (mc *MultiClient) Provide(ctx context.Context, keys []cid.Cid, ttl time.Duration) (time.Duration, error) {
+	var lk sync.Mutex
+	var d time.Duration
+	var set bool
+	failed, err := mc.forEach(func(i int, c DelegatedRoutingClient) error {
+		granted, err := c.Provide(ctx, keys, ttl)
+		if err != nil {
+			return err
+		}
+		lk.Lock()
+		if !set || granted < d {
+			d, set = granted, true
+		}
+		lk.Unlock()
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(failed) > 0 {
+		logger.Warnf("provide failed at %d of %d delegated routing endpoints (%v)", len(failed), len(mc.clients), multierr.Combine(failed...))
+	}
+	return d, nil
+}
+
+// ProvideAsync announces keys to every endpoint and streams the advisory TTLs they grant.
+// The results cannot carry the errors of the endpoints which failed, so they are logged as a warning.
+func (mc *MultiClient) ProvideAsync(ctx context.Context, keys []cid.Cid, ttl time.Duration) (<-chan time.Duration, error) {
+	chans := make([]<-chan time.Duration, len(mc.clients))
+	failed, err := mc.forEach(func(i int, c DelegatedRoutingClient) error {
+		ch, err := c.ProvideAsync(ctx, keys, ttl)
+		chans[i] = ch
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(failed) > 0 {
+		logger.Warnf("provide failed at %d of %d delegated routing endpoints (%v)", len(failed), len(mc.clients), multierr.Combine(failed...))
+	}
+
+	ch0 := fanIn(ctx, chans)
+	ch1 := make(chan time.Duration, 1)
+	go func() {
+		defer close(ch1)
+		for r0 := range ch0 {
+			select {
+			case <-ctx.Done():
+				return
+			case ch1 <- r0.value:
+			}
+		}
+	}()
+	return ch1, nil
+}
+
+// endpointResult is a value received from the endpoint with the given index.
+type endpointResult[T any] struct {
+	endpoint int
+	value    T
+}
+
+// fanIn forwards the values of all non-nil input channels to a single output channel,
+// which is closed once all inputs are closed or the context is done.
+func fanIn[T any](ctx context.Context, chans []<-chan T) <-chan endpointResult[T] {
+	out := make(chan endpointResult[T], 1)
+	var wg sync.WaitGroup
+	for i, ch := range chans {
+		if ch == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, ch <-chan T) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case v, ok := <-ch:
+					if !ok {
+						return
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case out <- endpointResult[T]{endpoint: i, value: v}:
+					}
+				}
+			}
+		}(i, ch)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// testProvidersClient is a DelegatedRoutingClient whose FindProviders calls return fixed providers or a fixed error.
+type testProvidersClient struct {
+	TestDelegatedRoutingClient
+	infos []peer.AddrInfo
+	err   error
+}
+
+func (t testProvidersClient) FindProviders(ctx context.Context, key cid.Cid) ([]peer.AddrInfo, error) {
+	return t.infos, t.err
+}
+
+func (t testProvidersClient) FindProvidersAsync(ctx context.Context, key cid.Cid) (<-chan FindProvidersAsyncResult, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	ch := make(chan FindProvidersAsyncResult, len(t.infos))
+	for _, info := range t.infos {
+		ch <- FindProvidersAsyncResult{AddrInfo: []peer.AddrInfo{info}}
+	}
+	close(ch)
+	return ch, nil
+}
+
+var (
+	testMultiPeerA = peer.ID("peer-a")
+	testMultiPeerB = peer.ID("peer-b")
+	testMultiAddr1 = multiaddr.StringCast("/ip4/1.1.1.1/tcp/4001")
+	testMultiAddr2 = multiaddr.StringCast("/ip4/2.2.2.2/tcp/4001")
+	testMultiErr   = errors.New("endpoint down")
+)
+
+func newTestMultiClient() *MultiClient {
+	return NewMultiClient(
+		testProvidersClient{infos: []peer.AddrInfo{
+			{ID: testMultiPeerA, Addrs: []multiaddr.Multiaddr{testMultiAddr1}},
+		}},
+		testProvidersClient{infos: []peer.AddrInfo{
+			{ID: testMultiPeerA, Addrs: []multiaddr.Multiaddr{testMultiAddr1, testMultiAddr2}},
+			{ID: testMultiPeerB, Addrs: []multiaddr.Multiaddr{testMultiAddr2}},
+		}},
+		testProvidersClient{err: testMultiErr},
+	)
+}
+
+func TestMultiClientFindProvidersMergesProviders(t *testing.T) {
+	infos, err := newTestMultiClient().FindProviders(context.Background(), cid.Cid{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs := map[peer.ID]int{}
+	for _, info := range infos {
+		addrs[info.ID] = len(info.Addrs)
+	}
+	if len(infos) != 2 || addrs[testMultiPeerA] != 2 || addrs[testMultiPeerB] != 1 {
+		t.Errorf("expecting peer a with 2 addresses and peer b with 1 address, got %v", infos)
+	}
+}
+
+func TestMultiClientFindProvidersAsyncReportsEndpointErrors(t *testing.T) {
+	ch, err := newTestMultiClient().FindProvidersAsync(context.Background(), cid.Cid{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := newAddrMerger()
+	var epErr *EndpointError
+	for r := range ch {
+		if r.Err != nil {
+			if !errors.As(r.Err, &epErr) || epErr.Endpoint != 2 || !errors.Is(r.Err, testMultiErr) {
+				t.Errorf("expecting error of endpoint 2, got %v", r.Err)
+			}
+			continue
+		}
+		merged.add(r.AddrInfo)
+	}
+	if epErr == nil {
+		t.Errorf("expecting an endpoint error")
+	}
+	if infos := merged.infos(); len(infos) != 2 {
+		t.Errorf("expecting 2 providers, got %v", infos)
+	}
+}
+
+func TestMultiClientFindProvidersAsyncEmitsProvidersOnce(t *testing.T) {
+	ch, err := newTestMultiClient().FindProvidersAsync(context.Background(), cid.Cid{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	emitted := map[peer.ID]int{}
+	for r := range ch {
+		for _, info := range r.AddrInfo {
+			emitted[info.ID]++
+		}
+	}
+	if len(emitted) != 2 || emitted[testMultiPeerA] != 1 || emitted[testMultiPeerB] != 1 {
+		t.Errorf("expecting peers a and b to be emitted once each, got %v", emitted)
+	}
+}
+
+func TestMultiClientAllEndpointsFail(t *testing.T) {
+	c := NewMultiClient(testProvidersClient{err: testMultiErr}, testProvidersClient{err: testMultiErr})
+	if _, err := c.FindProviders(context.Background(), cid.Cid{}); !errors.Is(err, testMultiErr) {
+		t.Errorf("expecting %v, got %v", testMultiErr, err)
+	}
+	if _, err := c.FindProvidersAsync(context.Background(), cid.Cid{}); !errors.Is(err, testMultiErr) {
+		t.Errorf("expecting %v, got %v", testMultiErr, err)
+	}
+}
@@ -0,0 +1,32 @@
+package client
+
+import (
+	"context"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/routing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type PeerRoutingClient struct {
+	client DelegatedRoutingClient
+}
+
+var _ routing.PeerRouting = (*PeerRoutingClient)(nil)
+
+func NewPeerRoutingClient(c DelegatedRoutingClient) *PeerRoutingClient {
+	return &PeerRoutingClient{client: c}
+}
+
+// FindPeer searches for a peer with given ID, returns a peer.AddrInfo with relevant addresses.
+func (c *PeerRoutingClient) FindPeer(ctx context.Context, id peer.ID) (peer.AddrInfo, error) {
+	var err error
+	recordMetrics := startMetrics(ctx, "PeerRoutingClient.FindPeer")
+	defer func() { recordMetrics(err) }()
+	ctx, endSpan := startSpan(ctx, "PeerRoutingClient.FindPeer", attribute.Stringer("peer", id))
+	defer func() { endSpan(err) }()
+
+	var info peer.AddrInfo
+	info, err = c.client.FindPeer(ctx, id)
+	return info, err
+}
@@ -12,13 +12,14 @@ import (
 	"github.com/ipfs/go-delegated-routing/gen/proto"
 	"github.com/ipld/edelweiss/values"
 	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
 	"github.com/ipld/go-ipld-prime/codec/dagjson"
 	"github.com/ipld/go-ipld-prime/node/bindnode"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/multiformats/go-multiaddr"
 	"github.com/multiformats/go-multicodec"
-	"github.com/polydawn/refmt/cbor"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Provider represents the source publishing one or more CIDs
@@ -61,8 +62,8 @@ func (tp *TransferProtocol) ToProto() proto.TransferProtocol {
 			Bitswap: &proto.BitswapProtocol{},
 		}
 	} else if tp.Codec == multicodec.TransportGraphsyncFilecoinv1 {
-		into := GraphSyncFILv1{}
-		if err := cbor.Unmarshal(cbor.DecodeOptions{}, tp.Payload, &into); err != nil {
+		into, err := decodeGraphSyncFILv1(tp.Payload)
+		if err != nil {
 			return proto.TransferProtocol{}
 		}
 		return proto.TransferProtocol{
@@ -86,18 +87,27 @@ func parseProtocol(tp *proto.TransferProtocol) (TransferProtocol, error) {
 			VerifiedDeal:  bool(tp.GraphSyncFILv1.VerifiedDeal),
 			FastRetrieval: bool(tp.GraphSyncFILv1.FastRetrieval),
 		}
-		plBytes, err := cbor.Marshal(&pl)
-		if err != nil {
-			return TransferProtocol{}, err
-		}
-		return TransferProtocol{
-			Codec:   multicodec.TransportGraphsyncFilecoinv1,
-			Payload: plBytes,
-		}, nil
+		return pl.TransferProtocol()
 	}
 	return TransferProtocol{}, nil
 }
 
+var graphSyncFILv1Schema, graphSyncFILv1SchemaErr = ipld.LoadSchemaBytes([]byte(`
+		type GraphSyncFILv1 struct {
+			PieceCID      Link
+			VerifiedDeal  Bool
+			FastRetrieval Bool
+		}
+	`))
+
+func decodeGraphSyncFILv1(payload []byte) (*GraphSyncFILv1, error) {
+	var pl GraphSyncFILv1
+	if _, err := ipld.Unmarshal(payload, dagcbor.Decode, &pl, graphSyncFILv1Schema.TypeByName("GraphSyncFILv1")); err != nil {
+		return nil, err
+	}
+	return &pl, nil
+}
+
 // ProvideRequest is a message indicating a provider can provide a Key for a given TTL
 type ProvideRequest struct {
 	Key []cid.Cid
@@ -133,6 +143,9 @@ func init() {
 	if provideSchemaErr != nil {
 		panic(provideSchemaErr)
 	}
+	if graphSyncFILv1SchemaErr != nil {
+		panic(graphSyncFILv1SchemaErr)
+	}
 }
 
 func bytesToMA(b []byte) (interface{}, error) {
@@ -229,10 +242,12 @@ func (pr *ProvideRequest) IsSigned() bool {
 	return pr.Signature != nil
 }
 
+// ParseProvideRequest parses a provide request received by the server and verifies its signature.
+// Invalid requests are reported as ErrInvalidRequest, and requests whose signature does not verify as ErrInvalidSignature.
 func ParseProvideRequest(req *proto.ProvideRequest) (*ProvideRequest, error) {
 	prov, err := parseProvider(&req.Provider)
 	if err != nil {
-		return nil, err
+		return nil, ErrInvalidRequest.WithReason(err.Error())
 	}
 	keys := make([]cid.Cid, 0, len(req.Key))
 	for _, c := range req.Key {
@@ -247,14 +262,18 @@ func ParseProvideRequest(req *proto.ProvideRequest) (*ProvideRequest, error) {
 	}
 
 	if err := pr.Verify(); err != nil {
-		return nil, err
+		return nil, ErrInvalidSignature.WithReason(err.Error())
 	}
 	return &pr, nil
 }
 
 func parseProvider(p *proto.Provider) (*Provider, error) {
+	infos := parseProtoNodeToAddrInfo(p.ProviderNode)
+	if len(infos) == 0 {
+		return nil, errors.New("provider node is not a peer")
+	}
 	prov := Provider{
-		Peer:          parseProtoNodeToAddrInfo(p.ProviderNode)[0],
+		Peer:          infos[0],
 		ProviderProto: make([]TransferProtocol, 0),
 	}
 	for _, tp := range p.ProviderProto {
@@ -272,7 +291,10 @@ type ProvideAsyncResult struct {
 	Err         error
 }
 
-func (fp *Client) Provide(ctx context.Context, keys []cid.Cid, ttl time.Duration) (time.Duration, error) {
+func (fp *Client) Provide(ctx context.Context, keys []cid.Cid, ttl time.Duration) (_ time.Duration, err error) {
+	ctx, endSpan := startSpan(ctx, "Client.Provide", attribute.Int("keys", len(keys)))
+	defer func() { endSpan(err) }()
+
 	req := ProvideRequest{
 		Key:         keys,
 		Provider:    fp.provider,
@@ -313,6 +335,7 @@ func (fp *Client) Provide(ctx context.Context, keys []cid.Cid, ttl time.Duration
 }
 
 func (fp *Client) ProvideAsync(ctx context.Context, keys []cid.Cid, ttl time.Duration) (<-chan time.Duration, error) {
+	ctx, endSpan := startSpan(ctx, "Client.ProvideAsync", attribute.Int("keys", len(keys)))
 	req := ProvideRequest{
 		Key:         keys,
 		Provider:    fp.provider,
@@ -324,6 +347,7 @@ func (fp *Client) ProvideAsync(ctx context.Context, keys []cid.Cid, ttl time.Dur
 	if fp.identity != nil {
 		if err := req.Sign(fp.identity); err != nil {
 			close(ch)
+			endSpan(err)
 			return ch, err
 		}
 	}
@@ -331,9 +355,11 @@ func (fp *Client) ProvideAsync(ctx context.Context, keys []cid.Cid, ttl time.Dur
 	record, err := fp.ProvideSignedRecord(ctx, &req)
 	if err != nil {
 		close(ch)
+		endSpan(err)
 		return ch, err
 	}
 	go func() {
+		defer endSpan(nil)
 		defer close(ch)
 		for resp := range record {
 			if resp.Err != nil {
@@ -348,30 +374,39 @@ func (fp *Client) ProvideAsync(ctx context.Context, keys []cid.Cid, ttl time.Dur
 
 // ProvideAsync makes a provide request to a delegated router
 func (fp *Client) ProvideSignedRecord(ctx context.Context, req *ProvideRequest) (<-chan ProvideAsyncResult, error) {
+	ctx, endSpan := startSpan(ctx, "Client.ProvideSignedRecord", attribute.Int("keys", len(req.Key)))
 	if !req.IsSigned() {
-		return nil, errors.New("request is not signed")
+		err := errors.New("request is not signed")
+		endSpan(err)
+		return nil, err
 	}
 
 	var providerProto proto.Provider
 	if req.Provider != nil {
 		providerProto = *req.Provider.ToProto()
 	}
-	keys := make(proto.AnonList14, 0, len(req.Key))
+	keys := make(proto.AnonList20, 0, len(req.Key))
 	for _, c := range req.Key {
 		keys = append(keys, proto.LinkToAny(c))
 	}
-	ch0, err := fp.client.Provide_Async(ctx, &proto.ProvideRequest{
-		Key:         keys,
-		Provider:    providerProto,
-		Timestamp:   values.Int(req.Timestamp),
-		AdvisoryTTL: values.Int(req.AdvisoryTTL),
-		Signature:   req.Signature,
+	var ch0 <-chan proto.DelegatedRouting_Provide_AsyncResult
+	err := fp.call(ctx, "Provide", false, func(ctx context.Context) (err error) {
+		ch0, err = fp.client.Provide_Async(ctx, &proto.ProvideRequest{
+			Key:         keys,
+			Provider:    providerProto,
+			Timestamp:   values.Int(req.Timestamp),
+			AdvisoryTTL: values.Int(req.AdvisoryTTL),
+			Signature:   req.Signature,
+		})
+		return err
 	})
 	if err != nil {
+		endSpan(err)
 		return nil, err
 	}
 	ch1 := make(chan ProvideAsyncResult, 1)
 	go func() {
+		defer endSpan(nil)
 		defer close(ch1)
 		for {
 			select {
@@ -385,7 +420,7 @@ func (fp *Client) ProvideSignedRecord(ctx context.Context, req *ProvideRequest)
 				var r1 ProvideAsyncResult
 
 				if r0.Err != nil {
-					r1.Err = r0.Err
+					r1.Err = errorCause(r0.Err)
 					select {
 					case <-ctx.Done():
 						return
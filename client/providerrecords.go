@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	proto "github.com/ipfs/go-delegated-routing/gen/proto"
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/multiformats/go-multicodec"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// FindProviderRecords returns the full provider records for key, including providers which do not support Bitswap.
+// Unlike FindProviders, which only returns the addresses of Bitswap providers, every record carries the
+// transfer protocols advertised by the provider.
+func (fp *Client) FindProviderRecords(ctx context.Context, key cid.Cid) (provs []Provider, err error) {
+	ctx, endSpan := startSpan(ctx, "Client.FindProviderRecords", attribute.Stringer("key", key))
+	defer func() { endSpan(err) }()
+
+	var resps []*proto.FindProvidersResponse
+	err = fp.call(ctx, "FindProviders", true, func(ctx context.Context) (err error) {
+		resps, err = fp.client.FindProviders(ctx, cidsToFindProvidersRequest(key))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	provs = []Provider{}
+	for _, resp := range resps {
+		if err := fp.checkProviders(len(resp.Providers)); err != nil {
+			return nil, err
+		}
+		provs = append(provs, parseFindProvidersResponseRecords(ctx, resp)...)
+	}
+	return provs, nil
+}
+
+type FindProviderRecordsAsyncResult struct {
+	Providers []Provider
+	Err       error
+}
+
+// FindProviderRecordsAsync processes the stream of raw protocol async results into a stream of full provider records.
+func (fp *Client) FindProviderRecordsAsync(ctx context.Context, key cid.Cid) (<-chan FindProviderRecordsAsyncResult, error) {
+	ctx, endSpan := startSpan(ctx, "Client.FindProviderRecordsAsync", attribute.Stringer("key", key))
+	var ch0 <-chan proto.DelegatedRouting_FindProviders_AsyncResult
+	err := fp.call(ctx, "FindProviders", true, func(ctx context.Context) (err error) {
+		ch0, err = fp.client.FindProviders_Async(ctx, cidsToFindProvidersRequest(key))
+		return err
+	})
+	if err != nil {
+		endSpan(err)
+		return nil, err
+	}
+	metrics := startStreamMetrics(ctx, "Client.FindProviderRecordsAsync")
+	ch1 := make(chan FindProviderRecordsAsyncResult, 1)
+	go func() {
+		defer endSpan(nil)
+		defer metrics.done()
+		defer close(ch1)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case r0, ok := <-ch0:
+				if !ok {
+					return
+				}
+
+				var r1 FindProviderRecordsAsyncResult
+
+				r1.Err = errorCause(r0.Err)
+				if r0.Resp != nil {
+					if err := fp.checkProviders(len(r0.Resp.Providers)); err != nil {
+						r1.Err = err
+					} else {
+						r1.Providers = parseFindProvidersResponseRecords(ctx, r0.Resp)
+					}
+				}
+				metrics.result(len(r1.Providers), r1.Err)
+
+				select {
+				case <-ctx.Done():
+					return
+				case ch1 <- r1:
+				}
+			}
+		}
+	}()
+	return ch1, nil
+}
+
+func parseFindProvidersResponseRecords(ctx context.Context, resp *proto.FindProvidersResponse) []Provider {
+	provs := []Provider{}
+	badAddrs := 0
+	defer func() { recordDropped(ctx, "Multiaddr", badAddrs) }()
+	for _, prov := range resp.Providers {
+		if prov.ProviderNode.Peer == nil { // ignore non-peer nodes
+			continue
+		}
+		info, dropped := parseNodeAddresses(prov.ProviderNode.Peer)
+		badAddrs += dropped
+		p := Provider{
+			Peer:          info,
+			ProviderProto: []TransferProtocol{},
+		}
+		for i := range prov.ProviderProto {
+			tp := &prov.ProviderProto[i]
+			if tp.Bitswap == nil && tp.GraphSyncFILv1 == nil {
+				logger.Infof("ignoring unknown transfer protocol %v of provider %v", tp.DefaultKey, p.Peer.ID)
+				continue
+			}
+			parsed, err := parseProtocol(tp)
+			if err != nil {
+				logger.Infof("cannot parse transfer protocol of provider %v (%v)", p.Peer.ID, err)
+				continue
+			}
+			p.ProviderProto = append(p.ProviderProto, parsed)
+		}
+		provs = append(provs, p)
+	}
+	return provs
+}
+
+// DecodeGraphSyncFILv1 decodes the payload of a GraphSyncFILv1 transfer protocol.
+func (tp *TransferProtocol) DecodeGraphSyncFILv1() (*GraphSyncFILv1, error) {
+	if tp.Codec != multicodec.TransportGraphsyncFilecoinv1 {
+		return nil, fmt.Errorf("transfer protocol is %v, not %v", tp.Codec, multicodec.TransportGraphsyncFilecoinv1)
+	}
+	return decodeGraphSyncFILv1(tp.Payload)
+}
+
+// TransferProtocol encodes gs as a GraphSyncFILv1 transfer protocol.
+func (gs *GraphSyncFILv1) TransferProtocol() (TransferProtocol, error) {
+	payload, err := ipld.Marshal(dagcbor.Encode, gs, graphSyncFILv1Schema.TypeByName("GraphSyncFILv1"))
+	if err != nil {
+		return TransferProtocol{}, err
+	}
+	return TransferProtocol{Codec: multicodec.TransportGraphsyncFilecoinv1, Payload: payload}, nil
+}
@@ -8,17 +8,19 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 )
 
-func (fp *Client) PutIPNS(ctx context.Context, id []byte, record []byte) error {
-	_, err := peer.IDFromBytes(id)
+func (fp *Client) PutIPNS(ctx context.Context, id []byte, record []byte) (err error) {
+	ctx, endSpan := startSpan(ctx, "Client.PutIPNS")
+	defer func() { endSpan(err) }()
+
+	_, err = peer.IDFromBytes(id)
 	if err != nil {
 		return fmt.Errorf("invalid peer ID: %w", err)
 	}
 
-	_, err = fp.client.PutIPNS(ctx, &proto.PutIPNSRequest{ID: id, Record: record})
-	if err != nil {
+	return fp.call(ctx, "PutIPNS", false, func(ctx context.Context) error {
+		_, err := fp.client.PutIPNS(ctx, &proto.PutIPNSRequest{ID: id, Record: record})
 		return err
-	}
-	return nil
+	})
 }
 
 type PutIPNSAsyncResult struct {
@@ -26,17 +28,26 @@ type PutIPNSAsyncResult struct {
 }
 
 func (fp *Client) PutIPNSAsync(ctx context.Context, id []byte, record []byte) (<-chan PutIPNSAsyncResult, error) {
+	ctx, endSpan := startSpan(ctx, "Client.PutIPNSAsync")
 	_, err := peer.IDFromBytes(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid peer ID: %w", err)
+		err = fmt.Errorf("invalid peer ID: %w", err)
+		endSpan(err)
+		return nil, err
 	}
 
-	ch0, err := fp.client.PutIPNS_Async(ctx, &proto.PutIPNSRequest{ID: id, Record: record})
+	var ch0 <-chan proto.DelegatedRouting_PutIPNS_AsyncResult
+	err = fp.call(ctx, "PutIPNS", false, func(ctx context.Context) (err error) {
+		ch0, err = fp.client.PutIPNS_Async(ctx, &proto.PutIPNSRequest{ID: id, Record: record})
+		return err
+	})
 	if err != nil {
+		endSpan(err)
 		return nil, err
 	}
 	ch1 := make(chan PutIPNSAsyncResult, 1)
 	go func() {
+		defer endSpan(nil)
 		defer close(ch1)
 		for {
 			select {
@@ -50,7 +61,7 @@ func (fp *Client) PutIPNSAsync(ctx context.Context, id []byte, record []byte) (<
 				case <-ctx.Done():
 					return
 				case ch1 <- PutIPNSAsyncResult{
-					Err: r0.Err,
+					Err: errorCause(r0.Err),
 				}:
 				}
 			}
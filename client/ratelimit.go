@@ -0,0 +1,92 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ipld/edelweiss/services"
+)
+
+// ErrRateLimited is matched by every RateLimitError.
+var ErrRateLimited = errors.New("delegated routing rate limited")
+
+// RateLimitCode is sent by the server in the Error header of its 429 Too Many Requests responses.
+const RateLimitCode = "rate-limited"
+
+// RateLimitError is returned when the server throttles a call. Calls failing with it are retried
+// according to the retry policy, waiting at least RetryAfter.
+type RateLimitError struct {
+	// RetryAfter is the delay requested by the server before the next call, or zero if unknown.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter <= 0 {
+		return ErrRateLimited.Error()
+	}
+	return fmt.Sprintf("%v (retry after %v)", ErrRateLimited, e.RetryAfter)
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RateLimitTransport is an http.RoundTripper which turns the 429 Too Many Requests responses of the server
+// into a RateLimitError carrying the delay of their Retry-After header.
+// Without it, throttled calls are still retried, but after the delays of the retry policy only.
+//
+// RateLimitTransport is installed in the HTTP client used by the protocol client, e.g.
+//
+//	hc := &http.Client{Transport: client.NewRateLimitTransport(http.DefaultTransport)}
+//	q, err := proto.New_DelegatedRouting_Client(endpoint, proto.DelegatedRouting_Client_WithHTTPClient(hc))
+type RateLimitTransport struct {
+	next http.RoundTripper
+}
+
+// NewRateLimitTransport creates a transport reporting throttled requests as a RateLimitError.
+// If next is nil, http.DefaultTransport is used.
+func NewRateLimitTransport(next http.RoundTripper) *RateLimitTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &RateLimitTransport{next: next}
+}
+
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+	resp.Body.Close()
+	return nil, &RateLimitError{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+}
+
+// parseRetryAfter returns the delay of a Retry-After header, given in seconds or as an HTTP date, or zero.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// parseRateLimit returns a RateLimitError if err is the service error of a throttled call
+// which did not go through a RateLimitTransport.
+func parseRateLimit(err error) (*RateLimitError, bool) {
+	var serviceErr services.ErrService
+	if errors.As(err, &serviceErr) && serviceErr.Cause != nil && serviceErr.Cause.Error() == RateLimitCode {
+		return &RateLimitError{}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ipld/edelweiss/services"
+)
+
+// RejectionError is returned when the server rejects a request for a reason identified by a stable code.
+// The server sends it as the code of a protocol error, and the client recovers it, so that
+// errors.Is(err, ErrIPNSStaleRecord) and the like hold on both sides.
+type RejectionError struct {
+	// Code identifies the reason of the rejection.
+	Code string
+	// Reason optionally details the rejection. It is meant for humans and is not stable.
+	Reason string
+}
+
+func (e *RejectionError) Error() string {
+	if e.Reason == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Reason
+}
+
+// Is matches any RejectionError with the same code.
+func (e *RejectionError) Is(target error) bool {
+	t, ok := target.(*RejectionError)
+	return ok && t.Code == e.Code
+}
+
+// WithReason returns a rejection with the code of e, detailed by reason.
+func (e *RejectionError) WithReason(reason string) *RejectionError {
+	return &RejectionError{Code: e.Code, Reason: reason}
+}
+
+// rejectionCodes are the codes recognized in the errors received from the server.
+var rejectionCodes = map[string]bool{}
+
+func newRejection(code string) *RejectionError {
+	rejectionCodes[code] = true
+	return &RejectionError{Code: code}
+}
+
+var (
+	// ErrInvalidRequest is returned when the server cannot make sense of a request.
+	ErrInvalidRequest = newRejection("invalid-request")
+	// ErrInvalidSignature is returned when the signature of a provide request does not verify.
+	ErrInvalidSignature = newRejection("invalid-signature")
+	// ErrServiceRejected is returned when the service of the server rejects a request, for a reason given by Reason only.
+	ErrServiceRejected = newRejection("service-rejected")
+
+	// ErrIPNSInvalidRecord is returned when an IPNS record does not validate against its name.
+	ErrIPNSInvalidRecord = newRejection("ipns-invalid-record")
+	// ErrIPNSExpiredRecord is returned when an IPNS record is past its EOL.
+	ErrIPNSExpiredRecord = newRejection("ipns-expired-record")
+	// ErrIPNSStaleRecord is returned when an IPNS record has a lower sequence number than the stored record,
+	// without a later EOL.
+	ErrIPNSStaleRecord = newRejection("ipns-stale-record")
+
+	// ErrProvideFromFuture is returned when the timestamp of a provide request is too far in the future.
+	ErrProvideFromFuture = newRejection("provide-from-future")
+	// ErrProvideTooOld is returned when the timestamp of a provide request is too far in the past.
+	ErrProvideTooOld = newRejection("provide-too-old")
+	// ErrProvideReplayed is returned when a provide request is older than the newest request accepted from its provider.
+	ErrProvideReplayed = newRejection("provide-replayed")
+	// ErrProvideUnauthorized is returned when the provider is not authorized to provide the keys of a request.
+	ErrProvideUnauthorized = newRejection("provide-unauthorized")
+	// ErrProvideQuotaExceeded is returned when a provide request exceeds the quota of keys of its provider.
+	ErrProvideQuotaExceeded = newRejection("provide-quota-exceeded")
+)
+
+// parseRejection returns the RejectionError carried by a service error received from the server, if any.
+func parseRejection(err error) (*RejectionError, bool) {
+	var serviceErr services.ErrService
+	if !errors.As(err, &serviceErr) || serviceErr.Cause == nil {
+		return nil, false
+	}
+	msg := serviceErr.Cause.Error()
+	code, reason, _ := strings.Cut(msg, ": ")
+	if !rejectionCodes[code] {
+		return nil, false
+	}
+	return &RejectionError{Code: code, Reason: reason}, true
+}
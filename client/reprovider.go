@@ -0,0 +1,356 @@
+package client
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+)
+
+const (
+	// DefaultReprovideBatchSize is the default maximum number of keys announced in a single provide request.
+	DefaultReprovideBatchSize = 1000
+	// DefaultReprovideTTL is the default AdvisoryTTL requested when providing keys.
+	DefaultReprovideTTL = 24 * time.Hour
+	// DefaultReprovideMargin is the default time ahead of the expiry of a granted TTL at which keys are reprovided.
+	DefaultReprovideMargin = time.Hour
+	// DefaultReprovideRetryDelay is the default delay before failed keys are provided again.
+	DefaultReprovideRetryDelay = time.Minute
+)
+
+// ReproviderOption configures optional behavior of a Reprovider.
+type ReproviderOption func(*Reprovider)
+
+// WithReprovideBatchSize sets the maximum number of keys announced in a single provide request.
+func WithReprovideBatchSize(n int) ReproviderOption {
+	return func(r *Reprovider) {
+		if n > 0 {
+			r.batchSize = n
+		}
+	}
+}
+
+// WithReprovideTTL sets the AdvisoryTTL requested when providing keys.
+// The server may grant a different TTL, which is the one reprovides are scheduled by.
+func WithReprovideTTL(ttl time.Duration) ReproviderOption {
+	return func(r *Reprovider) {
+		r.ttl = ttl
+	}
+}
+
+// WithReprovideMargin sets how long before the granted TTL expires keys are reprovided.
+// Keys whose granted TTL is shorter than twice the margin are reprovided halfway through their TTL,
+// but no sooner than the retry delay.
+func WithReprovideMargin(margin time.Duration) ReproviderOption {
+	return func(r *Reprovider) {
+		r.margin = margin
+	}
+}
+
+// WithReprovideRetryDelay sets the delay before keys which failed to be provided are tried again.
+func WithReprovideRetryDelay(d time.Duration) ReproviderOption {
+	return func(r *Reprovider) {
+		r.retryDelay = d
+	}
+}
+
+// ReproviderStats counts the keys tracked by a Reprovider by state.
+type ReproviderStats struct {
+	// Pending keys have not been provided yet.
+	Pending int
+	// Provided keys were provided successfully the last time they were announced.
+	Provided int
+	// Failed keys could not be provided the last time they were announced and will be retried.
+	Failed int
+}
+
+// Reprovider tracks provided keys and announces them to a delegated routing endpoint again
+// before the AdvisoryTTL granted by the endpoint expires.
+// Keys are announced in batches of signed provide requests by the underlying client.
+type Reprovider struct {
+	client     DelegatedRoutingClient
+	batchSize  int
+	ttl        time.Duration
+	margin     time.Duration
+	retryDelay time.Duration
+	ledger     datastore.Datastore
+
+	lk      sync.Mutex
+	entries map[cid.Cid]*reprovideEntry
+	queue   reprovideQueue
+	wake    chan struct{}
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+type reprovideEntry struct {
+	key          cid.Cid
+	lastProvided time.Time     // zero if the key was never provided successfully
+	grantedTTL   time.Duration // the TTL granted at the last successful provide
+	failed       bool
+	next         time.Time // when the key is due to be provided
+	index        int       // index in the queue
+}
+
+// NewReprovider creates a reprovider announcing keys with c once started.
+func NewReprovider(c DelegatedRoutingClient, opts ...ReproviderOption) *Reprovider {
+	r := &Reprovider{
+		client:     c,
+		batchSize:  DefaultReprovideBatchSize,
+		ttl:        DefaultReprovideTTL,
+		margin:     DefaultReprovideMargin,
+		retryDelay: DefaultReprovideRetryDelay,
+		entries:    map[cid.Cid]*reprovideEntry{},
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// Start begins announcing tracked keys in the background as they become due.
+func (r *Reprovider) Start() {
+	r.startOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		r.cancel = cancel
+		go r.run(ctx)
+	})
+}
+
+// Close stops announcing keys and waits for an ongoing announcement to return.
+func (r *Reprovider) Close() {
+	r.closeOnce.Do(func() {
+		r.startOnce.Do(func() { close(r.done) })
+		if r.cancel != nil {
+			r.cancel()
+			<-r.done
+		}
+	})
+}
+
+// Add tracks keys, which are announced by the background loop as soon as possible.
+// Keys which are already tracked keep their schedule.
+func (r *Reprovider) Add(keys ...cid.Cid) {
+	r.lk.Lock()
+	now := time.Now()
+	var added []*reprovideEntry
+	for _, key := range keys {
+		if _, ok := r.entries[key]; ok {
+			continue
+		}
+		e := &reprovideEntry{key: key, next: now}
+		r.entries[key] = e
+		heap.Push(&r.queue, e)
+		added = append(added, e)
+	}
+	r.persist(false, added...)
+	r.lk.Unlock()
+	r.notify()
+}
+
+// Remove stops tracking keys. They are not withdrawn from the endpoint, but expire with their granted TTL.
+func (r *Reprovider) Remove(keys ...cid.Cid) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	var removed []*reprovideEntry
+	for _, key := range keys {
+		if e, ok := r.entries[key]; ok {
+			heap.Remove(&r.queue, e.index)
+			delete(r.entries, key)
+			removed = append(removed, e)
+		}
+	}
+	r.persist(true, removed...)
+}
+
+// Provide tracks keys and announces them immediately, scheduling their reprovide by the TTL granted by the endpoint.
+func (r *Reprovider) Provide(ctx context.Context, keys ...cid.Cid) error {
+	r.lk.Lock()
+	var added []*reprovideEntry
+	for _, key := range keys {
+		if _, ok := r.entries[key]; !ok {
+			// keep new keys out of the background loop until they are provided below
+			e := &reprovideEntry{key: key, next: time.Now().Add(r.retryDelay)}
+			r.entries[key] = e
+			heap.Push(&r.queue, e)
+			added = append(added, e)
+		}
+	}
+	r.persist(false, added...)
+	r.lk.Unlock()
+	r.notify()
+
+	var err error
+	for len(keys) > 0 && err == nil {
+		n := len(keys)
+		if n > r.batchSize {
+			n = r.batchSize
+		}
+		err = r.provide(ctx, keys[:n])
+		keys = keys[n:]
+	}
+	return err
+}
+
+// Stats returns the number of tracked keys in each state.
+func (r *Reprovider) Stats() ReproviderStats {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	var s ReproviderStats
+	for _, e := range r.entries {
+		switch {
+		case e.failed:
+			s.Failed++
+		case e.lastProvided.IsZero():
+			s.Pending++
+		default:
+			s.Provided++
+		}
+	}
+	return s
+}
+
+func (r *Reprovider) notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Reprovider) run(ctx context.Context) {
+	defer close(r.done)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.wake:
+		case <-timer.C:
+		}
+
+		for {
+			keys, next := r.due(time.Now())
+			if len(keys) == 0 {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				if !next.IsZero() {
+					timer.Reset(time.Until(next))
+				}
+				break
+			}
+			if err := r.provide(ctx, keys); err != nil {
+				logger.Infof("reprovide of %d keys failed (%v)", len(keys), err)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}
+}
+
+// due returns up to a batch of keys which are due to be provided at now,
+// or the time the next key is due if there are none.
+func (r *Reprovider) due(now time.Time) ([]cid.Cid, time.Time) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	var keys []cid.Cid
+	for len(r.queue) > 0 && len(keys) < r.batchSize {
+		e := r.queue[0]
+		if e.next.After(now) {
+			break
+		}
+		keys = append(keys, e.key)
+		// push the key back while it is being provided, so that it is retried if the outcome is lost
+		e.next = now.Add(r.retryDelay)
+		heap.Fix(&r.queue, 0)
+	}
+	if len(keys) == 0 && len(r.queue) > 0 {
+		return nil, r.queue[0].next
+	}
+	return keys, time.Time{}
+}
+
+// provide announces keys and reschedules them according to the outcome.
+func (r *Reprovider) provide(ctx context.Context, keys []cid.Cid) error {
+	granted, err := r.client.Provide(ctx, keys, r.ttl)
+	now := time.Now()
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	updated := make([]*reprovideEntry, 0, len(keys))
+	for _, key := range keys {
+		e, ok := r.entries[key]
+		if !ok {
+			// removed while being provided
+			continue
+		}
+		if err != nil {
+			e.failed = true
+			e.next = now.Add(r.retryDelay)
+		} else {
+			e.failed = false
+			e.lastProvided = now
+			e.grantedTTL = granted
+			e.next = now.Add(r.reprovideAfter(granted))
+		}
+		heap.Fix(&r.queue, e.index)
+		updated = append(updated, e)
+	}
+	r.persist(false, updated...)
+	return err
+}
+
+// reprovideAfter returns how long after a provide granted ttl the keys are due again.
+func (r *Reprovider) reprovideAfter(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		// the endpoint did not grant a TTL, so fall back to the one requested
+		ttl = r.ttl
+	}
+	if ttl < 2*r.margin {
+		// keys granted a very short TTL are not reprovided more often than failed keys are retried
+		if ttl/2 < r.retryDelay {
+			return r.retryDelay
+		}
+		return ttl / 2
+	}
+	return ttl - r.margin
+}
+
+// reprovideQueue is a min-heap of entries ordered by the time they are due.
+type reprovideQueue []*reprovideEntry
+
+func (q reprovideQueue) Len() int           { return len(q) }
+func (q reprovideQueue) Less(i, j int) bool { return q[i].next.Before(q[j].next) }
+
+func (q reprovideQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *reprovideQueue) Push(x interface{}) {
+	e := x.(*reprovideEntry)
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *reprovideQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+)
+
+// testProvideClient is a DelegatedRoutingClient recording the keys of its Provide calls,
+// which grant a fixed TTL or fail with a fixed error.
+type testProvideClient struct {
+	TestDelegatedRoutingClient
+
+	lk      sync.Mutex
+	granted time.Duration
+	err     error
+	batches [][]cid.Cid
+}
+
+func (t *testProvideClient) Provide(ctx context.Context, keys []cid.Cid, ttl time.Duration) (time.Duration, error) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	t.batches = append(t.batches, append([]cid.Cid(nil), keys...))
+	return t.granted, t.err
+}
+
+func (t *testProvideClient) setErr(err error) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	t.err = err
+}
+
+// provided returns the number of times each key was provided and the size of the largest batch.
+func (t *testProvideClient) provided() (map[cid.Cid]int, int) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	counts := map[cid.Cid]int{}
+	largest := 0
+	for _, b := range t.batches {
+		for _, key := range b {
+			counts[key]++
+		}
+		if len(b) > largest {
+			largest = len(b)
+		}
+	}
+	return counts, largest
+}
+
+func testReprovideCids(t *testing.T, n int) []cid.Cid {
+	cids := make([]cid.Cid, n)
+	for i := range cids {
+		h, err := multihash.Sum([]byte{byte(i)}, multihash.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cids[i] = cid.NewCidV1(cid.Raw, h)
+	}
+	return cids
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReproviderHonorsGrantedTTL(t *testing.T) {
+	c := &testProvideClient{granted: 200 * time.Millisecond}
+	r := NewReprovider(c, WithReprovideBatchSize(2), WithReprovideMargin(50*time.Millisecond))
+	r.Start()
+	defer r.Close()
+
+	keys := testReprovideCids(t, 5)
+	r.Add(keys...)
+	waitFor(t, "all keys to be provided", func() bool { return r.Stats().Provided == len(keys) })
+
+	// keys are reprovided 150ms after being granted a TTL of 200ms
+	waitFor(t, "all keys to be reprovided", func() bool {
+		counts, _ := c.provided()
+		for _, key := range keys {
+			if counts[key] < 2 {
+				return false
+			}
+		}
+		return true
+	})
+	if _, largest := c.provided(); largest > 2 {
+		t.Errorf("expecting batches of at most 2 keys, got %d", largest)
+	}
+}
+
+func TestReproviderRetriesFailedKeys(t *testing.T) {
+	c := &testProvideClient{granted: time.Hour, err: errors.New("provide failed")}
+	r := NewReprovider(c, WithReprovideRetryDelay(50*time.Millisecond))
+	r.Start()
+	defer r.Close()
+
+	keys := testReprovideCids(t, 3)
+	if err := r.Provide(context.Background(), keys...); err == nil {
+		t.Fatal("expecting provide to fail")
+	}
+	if s := r.Stats(); s.Failed != len(keys) {
+		t.Fatalf("expecting %d failed keys, got %v", len(keys), s)
+	}
+
+	c.setErr(nil)
+	waitFor(t, "failed keys to be retried", func() bool { return r.Stats().Provided == len(keys) })
+
+	r.Remove(keys[0])
+	if s := r.Stats(); s != (ReproviderStats{Provided: len(keys) - 1}) {
+		t.Errorf("expecting %d provided keys after removal, got %v", len(keys)-1, s)
+	}
+}
+
+func TestContentRoutingProvideWithReprovider(t *testing.T) {
+	c := &testProvideClient{granted: time.Hour}
+	r := NewReprovider(c)
+	defer r.Close()
+	crc := NewContentRoutingClient(c, WithReprovider(r))
+
+	key := testReprovideCids(t, 1)[0]
+	if err := crc.Provide(context.Background(), key, true); err != nil {
+		t.Fatal(err)
+	}
+	if s := r.Stats(); s.Provided != 1 {
+		t.Errorf("expecting the key to be tracked as provided, got %v", s)
+	}
+}
+
+func TestReprovideAfter(t *testing.T) {
+	r := NewReprovider(&testProvideClient{}, WithReprovideMargin(time.Minute), WithReprovideRetryDelay(10*time.Second))
+	for ttl, expect := range map[time.Duration]time.Duration{
+		time.Hour:       59 * time.Minute,
+		time.Minute:     30 * time.Second,
+		time.Second:     10 * time.Second,
+		time.Nanosecond: 10 * time.Second,
+	} {
+		if after := r.reprovideAfter(ttl); after != expect {
+			t.Errorf("expecting keys granted %v to be reprovided after %v, got %v", ttl, expect, after)
+		}
+	}
+}
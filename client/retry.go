@@ -0,0 +1,239 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+// ErrCircuitOpen is returned when a call is not attempted because the circuit breaker of the endpoint is open.
+var ErrCircuitOpen = errors.New("delegated routing endpoint circuit breaker is open")
+
+// RetryPolicy configures the retrying of idempotent calls (FindProviders, FindPeer, GetIPNS and Identify)
+// which fail with a network failure or a server error, or are throttled by the server.
+// Server errors are told apart from other failures by the status of their response, which is only known
+// to clients whose transport is assembled by NewTransport.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It doubles with every subsequent retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between retries.
+	MaxDelay time.Duration
+	// Jitter is the fraction, between 0 and 1, of each delay which is randomized.
+	Jitter float64
+}
+
+// DefaultRetryPolicy retries a failed call twice, waiting about 100ms and then 200ms.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   100 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+	Jitter:      0.2,
+}
+
+// delay returns the backoff delay before the given retry, counting from 1.
+func (p RetryPolicy) delay(retry int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < retry && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(p.Jitter * rand.Float64() * float64(d))
+	}
+	return d
+}
+
+// isTransient reports whether err is a failure which may not occur again if the call is retried:
+// a network failure, a response in the 5xx Server Error class, or a throttled call.
+// status is the status of the response which failed the call, as recorded by statusTransport, or zero.
+// Other failures occur again when retried, such as 400 Bad Request and 404 Not Found (services.ErrSchema),
+// 413 Request Entity Too Large (LimitError), the rejections of the service (RejectionError),
+// and the errors returned by the service in the result stream of a 200 OK response.
+func isTransient(err error, status int) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var rejection *RejectionError
+	if errors.As(err, &rejection) || errors.Is(err, ErrLimitExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrRateLimited) {
+		return true
+	}
+	if status != 0 {
+		return status >= http.StatusInternalServerError
+	}
+	// url.Error is a net.Error whatever the failure of the transport
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// statusKey is the context key of the status recorded by statusTransport for an attempt of a call.
+type statusKey struct{}
+
+// statusTransport records the status of the responses received for a call in its context, since the protocol
+// client does not report it. NewTransport installs it next to the network, below the transports which turn
+// responses into errors.
+type statusTransport struct {
+	next http.RoundTripper
+}
+
+func (t statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if status, ok := req.Context().Value(statusKey{}).(*int32); ok && resp != nil {
+		atomic.StoreInt32(status, int32(resp.StatusCode))
+	}
+	return resp, err
+}
+
+// CircuitBreakerPolicy configures a circuit breaker, which stops calling an endpoint after
+// FailureThreshold consecutive transient failures. After Cooldown has passed, a single call is let
+// through to probe the endpoint: if it succeeds the breaker closes, otherwise it opens again.
+type CircuitBreakerPolicy struct {
+	// Endpoint names the endpoint in metrics.
+	Endpoint         string
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+const (
+	circuitClosed   = 0
+	circuitHalfOpen = 1
+	circuitOpen     = 2
+)
+
+type circuitBreaker struct {
+	policy CircuitBreakerPolicy
+
+	lk        sync.Mutex
+	state     int
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(policy CircuitBreakerPolicy) *circuitBreaker {
+	return &circuitBreaker{policy: policy}
+}
+
+// allow reports whether a call may be made to the endpoint.
+func (cb *circuitBreaker) allow() bool {
+	cb.lk.Lock()
+	defer cb.lk.Unlock()
+	switch cb.state {
+	case circuitOpen:
+		if time.Now().Before(cb.openUntil) {
+			return false
+		}
+		cb.setState(circuitHalfOpen)
+		cb.probing = true
+		return true
+	case circuitHalfOpen:
+		if cb.probing {
+			return false
+		}
+		cb.probing = true
+		return true
+	}
+	return true
+}
+
+// ready reports whether the breaker would let a call through, without reserving a probe.
+func (cb *circuitBreaker) ready() bool {
+	cb.lk.Lock()
+	defer cb.lk.Unlock()
+	return cb.state != circuitOpen || !time.Now().Before(cb.openUntil)
+}
+
+// record updates the breaker with the outcome of a call, whose error is transient as told by isTransient.
+// Errors which are not transient, like cancellations or schema errors, say nothing about the health of the endpoint.
+// Neither do throttled calls, which the server answers promptly.
+func (cb *circuitBreaker) record(err error, transient bool) {
+	cb.lk.Lock()
+	defer cb.lk.Unlock()
+	cb.probing = false
+	switch {
+	case err == nil:
+		cb.failures = 0
+		cb.setState(circuitClosed)
+	case transient && !errors.Is(err, ErrRateLimited):
+		cb.failures++
+		if cb.state == circuitHalfOpen || cb.failures >= cb.policy.FailureThreshold {
+			cb.openUntil = time.Now().Add(cb.policy.Cooldown)
+			cb.setState(circuitOpen)
+		}
+	}
+}
+
+func (cb *circuitBreaker) setState(state int) {
+	if cb.state == state {
+		return
+	}
+	cb.state = state
+	stats.RecordWithTags(context.Background(),
+		[]tag.Mutator{tag.Upsert(keyEndpoint, cb.policy.Endpoint)},
+		measureCircuitState.M(int64(state)),
+	)
+}
+
+// call runs fn, which calls the given protocol method with the context it is passed, under the circuit breaker
+// of the client, retrying it according to the retry policy if idempotent is set.
+func (fp *Client) call(ctx context.Context, method string, idempotent bool, fn func(ctx context.Context) error) error {
+	if err := fp.checkCapability(ctx, method); err != nil {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		if fp.breaker != nil && !fp.breaker.allow() {
+			return ErrCircuitOpen
+		}
+		var status int32
+		err := errorCause(fn(context.WithValue(ctx, statusKey{}, &status)))
+		transient := isTransient(err, int(atomic.LoadInt32(&status)))
+		if fp.breaker != nil {
+			fp.breaker.record(err, transient)
+		}
+		if err == nil || !idempotent || attempt >= fp.retry.MaxAttempts || !transient {
+			return err
+		}
+
+		logger.Infof("retrying delegated routing call after transient error (%v)", err)
+		delay := fp.retry.delay(attempt)
+		var rateLimitErr *RateLimitError
+		if errors.As(err, &rateLimitErr) && rateLimitErr.RetryAfter > delay {
+			delay = rateLimitErr.RetryAfter
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Ready reports whether the endpoint is considered available, that is whether its circuit breaker is not open.
+func (fp *Client) Ready() bool {
+	return fp.breaker == nil || fp.breaker.ready()
+}
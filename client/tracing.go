@@ -0,0 +1,128 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the name of the OpenTelemetry tracer used by the client.
+const tracerName = "github.com/ipfs/go-delegated-routing/client"
+
+// tracer is obtained from the global tracer provider, which forwards to the provider installed with otel.SetTracerProvider.
+var tracer = otel.Tracer(tracerName)
+
+// startSpan starts a span named name, as a child of the span in ctx if any.
+// The returned function ends the span when called, recording the passed error.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, func(err error)) {
+	ctx, span := tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, func(err error) {
+		endSpan(span, err)
+	}
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TracingTransport is an http.RoundTripper which traces the HTTP exchanges of the protocol client.
+// It propagates the trace context to the server in the request headers, using the propagator installed
+// with otel.SetTextMapPropagator, and records a span for the exchange and another one for reading
+// and decoding the response stream.
+//
+// TracingTransport is installed in the HTTP client used by the protocol client, e.g.
+//
+//	hc := &http.Client{Transport: client.NewTracingTransport(http.DefaultTransport)}
+//	q, err := proto.New_DelegatedRouting_Client(endpoint, proto.DelegatedRouting_Client_WithHTTPClient(hc))
+type TracingTransport struct {
+	next http.RoundTripper
+}
+
+// NewTracingTransport creates a transport tracing HTTP exchanges.
+// If next is nil, http.DefaultTransport is used.
+func NewTracingTransport(next http.RoundTripper) *TracingTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &TracingTransport{next: next}
+}
+
+func (t *TracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := tracer.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(req.Method),
+			semconv.HTTPURLKey.String(req.URL.Redacted()),
+		),
+	)
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	span.End()
+
+	_, streamSpan := tracer.Start(req.Context(), "ReadResponseStream")
+	resp.Body = &tracedBody{ReadCloser: resp.Body, span: streamSpan}
+	return resp, nil
+}
+
+// tracedBody ends its span when the response stream ends or is closed,
+// recording the number of bytes and new line separated results read.
+type tracedBody struct {
+	io.ReadCloser
+	span trace.Span
+
+	bytes   int64 // accessed atomically, as the body may be closed concurrently
+	results int64
+	endOnce sync.Once
+}
+
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(&b.bytes, int64(n))
+	atomic.AddInt64(&b.results, int64(bytes.Count(p[:n], []byte{'\n'})))
+	if err == io.EOF {
+		b.end(nil)
+	} else if err != nil {
+		b.end(err)
+	}
+	return n, err
+}
+
+func (b *tracedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.end(nil)
+	return err
+}
+
+func (b *tracedBody) end(err error) {
+	b.endOnce.Do(func() {
+		b.span.SetAttributes(
+			attribute.Int64("bytes", atomic.LoadInt64(&b.bytes)),
+			attribute.Int64("results", atomic.LoadInt64(&b.results)),
+		)
+		endSpan(b.span, err)
+	})
+}
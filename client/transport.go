@@ -0,0 +1,64 @@
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+// TransportOptions selects the transports assembled by NewTransport. The zero value selects none of them.
+type TransportOptions struct {
+	// Tracing installs a TracingTransport.
+	Tracing bool
+	// Limits installs a LimitTransport enforcing them, unless they are zero.
+	Limits Limits
+	// DagCBOR installs a DagCBORTransport.
+	DagCBOR bool
+	// Compression installs a CompressionTransport.
+	Compression bool
+	// CacheSize installs a CacheTransport holding at most CacheSize responses for the duration CacheTTL,
+	// unless it is zero.
+	CacheSize int
+	CacheTTL  time.Duration
+	// RateLimit installs a RateLimitTransport.
+	RateLimit bool
+}
+
+// NewTransport assembles the transports selected by o over next, in the order in which they work together:
+// TracingTransport records the exchange as the protocol client sees it, LimitTransport counts the DAG-JSON
+// results decoded by DagCBORTransport and CompressionTransport, and CacheTransport keeps the responses as
+// they are received, which RateLimitTransport turns into errors when they are throttled.
+// Next to next, the transport records the status of responses, which the client needs to retry server errors.
+// If next is nil, http.DefaultTransport is used.
+//
+// The transport is installed in the HTTP client used by the protocol client, e.g.
+//
+//	opts := client.TransportOptions{Limits: client.DefaultLimits, DagCBOR: true, Compression: true, RateLimit: true}
+//	hc := &http.Client{Transport: client.NewTransport(http.DefaultTransport, opts)}
+//	q, err := proto.New_DelegatedRouting_Client(endpoint, proto.DelegatedRouting_Client_WithHTTPClient(hc))
+func NewTransport(next http.RoundTripper, o TransportOptions) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	next = statusTransport{next: next}
+	if o.RateLimit {
+		next = NewRateLimitTransport(next)
+	}
+	if o.CacheSize > 0 {
+		next = NewCacheTransport(next, o.CacheSize, o.CacheTTL)
+	}
+	if o.Compression {
+		next = NewCompressionTransport(next)
+	}
+	if o.DagCBOR {
+		t := NewDagCBORTransport(next)
+		t.maxResultBytes = o.Limits.MaxResultBytes
+		next = t
+	}
+	if o.Limits != (Limits{}) {
+		next = NewLimitTransport(next, o.Limits)
+	}
+	if o.Tracing {
+		next = NewTracingTransport(next)
+	}
+	return next
+}
@@ -622,11 +622,13 @@ func (x DelegatedRouting_Error) Prototype() pd3.NodePrototype {
 // -- protocol type AnonInductive4 --
 
 type AnonInductive4 struct {
-	Identify      *DelegatedRouting_IdentifyArg
-	FindProviders *FindProvidersRequest
-	GetIPNS       *GetIPNSRequest
-	PutIPNS       *PutIPNSRequest
-	Provide       *ProvideRequest
+	Identify           *DelegatedRouting_IdentifyArg
+	FindProviders      *FindProvidersRequest
+	FindProvidersBatch *FindProvidersBatchRequest
+	FindPeer           *FindPeerRequest
+	GetIPNS            *GetIPNSRequest
+	PutIPNS            *PutIPNSRequest
+	Provide            *ProvideRequest
 }
 
 func (x *AnonInductive4) Parse(n pd3.Node) error {
@@ -658,6 +660,20 @@ func (x *AnonInductive4) Parse(n pd3.Node) error {
 		}
 		x.FindProviders = &y
 		return nil
+	case "FindProvidersBatchRequest":
+		var y FindProvidersBatchRequest
+		if err := y.Parse(vn); err != nil {
+			return err
+		}
+		x.FindProvidersBatch = &y
+		return nil
+	case "FindPeerRequest":
+		var y FindPeerRequest
+		if err := y.Parse(vn); err != nil {
+			return err
+		}
+		x.FindPeer = &y
+		return nil
 	case "GetIPNSRequest":
 		var y GetIPNSRequest
 		if err := y.Parse(vn); err != nil {
@@ -701,6 +717,10 @@ func (x *AnonInductive4_MapIterator) Next() (key pd3.Node, value pd3.Node, err e
 			return pd1.String("IdentifyRequest"), x.s.Identify.Node(), nil
 		case x.s.FindProviders != nil:
 			return pd1.String("FindProvidersRequest"), x.s.FindProviders.Node(), nil
+		case x.s.FindProvidersBatch != nil:
+			return pd1.String("FindProvidersBatchRequest"), x.s.FindProvidersBatch.Node(), nil
+		case x.s.FindPeer != nil:
+			return pd1.String("FindPeerRequest"), x.s.FindPeer.Node(), nil
 		case x.s.GetIPNS != nil:
 			return pd1.String("GetIPNSRequest"), x.s.GetIPNS.Node(), nil
 		case x.s.PutIPNS != nil:
@@ -732,6 +752,10 @@ func (x AnonInductive4) LookupByString(key string) (pd3.Node, error) {
 		return x.Identify.Node(), nil
 	case x.FindProviders != nil && key == "FindProvidersRequest":
 		return x.FindProviders.Node(), nil
+	case x.FindProvidersBatch != nil && key == "FindProvidersBatchRequest":
+		return x.FindProvidersBatch.Node(), nil
+	case x.FindPeer != nil && key == "FindPeerRequest":
+		return x.FindPeer.Node(), nil
 	case x.GetIPNS != nil && key == "GetIPNSRequest":
 		return x.GetIPNS.Node(), nil
 	case x.PutIPNS != nil && key == "PutIPNSRequest":
@@ -764,6 +788,10 @@ func (x AnonInductive4) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
 		return x.Identify.Node(), nil
 	case "FindProvidersRequest":
 		return x.FindProviders.Node(), nil
+	case "FindProvidersBatchRequest":
+		return x.FindProvidersBatch.Node(), nil
+	case "FindPeerRequest":
+		return x.FindPeer.Node(), nil
 	case "GetIPNSRequest":
 		return x.GetIPNS.Node(), nil
 	case "PutIPNSRequest":
@@ -826,12 +854,14 @@ func (x AnonInductive4) Prototype() pd3.NodePrototype {
 // -- protocol type AnonInductive5 --
 
 type AnonInductive5 struct {
-	Identify      *DelegatedRouting_IdentifyResult
-	FindProviders *FindProvidersResponse
-	GetIPNS       *GetIPNSResponse
-	PutIPNS       *PutIPNSResponse
-	Provide       *ProvideResponse
-	Error         *DelegatedRouting_Error
+	Identify           *DelegatedRouting_IdentifyResult
+	FindProviders      *FindProvidersResponse
+	FindProvidersBatch *FindProvidersBatchResponse
+	FindPeer           *FindPeerResponse
+	GetIPNS            *GetIPNSResponse
+	PutIPNS            *PutIPNSResponse
+	Provide            *ProvideResponse
+	Error              *DelegatedRouting_Error
 }
 
 func (x *AnonInductive5) Parse(n pd3.Node) error {
@@ -863,6 +893,20 @@ func (x *AnonInductive5) Parse(n pd3.Node) error {
 		}
 		x.FindProviders = &y
 		return nil
+	case "FindProvidersBatchResponse":
+		var y FindProvidersBatchResponse
+		if err := y.Parse(vn); err != nil {
+			return err
+		}
+		x.FindProvidersBatch = &y
+		return nil
+	case "FindPeerResponse":
+		var y FindPeerResponse
+		if err := y.Parse(vn); err != nil {
+			return err
+		}
+		x.FindPeer = &y
+		return nil
 	case "GetIPNSResponse":
 		var y GetIPNSResponse
 		if err := y.Parse(vn); err != nil {
@@ -913,6 +957,10 @@ func (x *AnonInductive5_MapIterator) Next() (key pd3.Node, value pd3.Node, err e
 			return pd1.String("IdentifyResponse"), x.s.Identify.Node(), nil
 		case x.s.FindProviders != nil:
 			return pd1.String("FindProvidersResponse"), x.s.FindProviders.Node(), nil
+		case x.s.FindProvidersBatch != nil:
+			return pd1.String("FindProvidersBatchResponse"), x.s.FindProvidersBatch.Node(), nil
+		case x.s.FindPeer != nil:
+			return pd1.String("FindPeerResponse"), x.s.FindPeer.Node(), nil
 		case x.s.GetIPNS != nil:
 			return pd1.String("GetIPNSResponse"), x.s.GetIPNS.Node(), nil
 		case x.s.PutIPNS != nil:
@@ -946,6 +994,10 @@ func (x AnonInductive5) LookupByString(key string) (pd3.Node, error) {
 		return x.Identify.Node(), nil
 	case x.FindProviders != nil && key == "FindProvidersResponse":
 		return x.FindProviders.Node(), nil
+	case x.FindProvidersBatch != nil && key == "FindProvidersBatchResponse":
+		return x.FindProvidersBatch.Node(), nil
+	case x.FindPeer != nil && key == "FindPeerResponse":
+		return x.FindPeer.Node(), nil
 	case x.GetIPNS != nil && key == "GetIPNSResponse":
 		return x.GetIPNS.Node(), nil
 	case x.PutIPNS != nil && key == "PutIPNSResponse":
@@ -980,6 +1032,10 @@ func (x AnonInductive5) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
 		return x.Identify.Node(), nil
 	case "FindProvidersResponse":
 		return x.FindProviders.Node(), nil
+	case "FindProvidersBatchResponse":
+		return x.FindProvidersBatch.Node(), nil
+	case "FindPeerResponse":
+		return x.FindPeer.Node(), nil
 	case "GetIPNSResponse":
 		return x.GetIPNS.Node(), nil
 	case "PutIPNSResponse":
@@ -1048,6 +1104,10 @@ type DelegatedRouting_Client interface {
 
 	FindProviders(ctx pd7.Context, req *FindProvidersRequest) ([]*FindProvidersResponse, error)
 
+	FindProvidersBatch(ctx pd7.Context, req *FindProvidersBatchRequest) ([]*FindProvidersBatchResponse, error)
+
+	FindPeer(ctx pd7.Context, req *FindPeerRequest) ([]*FindPeerResponse, error)
+
 	GetIPNS(ctx pd7.Context, req *GetIPNSRequest) ([]*GetIPNSResponse, error)
 
 	PutIPNS(ctx pd7.Context, req *PutIPNSRequest) ([]*PutIPNSResponse, error)
@@ -1058,6 +1118,10 @@ type DelegatedRouting_Client interface {
 
 	FindProviders_Async(ctx pd7.Context, req *FindProvidersRequest) (<-chan DelegatedRouting_FindProviders_AsyncResult, error)
 
+	FindProvidersBatch_Async(ctx pd7.Context, req *FindProvidersBatchRequest) (<-chan DelegatedRouting_FindProvidersBatch_AsyncResult, error)
+
+	FindPeer_Async(ctx pd7.Context, req *FindPeerRequest) (<-chan DelegatedRouting_FindPeer_AsyncResult, error)
+
 	GetIPNS_Async(ctx pd7.Context, req *GetIPNSRequest) (<-chan DelegatedRouting_GetIPNS_AsyncResult, error)
 
 	PutIPNS_Async(ctx pd7.Context, req *PutIPNSRequest) (<-chan DelegatedRouting_PutIPNS_AsyncResult, error)
@@ -1075,6 +1139,16 @@ type DelegatedRouting_FindProviders_AsyncResult struct {
 	Err  error
 }
 
+type DelegatedRouting_FindProvidersBatch_AsyncResult struct {
+	Resp *FindProvidersBatchResponse
+	Err  error
+}
+
+type DelegatedRouting_FindPeer_AsyncResult struct {
+	Resp *FindPeerResponse
+	Err  error
+}
+
 type DelegatedRouting_GetIPNS_AsyncResult struct {
 	Resp *GetIPNSResponse
 	Err  error
@@ -1410,14 +1484,14 @@ func process_DelegatedRouting_FindProviders_AsyncResult(ctx pd7.Context, ch chan
 	}
 }
 
-func (c *client_DelegatedRouting) GetIPNS(ctx pd7.Context, req *GetIPNSRequest) ([]*GetIPNSResponse, error) {
+func (c *client_DelegatedRouting) FindProvidersBatch(ctx pd7.Context, req *FindProvidersBatchRequest) ([]*FindProvidersBatchResponse, error) {
 	ctx, cancel := pd7.WithCancel(ctx)
 	defer cancel()
-	ch, err := c.GetIPNS_Async(ctx, req)
+	ch, err := c.FindProvidersBatch_Async(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	var resps []*GetIPNSResponse
+	var resps []*FindProvidersBatchResponse
 	for {
 		select {
 		case r, ok := <-ch:
@@ -1439,17 +1513,17 @@ func (c *client_DelegatedRouting) GetIPNS(ctx pd7.Context, req *GetIPNSRequest)
 	}
 }
 
-func (c *client_DelegatedRouting) GetIPNS_Async(ctx pd7.Context, req *GetIPNSRequest) (<-chan DelegatedRouting_GetIPNS_AsyncResult, error) {
+func (c *client_DelegatedRouting) FindProvidersBatch_Async(ctx pd7.Context, req *FindProvidersBatchRequest) (<-chan DelegatedRouting_FindProvidersBatch_AsyncResult, error) {
 	// check if we have memoized that this method is not supported by the server
 	c.ulk.Lock()
-	notSupported := c.unsupported["GetIPNS"]
+	notSupported := c.unsupported["FindProvidersBatch"]
 	c.ulk.Unlock()
 	if notSupported {
 		return nil, pd14.ErrSchema
 	}
 
 	envelope := &AnonInductive4{
-		GetIPNS: req,
+		FindProvidersBatch: req,
 	}
 
 	buf, err := pd12.Encode(envelope, pd9.Encode)
@@ -1482,7 +1556,7 @@ func (c *client_DelegatedRouting) GetIPNS_Async(ctx pd7.Context, req *GetIPNSReq
 		resp.Body.Close()
 		// memoize that this method is not supported by the server
 		c.ulk.Lock()
-		c.unsupported["GetIPNS"] = true
+		c.unsupported["FindProvidersBatch"] = true
 		c.ulk.Unlock()
 		return nil, pd14.ErrSchema
 	}
@@ -1502,12 +1576,12 @@ func (c *client_DelegatedRouting) GetIPNS_Async(ctx pd7.Context, req *GetIPNSReq
 		return nil, err
 	}
 
-	ch := make(chan DelegatedRouting_GetIPNS_AsyncResult, 1)
-	go process_DelegatedRouting_GetIPNS_AsyncResult(ctx, ch, resp.Body)
+	ch := make(chan DelegatedRouting_FindProvidersBatch_AsyncResult, 1)
+	go process_DelegatedRouting_FindProvidersBatch_AsyncResult(ctx, ch, resp.Body)
 	return ch, nil
 }
 
-func process_DelegatedRouting_GetIPNS_AsyncResult(ctx pd7.Context, ch chan<- DelegatedRouting_GetIPNS_AsyncResult, r pd11.ReadCloser) {
+func process_DelegatedRouting_FindProvidersBatch_AsyncResult(ctx pd7.Context, ch chan<- DelegatedRouting_FindProvidersBatch_AsyncResult, r pd11.ReadCloser) {
 	defer close(ch)
 	defer r.Close()
 	opt := pd9.DecodeOptions{
@@ -1516,7 +1590,7 @@ func process_DelegatedRouting_GetIPNS_AsyncResult(ctx pd7.Context, ch chan<- Del
 		DontParseBeyondEnd: true,
 	}
 	for {
-		var out DelegatedRouting_GetIPNS_AsyncResult
+		var out DelegatedRouting_FindProvidersBatch_AsyncResult
 
 		n, err := pd12.DecodeStreaming(r, opt.Decode)
 
@@ -1525,19 +1599,19 @@ func process_DelegatedRouting_GetIPNS_AsyncResult(ctx pd7.Context, ch chan<- Del
 		}
 
 		if err != nil {
-			out = DelegatedRouting_GetIPNS_AsyncResult{Err: pd14.ErrProto{Cause: err}} // IPLD decode error
+			out = DelegatedRouting_FindProvidersBatch_AsyncResult{Err: pd14.ErrProto{Cause: err}} // IPLD decode error
 		} else {
 			var x [1]byte
 			if k, err := r.Read(x[:]); k != 1 || x[0] != '\n' {
-				out = DelegatedRouting_GetIPNS_AsyncResult{Err: pd14.ErrProto{Cause: pd2.Errorf("missing new line after result: err (%v), read (%d), char (%q)", err, k, string(x[:]))}} // Edelweiss decode error
+				out = DelegatedRouting_FindProvidersBatch_AsyncResult{Err: pd14.ErrProto{Cause: pd2.Errorf("missing new line after result: err (%v), read (%d), char (%q)", err, k, string(x[:]))}} // Edelweiss decode error
 			} else {
 				env := &AnonInductive5{}
 				if err = env.Parse(n); err != nil {
-					out = DelegatedRouting_GetIPNS_AsyncResult{Err: pd14.ErrProto{Cause: err}} // schema decode error
+					out = DelegatedRouting_FindProvidersBatch_AsyncResult{Err: pd14.ErrProto{Cause: err}} // schema decode error
 				} else if env.Error != nil {
-					out = DelegatedRouting_GetIPNS_AsyncResult{Err: pd14.ErrService{Cause: pd10.New(string(env.Error.Code))}} // service-level error
-				} else if env.GetIPNS != nil {
-					out = DelegatedRouting_GetIPNS_AsyncResult{Resp: env.GetIPNS}
+					out = DelegatedRouting_FindProvidersBatch_AsyncResult{Err: pd14.ErrService{Cause: pd10.New(string(env.Error.Code))}} // service-level error
+				} else if env.FindProvidersBatch != nil {
+					out = DelegatedRouting_FindProvidersBatch_AsyncResult{Resp: env.FindProvidersBatch}
 				} else {
 					continue
 				}
@@ -1552,14 +1626,14 @@ func process_DelegatedRouting_GetIPNS_AsyncResult(ctx pd7.Context, ch chan<- Del
 	}
 }
 
-func (c *client_DelegatedRouting) PutIPNS(ctx pd7.Context, req *PutIPNSRequest) ([]*PutIPNSResponse, error) {
+func (c *client_DelegatedRouting) FindPeer(ctx pd7.Context, req *FindPeerRequest) ([]*FindPeerResponse, error) {
 	ctx, cancel := pd7.WithCancel(ctx)
 	defer cancel()
-	ch, err := c.PutIPNS_Async(ctx, req)
+	ch, err := c.FindPeer_Async(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	var resps []*PutIPNSResponse
+	var resps []*FindPeerResponse
 	for {
 		select {
 		case r, ok := <-ch:
@@ -1581,20 +1655,20 @@ func (c *client_DelegatedRouting) PutIPNS(ctx pd7.Context, req *PutIPNSRequest)
 	}
 }
 
-func (c *client_DelegatedRouting) PutIPNS_Async(ctx pd7.Context, req *PutIPNSRequest) (<-chan DelegatedRouting_PutIPNS_AsyncResult, error) {
+func (c *client_DelegatedRouting) FindPeer_Async(ctx pd7.Context, req *FindPeerRequest) (<-chan DelegatedRouting_FindPeer_AsyncResult, error) {
 	// check if we have memoized that this method is not supported by the server
 	c.ulk.Lock()
-	notSupported := c.unsupported["PutIPNS"]
+	notSupported := c.unsupported["FindPeer"]
 	c.ulk.Unlock()
 	if notSupported {
 		return nil, pd14.ErrSchema
 	}
 
 	envelope := &AnonInductive4{
-		PutIPNS: req,
+		FindPeer: req,
 	}
 
-	buf, err := pd12.Encode(envelope, pd9.Encode)
+	buf, err := pd12.Encode(envelope, pd8.Encode) // XXX: apply binary encoding on top?
 
 	if err != nil {
 		return nil, pd2.Errorf("serializing DAG-JSON request: %w", err)
@@ -1603,7 +1677,10 @@ func (c *client_DelegatedRouting) PutIPNS_Async(ctx pd7.Context, req *PutIPNSReq
 	// encode request in URL
 	u := *c.endpoint
 
-	httpReq, err := pd4.NewRequestWithContext(ctx, "POST", u.String(), pd6.NewReader(buf))
+	q := pd13.Values{}
+	q.Set("q", string(buf))
+	u.RawQuery = q.Encode()
+	httpReq, err := pd4.NewRequestWithContext(ctx, "GET", u.String(), nil)
 
 	if err != nil {
 		return nil, err
@@ -1624,7 +1701,7 @@ func (c *client_DelegatedRouting) PutIPNS_Async(ctx pd7.Context, req *PutIPNSReq
 		resp.Body.Close()
 		// memoize that this method is not supported by the server
 		c.ulk.Lock()
-		c.unsupported["PutIPNS"] = true
+		c.unsupported["FindPeer"] = true
 		c.ulk.Unlock()
 		return nil, pd14.ErrSchema
 	}
@@ -1644,12 +1721,12 @@ func (c *client_DelegatedRouting) PutIPNS_Async(ctx pd7.Context, req *PutIPNSReq
 		return nil, err
 	}
 
-	ch := make(chan DelegatedRouting_PutIPNS_AsyncResult, 1)
-	go process_DelegatedRouting_PutIPNS_AsyncResult(ctx, ch, resp.Body)
+	ch := make(chan DelegatedRouting_FindPeer_AsyncResult, 1)
+	go process_DelegatedRouting_FindPeer_AsyncResult(ctx, ch, resp.Body)
 	return ch, nil
 }
 
-func process_DelegatedRouting_PutIPNS_AsyncResult(ctx pd7.Context, ch chan<- DelegatedRouting_PutIPNS_AsyncResult, r pd11.ReadCloser) {
+func process_DelegatedRouting_FindPeer_AsyncResult(ctx pd7.Context, ch chan<- DelegatedRouting_FindPeer_AsyncResult, r pd11.ReadCloser) {
 	defer close(ch)
 	defer r.Close()
 	opt := pd9.DecodeOptions{
@@ -1658,7 +1735,7 @@ func process_DelegatedRouting_PutIPNS_AsyncResult(ctx pd7.Context, ch chan<- Del
 		DontParseBeyondEnd: true,
 	}
 	for {
-		var out DelegatedRouting_PutIPNS_AsyncResult
+		var out DelegatedRouting_FindPeer_AsyncResult
 
 		n, err := pd12.DecodeStreaming(r, opt.Decode)
 
@@ -1667,19 +1744,19 @@ func process_DelegatedRouting_PutIPNS_AsyncResult(ctx pd7.Context, ch chan<- Del
 		}
 
 		if err != nil {
-			out = DelegatedRouting_PutIPNS_AsyncResult{Err: pd14.ErrProto{Cause: err}} // IPLD decode error
+			out = DelegatedRouting_FindPeer_AsyncResult{Err: pd14.ErrProto{Cause: err}} // IPLD decode error
 		} else {
 			var x [1]byte
 			if k, err := r.Read(x[:]); k != 1 || x[0] != '\n' {
-				out = DelegatedRouting_PutIPNS_AsyncResult{Err: pd14.ErrProto{Cause: pd2.Errorf("missing new line after result: err (%v), read (%d), char (%q)", err, k, string(x[:]))}} // Edelweiss decode error
+				out = DelegatedRouting_FindPeer_AsyncResult{Err: pd14.ErrProto{Cause: pd2.Errorf("missing new line after result: err (%v), read (%d), char (%q)", err, k, string(x[:]))}} // Edelweiss decode error
 			} else {
 				env := &AnonInductive5{}
 				if err = env.Parse(n); err != nil {
-					out = DelegatedRouting_PutIPNS_AsyncResult{Err: pd14.ErrProto{Cause: err}} // schema decode error
+					out = DelegatedRouting_FindPeer_AsyncResult{Err: pd14.ErrProto{Cause: err}} // schema decode error
 				} else if env.Error != nil {
-					out = DelegatedRouting_PutIPNS_AsyncResult{Err: pd14.ErrService{Cause: pd10.New(string(env.Error.Code))}} // service-level error
-				} else if env.PutIPNS != nil {
-					out = DelegatedRouting_PutIPNS_AsyncResult{Resp: env.PutIPNS}
+					out = DelegatedRouting_FindPeer_AsyncResult{Err: pd14.ErrService{Cause: pd10.New(string(env.Error.Code))}} // service-level error
+				} else if env.FindPeer != nil {
+					out = DelegatedRouting_FindPeer_AsyncResult{Resp: env.FindPeer}
 				} else {
 					continue
 				}
@@ -1694,14 +1771,14 @@ func process_DelegatedRouting_PutIPNS_AsyncResult(ctx pd7.Context, ch chan<- Del
 	}
 }
 
-func (c *client_DelegatedRouting) Provide(ctx pd7.Context, req *ProvideRequest) ([]*ProvideResponse, error) {
+func (c *client_DelegatedRouting) GetIPNS(ctx pd7.Context, req *GetIPNSRequest) ([]*GetIPNSResponse, error) {
 	ctx, cancel := pd7.WithCancel(ctx)
 	defer cancel()
-	ch, err := c.Provide_Async(ctx, req)
+	ch, err := c.GetIPNS_Async(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	var resps []*ProvideResponse
+	var resps []*GetIPNSResponse
 	for {
 		select {
 		case r, ok := <-ch:
@@ -1723,17 +1800,17 @@ func (c *client_DelegatedRouting) Provide(ctx pd7.Context, req *ProvideRequest)
 	}
 }
 
-func (c *client_DelegatedRouting) Provide_Async(ctx pd7.Context, req *ProvideRequest) (<-chan DelegatedRouting_Provide_AsyncResult, error) {
+func (c *client_DelegatedRouting) GetIPNS_Async(ctx pd7.Context, req *GetIPNSRequest) (<-chan DelegatedRouting_GetIPNS_AsyncResult, error) {
 	// check if we have memoized that this method is not supported by the server
 	c.ulk.Lock()
-	notSupported := c.unsupported["Provide"]
+	notSupported := c.unsupported["GetIPNS"]
 	c.ulk.Unlock()
 	if notSupported {
 		return nil, pd14.ErrSchema
 	}
 
 	envelope := &AnonInductive4{
-		Provide: req,
+		GetIPNS: req,
 	}
 
 	buf, err := pd12.Encode(envelope, pd9.Encode)
@@ -1766,7 +1843,7 @@ func (c *client_DelegatedRouting) Provide_Async(ctx pd7.Context, req *ProvideReq
 		resp.Body.Close()
 		// memoize that this method is not supported by the server
 		c.ulk.Lock()
-		c.unsupported["Provide"] = true
+		c.unsupported["GetIPNS"] = true
 		c.ulk.Unlock()
 		return nil, pd14.ErrSchema
 	}
@@ -1786,12 +1863,12 @@ func (c *client_DelegatedRouting) Provide_Async(ctx pd7.Context, req *ProvideReq
 		return nil, err
 	}
 
-	ch := make(chan DelegatedRouting_Provide_AsyncResult, 1)
-	go process_DelegatedRouting_Provide_AsyncResult(ctx, ch, resp.Body)
+	ch := make(chan DelegatedRouting_GetIPNS_AsyncResult, 1)
+	go process_DelegatedRouting_GetIPNS_AsyncResult(ctx, ch, resp.Body)
 	return ch, nil
 }
 
-func process_DelegatedRouting_Provide_AsyncResult(ctx pd7.Context, ch chan<- DelegatedRouting_Provide_AsyncResult, r pd11.ReadCloser) {
+func process_DelegatedRouting_GetIPNS_AsyncResult(ctx pd7.Context, ch chan<- DelegatedRouting_GetIPNS_AsyncResult, r pd11.ReadCloser) {
 	defer close(ch)
 	defer r.Close()
 	opt := pd9.DecodeOptions{
@@ -1800,7 +1877,7 @@ func process_DelegatedRouting_Provide_AsyncResult(ctx pd7.Context, ch chan<- Del
 		DontParseBeyondEnd: true,
 	}
 	for {
-		var out DelegatedRouting_Provide_AsyncResult
+		var out DelegatedRouting_GetIPNS_AsyncResult
 
 		n, err := pd12.DecodeStreaming(r, opt.Decode)
 
@@ -1809,19 +1886,19 @@ func process_DelegatedRouting_Provide_AsyncResult(ctx pd7.Context, ch chan<- Del
 		}
 
 		if err != nil {
-			out = DelegatedRouting_Provide_AsyncResult{Err: pd14.ErrProto{Cause: err}} // IPLD decode error
+			out = DelegatedRouting_GetIPNS_AsyncResult{Err: pd14.ErrProto{Cause: err}} // IPLD decode error
 		} else {
 			var x [1]byte
 			if k, err := r.Read(x[:]); k != 1 || x[0] != '\n' {
-				out = DelegatedRouting_Provide_AsyncResult{Err: pd14.ErrProto{Cause: pd2.Errorf("missing new line after result: err (%v), read (%d), char (%q)", err, k, string(x[:]))}} // Edelweiss decode error
+				out = DelegatedRouting_GetIPNS_AsyncResult{Err: pd14.ErrProto{Cause: pd2.Errorf("missing new line after result: err (%v), read (%d), char (%q)", err, k, string(x[:]))}} // Edelweiss decode error
 			} else {
 				env := &AnonInductive5{}
 				if err = env.Parse(n); err != nil {
-					out = DelegatedRouting_Provide_AsyncResult{Err: pd14.ErrProto{Cause: err}} // schema decode error
+					out = DelegatedRouting_GetIPNS_AsyncResult{Err: pd14.ErrProto{Cause: err}} // schema decode error
 				} else if env.Error != nil {
-					out = DelegatedRouting_Provide_AsyncResult{Err: pd14.ErrService{Cause: pd10.New(string(env.Error.Code))}} // service-level error
-				} else if env.Provide != nil {
-					out = DelegatedRouting_Provide_AsyncResult{Resp: env.Provide}
+					out = DelegatedRouting_GetIPNS_AsyncResult{Err: pd14.ErrService{Cause: pd10.New(string(env.Error.Code))}} // service-level error
+				} else if env.GetIPNS != nil {
+					out = DelegatedRouting_GetIPNS_AsyncResult{Resp: env.GetIPNS}
 				} else {
 					continue
 				}
@@ -1836,95 +1913,774 @@ func process_DelegatedRouting_Provide_AsyncResult(ctx pd7.Context, ch chan<- Del
 	}
 }
 
-var logger_server_DelegatedRouting = pd5.Logger("service/server/delegatedrouting")
-
-type DelegatedRouting_Server interface {
-	FindProviders(ctx pd7.Context, req *FindProvidersRequest) (<-chan *DelegatedRouting_FindProviders_AsyncResult, error)
-	GetIPNS(ctx pd7.Context, req *GetIPNSRequest) (<-chan *DelegatedRouting_GetIPNS_AsyncResult, error)
-	PutIPNS(ctx pd7.Context, req *PutIPNSRequest) (<-chan *DelegatedRouting_PutIPNS_AsyncResult, error)
-	Provide(ctx pd7.Context, req *ProvideRequest) (<-chan *DelegatedRouting_Provide_AsyncResult, error)
+func (c *client_DelegatedRouting) PutIPNS(ctx pd7.Context, req *PutIPNSRequest) ([]*PutIPNSResponse, error) {
+	ctx, cancel := pd7.WithCancel(ctx)
+	defer cancel()
+	ch, err := c.PutIPNS_Async(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var resps []*PutIPNSResponse
+	for {
+		select {
+		case r, ok := <-ch:
+			if !ok {
+				cancel()
+				return resps, nil
+			} else {
+				if r.Err == nil {
+					resps = append(resps, r.Resp)
+				} else {
+					logger_client_DelegatedRouting.Errorf("client received error response (%v)", r.Err)
+					cancel()
+					return resps, r.Err
+				}
+			}
+		case <-ctx.Done():
+			return resps, ctx.Err()
+		}
+	}
 }
 
-func DelegatedRouting_AsyncHandler(s DelegatedRouting_Server) pd4.HandlerFunc {
-	return func(writer pd4.ResponseWriter, request *pd4.Request) {
-		// parse request
-		env := &AnonInductive4{}
-		isReqCachable := false
-		switch request.Method {
-		case "POST":
-			isReqCachable = false
-			msg, err := pd11.ReadAll(request.Body)
-			if err != nil {
-				logger_server_DelegatedRouting.Errorf("reading request body (%v)", err)
-				writer.WriteHeader(400)
-				return
-			}
-			n, err := pd12.Decode(msg, pd9.Decode)
-			if err != nil {
-				logger_server_DelegatedRouting.Errorf("received request not decodeable (%v)", err)
-				writer.WriteHeader(400)
-				return
-			}
-			if err = env.Parse(n); err != nil {
-				logger_server_DelegatedRouting.Errorf("parsing call envelope (%v)", err)
-				writer.WriteHeader(400)
-				return
-			}
-		case "GET":
-			isReqCachable = true
-			msg := request.URL.Query().Get("q")
-			n, err := pd12.Decode([]byte(msg), pd8.Decode)
-			if err != nil {
-				logger_server_DelegatedRouting.Errorf("received url not decodeable (%v)", err)
-				writer.WriteHeader(400)
-				return
-			}
+func (c *client_DelegatedRouting) PutIPNS_Async(ctx pd7.Context, req *PutIPNSRequest) (<-chan DelegatedRouting_PutIPNS_AsyncResult, error) {
+	// check if we have memoized that this method is not supported by the server
+	c.ulk.Lock()
+	notSupported := c.unsupported["PutIPNS"]
+	c.ulk.Unlock()
+	if notSupported {
+		return nil, pd14.ErrSchema
+	}
 
-			if err = env.Parse(n); err != nil {
-				logger_server_DelegatedRouting.Errorf("parsing call envelope (%v)", err)
-				writer.WriteHeader(400)
-				return
-			}
-		default:
-			logger_server_DelegatedRouting.Errorf("http method not supported")
-			writer.WriteHeader(400)
-			return
-		}
-		_ = isReqCachable
+	envelope := &AnonInductive4{
+		PutIPNS: req,
+	}
 
-		writer.Header()["Content-Type"] = []string{
-			"application/vnd.ipfs.rpc+dag-json; version=1",
-		}
+	buf, err := pd12.Encode(envelope, pd9.Encode)
 
-		// demultiplex request
-		var err error
-		switch {
+	if err != nil {
+		return nil, pd2.Errorf("serializing DAG-JSON request: %w", err)
+	}
 
-		case env.FindProviders != nil:
+	// encode request in URL
+	u := *c.endpoint
 
-			ch, err := s.FindProviders(request.Context(), env.FindProviders)
-			if err != nil {
-				logger_server_DelegatedRouting.Errorf("service rejected request (%v)", err)
-				writer.Header()["Error"] = []string{err.Error()}
-				writer.WriteHeader(500)
-				return
-			}
+	httpReq, err := pd4.NewRequestWithContext(ctx, "POST", u.String(), pd6.NewReader(buf))
 
-			// if the request is cachable, collect all async results in a buffer, otherwise write them directly to http
-			var resultWriter pd11.Writer
-			if isReqCachable {
-				resultWriter = new(pd6.Buffer)
-			} else {
-				resultWriter = writer
-				writer.WriteHeader(200)
-				if f, ok := writer.(pd4.Flusher); ok {
-					f.Flush()
-				}
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header = map[string][]string{
+		"Accept": {
+			"application/vnd.ipfs.rpc+dag-json; version=1",
+		},
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, pd2.Errorf("sending HTTP request: %w", err)
+	}
 
+	// HTTP codes 400 and 404 correspond to unrecognized method or request schema
+	if resp.StatusCode == 400 || resp.StatusCode == 404 {
+		resp.Body.Close()
+		// memoize that this method is not supported by the server
+		c.ulk.Lock()
+		c.unsupported["PutIPNS"] = true
+		c.ulk.Unlock()
+		return nil, pd14.ErrSchema
+	}
+	// HTTP codes other than 200 correspond to service implementation rejecting the call when it is received
+	// for reasons unrelated to protocol schema
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
+		if resp.Header != nil {
+			if errValues, ok := resp.Header["Error"]; ok && len(errValues) == 1 {
+				err = pd14.ErrService{Cause: pd2.Errorf("%s", errValues[0])}
+			} else {
+				err = pd2.Errorf("service rejected the call, no cause provided")
 			}
-			// if the request is cachable, compute an etag and send the collected results to http
-			if isReqCachable {
-				defer func() {
+		} else {
+			err = pd2.Errorf("service rejected the call")
+		}
+		return nil, err
+	}
+
+	ch := make(chan DelegatedRouting_PutIPNS_AsyncResult, 1)
+	go process_DelegatedRouting_PutIPNS_AsyncResult(ctx, ch, resp.Body)
+	return ch, nil
+}
+
+func process_DelegatedRouting_PutIPNS_AsyncResult(ctx pd7.Context, ch chan<- DelegatedRouting_PutIPNS_AsyncResult, r pd11.ReadCloser) {
+	defer close(ch)
+	defer r.Close()
+	opt := pd9.DecodeOptions{
+		ParseLinks:         true,
+		ParseBytes:         true,
+		DontParseBeyondEnd: true,
+	}
+	for {
+		var out DelegatedRouting_PutIPNS_AsyncResult
+
+		n, err := pd12.DecodeStreaming(r, opt.Decode)
+
+		if pd10.Is(err, pd11.EOF) || pd10.Is(err, pd11.ErrUnexpectedEOF) || pd10.Is(err, pd7.DeadlineExceeded) || pd10.Is(err, pd7.Canceled) {
+			return
+		}
+
+		if err != nil {
+			out = DelegatedRouting_PutIPNS_AsyncResult{Err: pd14.ErrProto{Cause: err}} // IPLD decode error
+		} else {
+			var x [1]byte
+			if k, err := r.Read(x[:]); k != 1 || x[0] != '\n' {
+				out = DelegatedRouting_PutIPNS_AsyncResult{Err: pd14.ErrProto{Cause: pd2.Errorf("missing new line after result: err (%v), read (%d), char (%q)", err, k, string(x[:]))}} // Edelweiss decode error
+			} else {
+				env := &AnonInductive5{}
+				if err = env.Parse(n); err != nil {
+					out = DelegatedRouting_PutIPNS_AsyncResult{Err: pd14.ErrProto{Cause: err}} // schema decode error
+				} else if env.Error != nil {
+					out = DelegatedRouting_PutIPNS_AsyncResult{Err: pd14.ErrService{Cause: pd10.New(string(env.Error.Code))}} // service-level error
+				} else if env.PutIPNS != nil {
+					out = DelegatedRouting_PutIPNS_AsyncResult{Resp: env.PutIPNS}
+				} else {
+					continue
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case ch <- out:
+		}
+	}
+}
+
+func (c *client_DelegatedRouting) Provide(ctx pd7.Context, req *ProvideRequest) ([]*ProvideResponse, error) {
+	ctx, cancel := pd7.WithCancel(ctx)
+	defer cancel()
+	ch, err := c.Provide_Async(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var resps []*ProvideResponse
+	for {
+		select {
+		case r, ok := <-ch:
+			if !ok {
+				cancel()
+				return resps, nil
+			} else {
+				if r.Err == nil {
+					resps = append(resps, r.Resp)
+				} else {
+					logger_client_DelegatedRouting.Errorf("client received error response (%v)", r.Err)
+					cancel()
+					return resps, r.Err
+				}
+			}
+		case <-ctx.Done():
+			return resps, ctx.Err()
+		}
+	}
+}
+
+func (c *client_DelegatedRouting) Provide_Async(ctx pd7.Context, req *ProvideRequest) (<-chan DelegatedRouting_Provide_AsyncResult, error) {
+	// check if we have memoized that this method is not supported by the server
+	c.ulk.Lock()
+	notSupported := c.unsupported["Provide"]
+	c.ulk.Unlock()
+	if notSupported {
+		return nil, pd14.ErrSchema
+	}
+
+	envelope := &AnonInductive4{
+		Provide: req,
+	}
+
+	buf, err := pd12.Encode(envelope, pd9.Encode)
+
+	if err != nil {
+		return nil, pd2.Errorf("serializing DAG-JSON request: %w", err)
+	}
+
+	// encode request in URL
+	u := *c.endpoint
+
+	httpReq, err := pd4.NewRequestWithContext(ctx, "POST", u.String(), pd6.NewReader(buf))
+
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header = map[string][]string{
+		"Accept": {
+			"application/vnd.ipfs.rpc+dag-json; version=1",
+		},
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, pd2.Errorf("sending HTTP request: %w", err)
+	}
+
+	// HTTP codes 400 and 404 correspond to unrecognized method or request schema
+	if resp.StatusCode == 400 || resp.StatusCode == 404 {
+		resp.Body.Close()
+		// memoize that this method is not supported by the server
+		c.ulk.Lock()
+		c.unsupported["Provide"] = true
+		c.ulk.Unlock()
+		return nil, pd14.ErrSchema
+	}
+	// HTTP codes other than 200 correspond to service implementation rejecting the call when it is received
+	// for reasons unrelated to protocol schema
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
+		if resp.Header != nil {
+			if errValues, ok := resp.Header["Error"]; ok && len(errValues) == 1 {
+				err = pd14.ErrService{Cause: pd2.Errorf("%s", errValues[0])}
+			} else {
+				err = pd2.Errorf("service rejected the call, no cause provided")
+			}
+		} else {
+			err = pd2.Errorf("service rejected the call")
+		}
+		return nil, err
+	}
+
+	ch := make(chan DelegatedRouting_Provide_AsyncResult, 1)
+	go process_DelegatedRouting_Provide_AsyncResult(ctx, ch, resp.Body)
+	return ch, nil
+}
+
+func process_DelegatedRouting_Provide_AsyncResult(ctx pd7.Context, ch chan<- DelegatedRouting_Provide_AsyncResult, r pd11.ReadCloser) {
+	defer close(ch)
+	defer r.Close()
+	opt := pd9.DecodeOptions{
+		ParseLinks:         true,
+		ParseBytes:         true,
+		DontParseBeyondEnd: true,
+	}
+	for {
+		var out DelegatedRouting_Provide_AsyncResult
+
+		n, err := pd12.DecodeStreaming(r, opt.Decode)
+
+		if pd10.Is(err, pd11.EOF) || pd10.Is(err, pd11.ErrUnexpectedEOF) || pd10.Is(err, pd7.DeadlineExceeded) || pd10.Is(err, pd7.Canceled) {
+			return
+		}
+
+		if err != nil {
+			out = DelegatedRouting_Provide_AsyncResult{Err: pd14.ErrProto{Cause: err}} // IPLD decode error
+		} else {
+			var x [1]byte
+			if k, err := r.Read(x[:]); k != 1 || x[0] != '\n' {
+				out = DelegatedRouting_Provide_AsyncResult{Err: pd14.ErrProto{Cause: pd2.Errorf("missing new line after result: err (%v), read (%d), char (%q)", err, k, string(x[:]))}} // Edelweiss decode error
+			} else {
+				env := &AnonInductive5{}
+				if err = env.Parse(n); err != nil {
+					out = DelegatedRouting_Provide_AsyncResult{Err: pd14.ErrProto{Cause: err}} // schema decode error
+				} else if env.Error != nil {
+					out = DelegatedRouting_Provide_AsyncResult{Err: pd14.ErrService{Cause: pd10.New(string(env.Error.Code))}} // service-level error
+				} else if env.Provide != nil {
+					out = DelegatedRouting_Provide_AsyncResult{Resp: env.Provide}
+				} else {
+					continue
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case ch <- out:
+		}
+	}
+}
+
+var logger_server_DelegatedRouting = pd5.Logger("service/server/delegatedrouting")
+
+type DelegatedRouting_Server interface {
+	FindProviders(ctx pd7.Context, req *FindProvidersRequest) (<-chan *DelegatedRouting_FindProviders_AsyncResult, error)
+	FindProvidersBatch(ctx pd7.Context, req *FindProvidersBatchRequest) (<-chan *DelegatedRouting_FindProvidersBatch_AsyncResult, error)
+	FindPeer(ctx pd7.Context, req *FindPeerRequest) (<-chan *DelegatedRouting_FindPeer_AsyncResult, error)
+	GetIPNS(ctx pd7.Context, req *GetIPNSRequest) (<-chan *DelegatedRouting_GetIPNS_AsyncResult, error)
+	PutIPNS(ctx pd7.Context, req *PutIPNSRequest) (<-chan *DelegatedRouting_PutIPNS_AsyncResult, error)
+	Provide(ctx pd7.Context, req *ProvideRequest) (<-chan *DelegatedRouting_Provide_AsyncResult, error)
+}
+
+func DelegatedRouting_AsyncHandler(s DelegatedRouting_Server) pd4.HandlerFunc {
+	return func(writer pd4.ResponseWriter, request *pd4.Request) {
+		// parse request
+		env := &AnonInductive4{}
+		isReqCachable := false
+		switch request.Method {
+		case "POST":
+			isReqCachable = false
+			msg, err := pd11.ReadAll(request.Body)
+			if err != nil {
+				logger_server_DelegatedRouting.Errorf("reading request body (%v)", err)
+				writer.WriteHeader(400)
+				return
+			}
+			n, err := pd12.Decode(msg, pd9.Decode)
+			if err != nil {
+				logger_server_DelegatedRouting.Errorf("received request not decodeable (%v)", err)
+				writer.WriteHeader(400)
+				return
+			}
+			if err = env.Parse(n); err != nil {
+				logger_server_DelegatedRouting.Errorf("parsing call envelope (%v)", err)
+				writer.WriteHeader(400)
+				return
+			}
+		case "GET":
+			isReqCachable = true
+			msg := request.URL.Query().Get("q")
+			n, err := pd12.Decode([]byte(msg), pd8.Decode)
+			if err != nil {
+				logger_server_DelegatedRouting.Errorf("received url not decodeable (%v)", err)
+				writer.WriteHeader(400)
+				return
+			}
+
+			if err = env.Parse(n); err != nil {
+				logger_server_DelegatedRouting.Errorf("parsing call envelope (%v)", err)
+				writer.WriteHeader(400)
+				return
+			}
+		default:
+			logger_server_DelegatedRouting.Errorf("http method not supported")
+			writer.WriteHeader(400)
+			return
+		}
+		_ = isReqCachable
+
+		writer.Header()["Content-Type"] = []string{
+			"application/vnd.ipfs.rpc+dag-json; version=1",
+		}
+
+		// demultiplex request
+		var err error
+		switch {
+
+		case env.FindProviders != nil:
+
+			ch, err := s.FindProviders(request.Context(), env.FindProviders)
+			if err != nil {
+				logger_server_DelegatedRouting.Errorf("service rejected request (%v)", err)
+				writer.Header()["Error"] = []string{err.Error()}
+				writer.WriteHeader(500)
+				return
+			}
+
+			// if the request is cachable, collect all async results in a buffer, otherwise write them directly to http
+			var resultWriter pd11.Writer
+			if isReqCachable {
+				resultWriter = new(pd6.Buffer)
+			} else {
+				resultWriter = writer
+				writer.WriteHeader(200)
+				if f, ok := writer.(pd4.Flusher); ok {
+					f.Flush()
+				}
+
+			}
+			// if the request is cachable, compute an etag and send the collected results to http
+			if isReqCachable {
+				defer func() {
+					result := resultWriter.(*pd6.Buffer).Bytes()
+					etag, err := pd14.ETag(result)
+					if err != nil {
+						logger_server_DelegatedRouting.Errorf("etag generation (%v)", err)
+						writer.Header()["Error"] = []string{err.Error()}
+						writer.WriteHeader(500)
+						return
+					}
+					// if the request has an If-None-Match header, respond appropriately
+					ifNoneMatchValue := request.Header["If-None-Match"]
+					if len(ifNoneMatchValue) == 1 && ifNoneMatchValue[0] == etag {
+						writer.WriteHeader(304)
+					} else {
+						writer.Header()["ETag"] = []string{etag}
+						writer.Write(result)
+						if f, ok := writer.(pd4.Flusher); ok {
+							f.Flush()
+						}
+					}
+				}()
+			}
+			for {
+				select {
+				case <-request.Context().Done():
+					return
+				case resp, ok := <-ch:
+					if !ok {
+						return
+					}
+					var env *AnonInductive5
+					if resp.Err != nil {
+						env = &AnonInductive5{Error: &DelegatedRouting_Error{Code: pd1.String(resp.Err.Error())}}
+					} else {
+						env = &AnonInductive5{FindProviders: resp.Resp}
+					}
+					var buf pd6.Buffer
+					if err = pd12.EncodeStreaming(&buf, env, pd9.Encode); err != nil {
+						logger_server_DelegatedRouting.Errorf("cannot encode response (%v)", err)
+						continue
+					}
+					buf.WriteByte("\n"[0])
+					resultWriter.Write(buf.Bytes())
+					if f, ok := resultWriter.(pd4.Flusher); ok {
+						f.Flush()
+					}
+				}
+			}
+
+		case env.FindProvidersBatch != nil:
+
+			if isReqCachable {
+				logger_server_DelegatedRouting.Errorf("non-cachable method called with http GET")
+				writer.Header()["Error"] = []string{"non-cachable method called with GET"}
+				writer.WriteHeader(500)
+				return
+			}
+
+			ch, err := s.FindProvidersBatch(request.Context(), env.FindProvidersBatch)
+			if err != nil {
+				logger_server_DelegatedRouting.Errorf("service rejected request (%v)", err)
+				writer.Header()["Error"] = []string{err.Error()}
+				writer.WriteHeader(500)
+				return
+			}
+
+			// if the request is cachable, collect all async results in a buffer, otherwise write them directly to http
+			var resultWriter pd11.Writer
+			if isReqCachable {
+				resultWriter = new(pd6.Buffer)
+			} else {
+				resultWriter = writer
+				writer.WriteHeader(200)
+				if f, ok := writer.(pd4.Flusher); ok {
+					f.Flush()
+				}
+
+			}
+			// if the request is cachable, compute an etag and send the collected results to http
+			if isReqCachable {
+				defer func() {
+					result := resultWriter.(*pd6.Buffer).Bytes()
+					etag, err := pd14.ETag(result)
+					if err != nil {
+						logger_server_DelegatedRouting.Errorf("etag generation (%v)", err)
+						writer.Header()["Error"] = []string{err.Error()}
+						writer.WriteHeader(500)
+						return
+					}
+					// if the request has an If-None-Match header, respond appropriately
+					ifNoneMatchValue := request.Header["If-None-Match"]
+					if len(ifNoneMatchValue) == 1 && ifNoneMatchValue[0] == etag {
+						writer.WriteHeader(304)
+					} else {
+						writer.Header()["ETag"] = []string{etag}
+						writer.Write(result)
+						if f, ok := writer.(pd4.Flusher); ok {
+							f.Flush()
+						}
+					}
+				}()
+			}
+			for {
+				select {
+				case <-request.Context().Done():
+					return
+				case resp, ok := <-ch:
+					if !ok {
+						return
+					}
+					var env *AnonInductive5
+					if resp.Err != nil {
+						env = &AnonInductive5{Error: &DelegatedRouting_Error{Code: pd1.String(resp.Err.Error())}}
+					} else {
+						env = &AnonInductive5{FindProvidersBatch: resp.Resp}
+					}
+					var buf pd6.Buffer
+					if err = pd12.EncodeStreaming(&buf, env, pd9.Encode); err != nil {
+						logger_server_DelegatedRouting.Errorf("cannot encode response (%v)", err)
+						continue
+					}
+					buf.WriteByte("\n"[0])
+					resultWriter.Write(buf.Bytes())
+					if f, ok := resultWriter.(pd4.Flusher); ok {
+						f.Flush()
+					}
+				}
+			}
+
+		case env.FindPeer != nil:
+
+			ch, err := s.FindPeer(request.Context(), env.FindPeer)
+			if err != nil {
+				logger_server_DelegatedRouting.Errorf("service rejected request (%v)", err)
+				writer.Header()["Error"] = []string{err.Error()}
+				writer.WriteHeader(500)
+				return
+			}
+
+			// if the request is cachable, collect all async results in a buffer, otherwise write them directly to http
+			var resultWriter pd11.Writer
+			if isReqCachable {
+				resultWriter = new(pd6.Buffer)
+			} else {
+				resultWriter = writer
+				writer.WriteHeader(200)
+				if f, ok := writer.(pd4.Flusher); ok {
+					f.Flush()
+				}
+
+			}
+			// if the request is cachable, compute an etag and send the collected results to http
+			if isReqCachable {
+				defer func() {
+					result := resultWriter.(*pd6.Buffer).Bytes()
+					etag, err := pd14.ETag(result)
+					if err != nil {
+						logger_server_DelegatedRouting.Errorf("etag generation (%v)", err)
+						writer.Header()["Error"] = []string{err.Error()}
+						writer.WriteHeader(500)
+						return
+					}
+					// if the request has an If-None-Match header, respond appropriately
+					ifNoneMatchValue := request.Header["If-None-Match"]
+					if len(ifNoneMatchValue) == 1 && ifNoneMatchValue[0] == etag {
+						writer.WriteHeader(304)
+					} else {
+						writer.Header()["ETag"] = []string{etag}
+						writer.Write(result)
+						if f, ok := writer.(pd4.Flusher); ok {
+							f.Flush()
+						}
+					}
+				}()
+			}
+			for {
+				select {
+				case <-request.Context().Done():
+					return
+				case resp, ok := <-ch:
+					if !ok {
+						return
+					}
+					var env *AnonInductive5
+					if resp.Err != nil {
+						env = &AnonInductive5{Error: &DelegatedRouting_Error{Code: pd1.String(resp.Err.Error())}}
+					} else {
+						env = &AnonInductive5{FindPeer: resp.Resp}
+					}
+					var buf pd6.Buffer
+					if err = pd12.EncodeStreaming(&buf, env, pd9.Encode); err != nil {
+						logger_server_DelegatedRouting.Errorf("cannot encode response (%v)", err)
+						continue
+					}
+					buf.WriteByte("\n"[0])
+					resultWriter.Write(buf.Bytes())
+					if f, ok := resultWriter.(pd4.Flusher); ok {
+						f.Flush()
+					}
+				}
+			}
+
+		case env.GetIPNS != nil:
+
+			if isReqCachable {
+				logger_server_DelegatedRouting.Errorf("non-cachable method called with http GET")
+				writer.Header()["Error"] = []string{"non-cachable method called with GET"}
+				writer.WriteHeader(500)
+				return
+			}
+
+			ch, err := s.GetIPNS(request.Context(), env.GetIPNS)
+			if err != nil {
+				logger_server_DelegatedRouting.Errorf("service rejected request (%v)", err)
+				writer.Header()["Error"] = []string{err.Error()}
+				writer.WriteHeader(500)
+				return
+			}
+
+			// if the request is cachable, collect all async results in a buffer, otherwise write them directly to http
+			var resultWriter pd11.Writer
+			if isReqCachable {
+				resultWriter = new(pd6.Buffer)
+			} else {
+				resultWriter = writer
+				writer.WriteHeader(200)
+				if f, ok := writer.(pd4.Flusher); ok {
+					f.Flush()
+				}
+
+			}
+			// if the request is cachable, compute an etag and send the collected results to http
+			if isReqCachable {
+				defer func() {
+					result := resultWriter.(*pd6.Buffer).Bytes()
+					etag, err := pd14.ETag(result)
+					if err != nil {
+						logger_server_DelegatedRouting.Errorf("etag generation (%v)", err)
+						writer.Header()["Error"] = []string{err.Error()}
+						writer.WriteHeader(500)
+						return
+					}
+					// if the request has an If-None-Match header, respond appropriately
+					ifNoneMatchValue := request.Header["If-None-Match"]
+					if len(ifNoneMatchValue) == 1 && ifNoneMatchValue[0] == etag {
+						writer.WriteHeader(304)
+					} else {
+						writer.Header()["ETag"] = []string{etag}
+						writer.Write(result)
+						if f, ok := writer.(pd4.Flusher); ok {
+							f.Flush()
+						}
+					}
+				}()
+			}
+			for {
+				select {
+				case <-request.Context().Done():
+					return
+				case resp, ok := <-ch:
+					if !ok {
+						return
+					}
+					var env *AnonInductive5
+					if resp.Err != nil {
+						env = &AnonInductive5{Error: &DelegatedRouting_Error{Code: pd1.String(resp.Err.Error())}}
+					} else {
+						env = &AnonInductive5{GetIPNS: resp.Resp}
+					}
+					var buf pd6.Buffer
+					if err = pd12.EncodeStreaming(&buf, env, pd9.Encode); err != nil {
+						logger_server_DelegatedRouting.Errorf("cannot encode response (%v)", err)
+						continue
+					}
+					buf.WriteByte("\n"[0])
+					resultWriter.Write(buf.Bytes())
+					if f, ok := resultWriter.(pd4.Flusher); ok {
+						f.Flush()
+					}
+				}
+			}
+
+		case env.PutIPNS != nil:
+
+			if isReqCachable {
+				logger_server_DelegatedRouting.Errorf("non-cachable method called with http GET")
+				writer.Header()["Error"] = []string{"non-cachable method called with GET"}
+				writer.WriteHeader(500)
+				return
+			}
+
+			ch, err := s.PutIPNS(request.Context(), env.PutIPNS)
+			if err != nil {
+				logger_server_DelegatedRouting.Errorf("service rejected request (%v)", err)
+				writer.Header()["Error"] = []string{err.Error()}
+				writer.WriteHeader(500)
+				return
+			}
+
+			// if the request is cachable, collect all async results in a buffer, otherwise write them directly to http
+			var resultWriter pd11.Writer
+			if isReqCachable {
+				resultWriter = new(pd6.Buffer)
+			} else {
+				resultWriter = writer
+				writer.WriteHeader(200)
+				if f, ok := writer.(pd4.Flusher); ok {
+					f.Flush()
+				}
+
+			}
+			// if the request is cachable, compute an etag and send the collected results to http
+			if isReqCachable {
+				defer func() {
+					result := resultWriter.(*pd6.Buffer).Bytes()
+					etag, err := pd14.ETag(result)
+					if err != nil {
+						logger_server_DelegatedRouting.Errorf("etag generation (%v)", err)
+						writer.Header()["Error"] = []string{err.Error()}
+						writer.WriteHeader(500)
+						return
+					}
+					// if the request has an If-None-Match header, respond appropriately
+					ifNoneMatchValue := request.Header["If-None-Match"]
+					if len(ifNoneMatchValue) == 1 && ifNoneMatchValue[0] == etag {
+						writer.WriteHeader(304)
+					} else {
+						writer.Header()["ETag"] = []string{etag}
+						writer.Write(result)
+						if f, ok := writer.(pd4.Flusher); ok {
+							f.Flush()
+						}
+					}
+				}()
+			}
+			for {
+				select {
+				case <-request.Context().Done():
+					return
+				case resp, ok := <-ch:
+					if !ok {
+						return
+					}
+					var env *AnonInductive5
+					if resp.Err != nil {
+						env = &AnonInductive5{Error: &DelegatedRouting_Error{Code: pd1.String(resp.Err.Error())}}
+					} else {
+						env = &AnonInductive5{PutIPNS: resp.Resp}
+					}
+					var buf pd6.Buffer
+					if err = pd12.EncodeStreaming(&buf, env, pd9.Encode); err != nil {
+						logger_server_DelegatedRouting.Errorf("cannot encode response (%v)", err)
+						continue
+					}
+					buf.WriteByte("\n"[0])
+					resultWriter.Write(buf.Bytes())
+					if f, ok := resultWriter.(pd4.Flusher); ok {
+						f.Flush()
+					}
+				}
+			}
+
+		case env.Provide != nil:
+
+			if isReqCachable {
+				logger_server_DelegatedRouting.Errorf("non-cachable method called with http GET")
+				writer.Header()["Error"] = []string{"non-cachable method called with GET"}
+				writer.WriteHeader(500)
+				return
+			}
+
+			ch, err := s.Provide(request.Context(), env.Provide)
+			if err != nil {
+				logger_server_DelegatedRouting.Errorf("service rejected request (%v)", err)
+				writer.Header()["Error"] = []string{err.Error()}
+				writer.WriteHeader(500)
+				return
+			}
+
+			// if the request is cachable, collect all async results in a buffer, otherwise write them directly to http
+			var resultWriter pd11.Writer
+			if isReqCachable {
+				resultWriter = new(pd6.Buffer)
+			} else {
+				resultWriter = writer
+				writer.WriteHeader(200)
+				if f, ok := writer.(pd4.Flusher); ok {
+					f.Flush()
+				}
+
+			}
+			// if the request is cachable, compute an etag and send the collected results to http
+			if isReqCachable {
+				defer func() {
 					result := resultWriter.(*pd6.Buffer).Bytes()
 					etag, err := pd14.ETag(result)
 					if err != nil {
@@ -1933,351 +2689,1053 @@ func DelegatedRouting_AsyncHandler(s DelegatedRouting_Server) pd4.HandlerFunc {
 						writer.WriteHeader(500)
 						return
 					}
-					// if the request has an If-None-Match header, respond appropriately
-					ifNoneMatchValue := request.Header["If-None-Match"]
-					if len(ifNoneMatchValue) == 1 && ifNoneMatchValue[0] == etag {
-						writer.WriteHeader(304)
-					} else {
-						writer.Header()["ETag"] = []string{etag}
-						writer.Write(result)
-						if f, ok := writer.(pd4.Flusher); ok {
-							f.Flush()
-						}
+					// if the request has an If-None-Match header, respond appropriately
+					ifNoneMatchValue := request.Header["If-None-Match"]
+					if len(ifNoneMatchValue) == 1 && ifNoneMatchValue[0] == etag {
+						writer.WriteHeader(304)
+					} else {
+						writer.Header()["ETag"] = []string{etag}
+						writer.Write(result)
+						if f, ok := writer.(pd4.Flusher); ok {
+							f.Flush()
+						}
+					}
+				}()
+			}
+			for {
+				select {
+				case <-request.Context().Done():
+					return
+				case resp, ok := <-ch:
+					if !ok {
+						return
+					}
+					var env *AnonInductive5
+					if resp.Err != nil {
+						env = &AnonInductive5{Error: &DelegatedRouting_Error{Code: pd1.String(resp.Err.Error())}}
+					} else {
+						env = &AnonInductive5{Provide: resp.Resp}
+					}
+					var buf pd6.Buffer
+					if err = pd12.EncodeStreaming(&buf, env, pd9.Encode); err != nil {
+						logger_server_DelegatedRouting.Errorf("cannot encode response (%v)", err)
+						continue
+					}
+					buf.WriteByte("\n"[0])
+					resultWriter.Write(buf.Bytes())
+					if f, ok := resultWriter.(pd4.Flusher); ok {
+						f.Flush()
+					}
+				}
+			}
+
+		case env.Identify != nil:
+			var env *AnonInductive5
+			env = &AnonInductive5{
+				Identify: &DelegatedRouting_IdentifyResult{
+					Methods: []pd1.String{
+						"FindProviders",
+						"FindProvidersBatch",
+						"FindPeer",
+						"GetIPNS",
+						"PutIPNS",
+						"Provide",
+					},
+				},
+			}
+			var buf pd6.Buffer
+			if err = pd12.EncodeStreaming(&buf, env, pd9.Encode); err != nil {
+				logger_server_DelegatedRouting.Errorf("cannot encode identify response (%v)", err)
+				writer.WriteHeader(500)
+				return
+			}
+			buf.WriteByte("\n"[0])
+
+			// compute etag, since Identify is cachable
+			result := buf.Bytes()
+			etag, err := pd14.ETag(result)
+			if err != nil {
+				logger_server_DelegatedRouting.Errorf("etag generation (%v)", err)
+				writer.Header()["Error"] = []string{err.Error()}
+				writer.WriteHeader(500)
+				return
+			}
+			// if the request has an If-None-Match header, respond appropriately
+			ifNoneMatchValue := request.Header["If-None-Match"]
+			if len(ifNoneMatchValue) == 1 && ifNoneMatchValue[0] == etag {
+				writer.WriteHeader(304)
+			} else {
+				writer.Header()["ETag"] = []string{etag}
+				writer.Write(result)
+				if f, ok := writer.(pd4.Flusher); ok {
+					f.Flush()
+				}
+			}
+
+		default:
+			logger_server_DelegatedRouting.Errorf("missing or unknown request")
+			writer.WriteHeader(404)
+		}
+	}
+}
+
+// -- protocol type FindProvidersRequest --
+
+type FindProvidersRequest struct {
+	Key LinkToAny
+}
+
+func (x FindProvidersRequest) Node() pd3.Node {
+	return x
+}
+
+func (x *FindProvidersRequest) Parse(n pd3.Node) error {
+	if n.Kind() != pd3.Kind_Map {
+		return pd1.ErrNA
+	}
+	iter := n.MapIterator()
+	fieldMap := map[string]pd1.ParseFunc{
+		"Key": x.Key.Parse,
+	}
+	for !iter.Done() {
+		if kn, vn, err := iter.Next(); err != nil {
+			return err
+		} else {
+			if k, err := kn.AsString(); err != nil {
+				return pd2.Errorf("structure map key is not a string")
+			} else {
+				_ = vn
+				switch k {
+				case "Key":
+					if _, notParsed := fieldMap["Key"]; !notParsed {
+						return pd2.Errorf("field %s already parsed", "Key")
+					}
+					if err := x.Key.Parse(vn); err != nil {
+						return err
 					}
-				}()
+					delete(fieldMap, "Key")
+
+				}
 			}
-			for {
-				select {
-				case <-request.Context().Done():
-					return
-				case resp, ok := <-ch:
-					if !ok {
-						return
-					}
-					var env *AnonInductive5
-					if resp.Err != nil {
-						env = &AnonInductive5{Error: &DelegatedRouting_Error{Code: pd1.String(resp.Err.Error())}}
-					} else {
-						env = &AnonInductive5{FindProviders: resp.Resp}
-					}
-					var buf pd6.Buffer
-					if err = pd12.EncodeStreaming(&buf, env, pd9.Encode); err != nil {
-						logger_server_DelegatedRouting.Errorf("cannot encode response (%v)", err)
-						continue
+		}
+	}
+	for _, fieldParse := range fieldMap {
+		if err := fieldParse(pd3.Null); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type FindProvidersRequest_MapIterator struct {
+	i int64
+	s *FindProvidersRequest
+}
+
+func (x *FindProvidersRequest_MapIterator) Next() (key pd3.Node, value pd3.Node, err error) {
+	x.i++
+	switch x.i {
+	case 0:
+		return pd1.String("Key"), x.s.Key.Node(), nil
+
+	}
+	return nil, nil, pd1.ErrNA
+}
+
+func (x *FindProvidersRequest_MapIterator) Done() bool {
+	return x.i+1 >= 1
+}
+
+func (x FindProvidersRequest) Kind() pd3.Kind {
+	return pd3.Kind_Map
+}
+
+func (x FindProvidersRequest) LookupByString(key string) (pd3.Node, error) {
+	switch key {
+	case "Key":
+		return x.Key.Node(), nil
+
+	}
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersRequest) LookupByNode(key pd3.Node) (pd3.Node, error) {
+	switch key.Kind() {
+	case pd3.Kind_String:
+		if s, err := key.AsString(); err != nil {
+			return nil, err
+		} else {
+			return x.LookupByString(s)
+		}
+	case pd3.Kind_Int:
+		if i, err := key.AsInt(); err != nil {
+			return nil, err
+		} else {
+			return x.LookupByIndex(i)
+		}
+	}
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersRequest) LookupByIndex(idx int64) (pd3.Node, error) {
+	switch idx {
+	case 0:
+		return x.Key.Node(), nil
+
+	}
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersRequest) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
+	switch seg.String() {
+	case "0", "Key":
+		return x.Key.Node(), nil
+
+	}
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersRequest) MapIterator() pd3.MapIterator {
+	return &FindProvidersRequest_MapIterator{-1, &x}
+}
+
+func (x FindProvidersRequest) ListIterator() pd3.ListIterator {
+	return nil
+}
+
+func (x FindProvidersRequest) Length() int64 {
+	return 1
+}
+
+func (x FindProvidersRequest) IsAbsent() bool {
+	return false
+}
+
+func (x FindProvidersRequest) IsNull() bool {
+	return false
+}
+
+func (x FindProvidersRequest) AsBool() (bool, error) {
+	return false, pd1.ErrNA
+}
+
+func (x FindProvidersRequest) AsInt() (int64, error) {
+	return 0, pd1.ErrNA
+}
+
+func (x FindProvidersRequest) AsFloat() (float64, error) {
+	return 0, pd1.ErrNA
+}
+
+func (x FindProvidersRequest) AsString() (string, error) {
+	return "", pd1.ErrNA
+}
+
+func (x FindProvidersRequest) AsBytes() ([]byte, error) {
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersRequest) AsLink() (pd3.Link, error) {
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersRequest) Prototype() pd3.NodePrototype {
+	return nil
+}
+
+// -- protocol type ProvidersList --
+
+type ProvidersList []Provider
+
+func (v ProvidersList) Node() pd3.Node {
+	return v
+}
+
+func (v *ProvidersList) Parse(n pd3.Node) error {
+	if n.Kind() == pd3.Kind_Null {
+		*v = nil
+		return nil
+	}
+	if n.Kind() != pd3.Kind_List {
+		return pd1.ErrNA
+	} else {
+		*v = make(ProvidersList, n.Length())
+		iter := n.ListIterator()
+		for !iter.Done() {
+			if i, n, err := iter.Next(); err != nil {
+				return pd1.ErrNA
+			} else if err = (*v)[i].Parse(n); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (ProvidersList) Kind() pd3.Kind {
+	return pd3.Kind_List
+}
+
+func (ProvidersList) LookupByString(string) (pd3.Node, error) {
+	return nil, pd1.ErrNA
+}
+
+func (ProvidersList) LookupByNode(key pd3.Node) (pd3.Node, error) {
+	return nil, pd1.ErrNA
+}
+
+func (v ProvidersList) LookupByIndex(i int64) (pd3.Node, error) {
+	if i < 0 || i >= v.Length() {
+		return nil, pd1.ErrBounds
+	} else {
+		return v[i].Node(), nil
+	}
+}
+
+func (v ProvidersList) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
+	if i, err := seg.Index(); err != nil {
+		return nil, pd1.ErrNA
+	} else {
+		return v.LookupByIndex(i)
+	}
+}
+
+func (ProvidersList) MapIterator() pd3.MapIterator {
+	return nil
+}
+
+func (v ProvidersList) ListIterator() pd3.ListIterator {
+	return &ProvidersList_ListIterator{v, 0}
+}
+
+func (v ProvidersList) Length() int64 {
+	return int64(len(v))
+}
+
+func (ProvidersList) IsAbsent() bool {
+	return false
+}
+
+func (ProvidersList) IsNull() bool {
+	return false
+}
+
+func (v ProvidersList) AsBool() (bool, error) {
+	return false, pd1.ErrNA
+}
+
+func (ProvidersList) AsInt() (int64, error) {
+	return 0, pd1.ErrNA
+}
+
+func (ProvidersList) AsFloat() (float64, error) {
+	return 0, pd1.ErrNA
+}
+
+func (ProvidersList) AsString() (string, error) {
+	return "", pd1.ErrNA
+}
+
+func (ProvidersList) AsBytes() ([]byte, error) {
+	return nil, pd1.ErrNA
+}
+
+func (ProvidersList) AsLink() (pd3.Link, error) {
+	return nil, pd1.ErrNA
+}
+
+func (ProvidersList) Prototype() pd3.NodePrototype {
+	return nil // not needed
+}
+
+type ProvidersList_ListIterator struct {
+	list ProvidersList
+	at   int64
+}
+
+func (iter *ProvidersList_ListIterator) Next() (int64, pd3.Node, error) {
+	if iter.Done() {
+		return -1, nil, pd1.ErrBounds
+	}
+	v := iter.list[iter.at]
+	i := int64(iter.at)
+	iter.at++
+	return i, v.Node(), nil
+}
+
+func (iter *ProvidersList_ListIterator) Done() bool {
+	return iter.at >= iter.list.Length()
+}
+
+// -- protocol type FindProvidersResponse --
+
+type FindProvidersResponse struct {
+	Providers ProvidersList
+}
+
+func (x FindProvidersResponse) Node() pd3.Node {
+	return x
+}
+
+func (x *FindProvidersResponse) Parse(n pd3.Node) error {
+	if n.Kind() != pd3.Kind_Map {
+		return pd1.ErrNA
+	}
+	iter := n.MapIterator()
+	fieldMap := map[string]pd1.ParseFunc{
+		"Providers": x.Providers.Parse,
+	}
+	for !iter.Done() {
+		if kn, vn, err := iter.Next(); err != nil {
+			return err
+		} else {
+			if k, err := kn.AsString(); err != nil {
+				return pd2.Errorf("structure map key is not a string")
+			} else {
+				_ = vn
+				switch k {
+				case "Providers":
+					if _, notParsed := fieldMap["Providers"]; !notParsed {
+						return pd2.Errorf("field %s already parsed", "Providers")
 					}
-					buf.WriteByte("\n"[0])
-					resultWriter.Write(buf.Bytes())
-					if f, ok := resultWriter.(pd4.Flusher); ok {
-						f.Flush()
+					if err := x.Providers.Parse(vn); err != nil {
+						return err
 					}
+					delete(fieldMap, "Providers")
+
 				}
 			}
+		}
+	}
+	for _, fieldParse := range fieldMap {
+		if err := fieldParse(pd3.Null); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type FindProvidersResponse_MapIterator struct {
+	i int64
+	s *FindProvidersResponse
+}
+
+func (x *FindProvidersResponse_MapIterator) Next() (key pd3.Node, value pd3.Node, err error) {
+	x.i++
+	switch x.i {
+	case 0:
+		return pd1.String("Providers"), x.s.Providers.Node(), nil
+
+	}
+	return nil, nil, pd1.ErrNA
+}
+
+func (x *FindProvidersResponse_MapIterator) Done() bool {
+	return x.i+1 >= 1
+}
+
+func (x FindProvidersResponse) Kind() pd3.Kind {
+	return pd3.Kind_Map
+}
+
+func (x FindProvidersResponse) LookupByString(key string) (pd3.Node, error) {
+	switch key {
+	case "Providers":
+		return x.Providers.Node(), nil
+
+	}
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersResponse) LookupByNode(key pd3.Node) (pd3.Node, error) {
+	switch key.Kind() {
+	case pd3.Kind_String:
+		if s, err := key.AsString(); err != nil {
+			return nil, err
+		} else {
+			return x.LookupByString(s)
+		}
+	case pd3.Kind_Int:
+		if i, err := key.AsInt(); err != nil {
+			return nil, err
+		} else {
+			return x.LookupByIndex(i)
+		}
+	}
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersResponse) LookupByIndex(idx int64) (pd3.Node, error) {
+	switch idx {
+	case 0:
+		return x.Providers.Node(), nil
+
+	}
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersResponse) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
+	switch seg.String() {
+	case "0", "Providers":
+		return x.Providers.Node(), nil
+
+	}
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersResponse) MapIterator() pd3.MapIterator {
+	return &FindProvidersResponse_MapIterator{-1, &x}
+}
+
+func (x FindProvidersResponse) ListIterator() pd3.ListIterator {
+	return nil
+}
+
+func (x FindProvidersResponse) Length() int64 {
+	return 1
+}
+
+func (x FindProvidersResponse) IsAbsent() bool {
+	return false
+}
+
+func (x FindProvidersResponse) IsNull() bool {
+	return false
+}
+
+func (x FindProvidersResponse) AsBool() (bool, error) {
+	return false, pd1.ErrNA
+}
+
+func (x FindProvidersResponse) AsInt() (int64, error) {
+	return 0, pd1.ErrNA
+}
+
+func (x FindProvidersResponse) AsFloat() (float64, error) {
+	return 0, pd1.ErrNA
+}
+
+func (x FindProvidersResponse) AsString() (string, error) {
+	return "", pd1.ErrNA
+}
+
+func (x FindProvidersResponse) AsBytes() ([]byte, error) {
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersResponse) AsLink() (pd3.Link, error) {
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersResponse) Prototype() pd3.NodePrototype {
+	return nil
+}
+
+// -- protocol type LinksList --
+
+type LinksList []LinkToAny
+
+func (v LinksList) Node() pd3.Node {
+	return v
+}
+
+func (v *LinksList) Parse(n pd3.Node) error {
+	if n.Kind() == pd3.Kind_Null {
+		*v = nil
+		return nil
+	}
+	if n.Kind() != pd3.Kind_List {
+		return pd1.ErrNA
+	} else {
+		*v = make(LinksList, n.Length())
+		iter := n.ListIterator()
+		for !iter.Done() {
+			if i, n, err := iter.Next(); err != nil {
+				return pd1.ErrNA
+			} else if err = (*v)[i].Parse(n); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (LinksList) Kind() pd3.Kind {
+	return pd3.Kind_List
+}
+
+func (LinksList) LookupByString(string) (pd3.Node, error) {
+	return nil, pd1.ErrNA
+}
+
+func (LinksList) LookupByNode(key pd3.Node) (pd3.Node, error) {
+	return nil, pd1.ErrNA
+}
+
+func (v LinksList) LookupByIndex(i int64) (pd3.Node, error) {
+	if i < 0 || i >= v.Length() {
+		return nil, pd1.ErrBounds
+	} else {
+		return v[i].Node(), nil
+	}
+}
+
+func (v LinksList) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
+	if i, err := seg.Index(); err != nil {
+		return nil, pd1.ErrNA
+	} else {
+		return v.LookupByIndex(i)
+	}
+}
+
+func (LinksList) MapIterator() pd3.MapIterator {
+	return nil
+}
+
+func (v LinksList) ListIterator() pd3.ListIterator {
+	return &LinksList_ListIterator{v, 0}
+}
+
+func (v LinksList) Length() int64 {
+	return int64(len(v))
+}
 
-		case env.GetIPNS != nil:
+func (LinksList) IsAbsent() bool {
+	return false
+}
 
-			if isReqCachable {
-				logger_server_DelegatedRouting.Errorf("non-cachable method called with http GET")
-				writer.Header()["Error"] = []string{"non-cachable method called with GET"}
-				writer.WriteHeader(500)
-				return
-			}
+func (LinksList) IsNull() bool {
+	return false
+}
 
-			ch, err := s.GetIPNS(request.Context(), env.GetIPNS)
-			if err != nil {
-				logger_server_DelegatedRouting.Errorf("service rejected request (%v)", err)
-				writer.Header()["Error"] = []string{err.Error()}
-				writer.WriteHeader(500)
-				return
-			}
+func (v LinksList) AsBool() (bool, error) {
+	return false, pd1.ErrNA
+}
 
-			// if the request is cachable, collect all async results in a buffer, otherwise write them directly to http
-			var resultWriter pd11.Writer
-			if isReqCachable {
-				resultWriter = new(pd6.Buffer)
-			} else {
-				resultWriter = writer
-				writer.WriteHeader(200)
-				if f, ok := writer.(pd4.Flusher); ok {
-					f.Flush()
-				}
+func (LinksList) AsInt() (int64, error) {
+	return 0, pd1.ErrNA
+}
 
-			}
-			// if the request is cachable, compute an etag and send the collected results to http
-			if isReqCachable {
-				defer func() {
-					result := resultWriter.(*pd6.Buffer).Bytes()
-					etag, err := pd14.ETag(result)
-					if err != nil {
-						logger_server_DelegatedRouting.Errorf("etag generation (%v)", err)
-						writer.Header()["Error"] = []string{err.Error()}
-						writer.WriteHeader(500)
-						return
-					}
-					// if the request has an If-None-Match header, respond appropriately
-					ifNoneMatchValue := request.Header["If-None-Match"]
-					if len(ifNoneMatchValue) == 1 && ifNoneMatchValue[0] == etag {
-						writer.WriteHeader(304)
-					} else {
-						writer.Header()["ETag"] = []string{etag}
-						writer.Write(result)
-						if f, ok := writer.(pd4.Flusher); ok {
-							f.Flush()
-						}
-					}
-				}()
-			}
-			for {
-				select {
-				case <-request.Context().Done():
-					return
-				case resp, ok := <-ch:
-					if !ok {
-						return
-					}
-					var env *AnonInductive5
-					if resp.Err != nil {
-						env = &AnonInductive5{Error: &DelegatedRouting_Error{Code: pd1.String(resp.Err.Error())}}
-					} else {
-						env = &AnonInductive5{GetIPNS: resp.Resp}
-					}
-					var buf pd6.Buffer
-					if err = pd12.EncodeStreaming(&buf, env, pd9.Encode); err != nil {
-						logger_server_DelegatedRouting.Errorf("cannot encode response (%v)", err)
-						continue
+func (LinksList) AsFloat() (float64, error) {
+	return 0, pd1.ErrNA
+}
+
+func (LinksList) AsString() (string, error) {
+	return "", pd1.ErrNA
+}
+
+func (LinksList) AsBytes() ([]byte, error) {
+	return nil, pd1.ErrNA
+}
+
+func (LinksList) AsLink() (pd3.Link, error) {
+	return nil, pd1.ErrNA
+}
+
+func (LinksList) Prototype() pd3.NodePrototype {
+	return nil // not needed
+}
+
+type LinksList_ListIterator struct {
+	list LinksList
+	at   int64
+}
+
+func (iter *LinksList_ListIterator) Next() (int64, pd3.Node, error) {
+	if iter.Done() {
+		return -1, nil, pd1.ErrBounds
+	}
+	v := iter.list[iter.at]
+	i := int64(iter.at)
+	iter.at++
+	return i, v.Node(), nil
+}
+
+func (iter *LinksList_ListIterator) Done() bool {
+	return iter.at >= iter.list.Length()
+}
+
+// -- protocol type FindProvidersBatchRequest --
+
+type FindProvidersBatchRequest struct {
+	Keys LinksList
+}
+
+func (x FindProvidersBatchRequest) Node() pd3.Node {
+	return x
+}
+
+func (x *FindProvidersBatchRequest) Parse(n pd3.Node) error {
+	if n.Kind() != pd3.Kind_Map {
+		return pd1.ErrNA
+	}
+	iter := n.MapIterator()
+	fieldMap := map[string]pd1.ParseFunc{
+		"Keys": x.Keys.Parse,
+	}
+	for !iter.Done() {
+		if kn, vn, err := iter.Next(); err != nil {
+			return err
+		} else {
+			if k, err := kn.AsString(); err != nil {
+				return pd2.Errorf("structure map key is not a string")
+			} else {
+				_ = vn
+				switch k {
+				case "Keys":
+					if _, notParsed := fieldMap["Keys"]; !notParsed {
+						return pd2.Errorf("field %s already parsed", "Keys")
 					}
-					buf.WriteByte("\n"[0])
-					resultWriter.Write(buf.Bytes())
-					if f, ok := resultWriter.(pd4.Flusher); ok {
-						f.Flush()
+					if err := x.Keys.Parse(vn); err != nil {
+						return err
 					}
+					delete(fieldMap, "Keys")
+
 				}
 			}
+		}
+	}
+	for _, fieldParse := range fieldMap {
+		if err := fieldParse(pd3.Null); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		case env.PutIPNS != nil:
+type FindProvidersBatchRequest_MapIterator struct {
+	i int64
+	s *FindProvidersBatchRequest
+}
 
-			if isReqCachable {
-				logger_server_DelegatedRouting.Errorf("non-cachable method called with http GET")
-				writer.Header()["Error"] = []string{"non-cachable method called with GET"}
-				writer.WriteHeader(500)
-				return
-			}
+func (x *FindProvidersBatchRequest_MapIterator) Next() (key pd3.Node, value pd3.Node, err error) {
+	x.i++
+	switch x.i {
+	case 0:
+		return pd1.String("Keys"), x.s.Keys.Node(), nil
 
-			ch, err := s.PutIPNS(request.Context(), env.PutIPNS)
-			if err != nil {
-				logger_server_DelegatedRouting.Errorf("service rejected request (%v)", err)
-				writer.Header()["Error"] = []string{err.Error()}
-				writer.WriteHeader(500)
-				return
-			}
+	}
+	return nil, nil, pd1.ErrNA
+}
 
-			// if the request is cachable, collect all async results in a buffer, otherwise write them directly to http
-			var resultWriter pd11.Writer
-			if isReqCachable {
-				resultWriter = new(pd6.Buffer)
-			} else {
-				resultWriter = writer
-				writer.WriteHeader(200)
-				if f, ok := writer.(pd4.Flusher); ok {
-					f.Flush()
-				}
+func (x *FindProvidersBatchRequest_MapIterator) Done() bool {
+	return x.i+1 >= 1
+}
+
+func (x FindProvidersBatchRequest) Kind() pd3.Kind {
+	return pd3.Kind_Map
+}
+
+func (x FindProvidersBatchRequest) LookupByString(key string) (pd3.Node, error) {
+	switch key {
+	case "Keys":
+		return x.Keys.Node(), nil
+
+	}
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersBatchRequest) LookupByNode(key pd3.Node) (pd3.Node, error) {
+	switch key.Kind() {
+	case pd3.Kind_String:
+		if s, err := key.AsString(); err != nil {
+			return nil, err
+		} else {
+			return x.LookupByString(s)
+		}
+	case pd3.Kind_Int:
+		if i, err := key.AsInt(); err != nil {
+			return nil, err
+		} else {
+			return x.LookupByIndex(i)
+		}
+	}
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersBatchRequest) LookupByIndex(idx int64) (pd3.Node, error) {
+	switch idx {
+	case 0:
+		return x.Keys.Node(), nil
+
+	}
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersBatchRequest) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
+	switch seg.String() {
+	case "0", "Keys":
+		return x.Keys.Node(), nil
+
+	}
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersBatchRequest) MapIterator() pd3.MapIterator {
+	return &FindProvidersBatchRequest_MapIterator{-1, &x}
+}
+
+func (x FindProvidersBatchRequest) ListIterator() pd3.ListIterator {
+	return nil
+}
+
+func (x FindProvidersBatchRequest) Length() int64 {
+	return 1
+}
+
+func (x FindProvidersBatchRequest) IsAbsent() bool {
+	return false
+}
+
+func (x FindProvidersBatchRequest) IsNull() bool {
+	return false
+}
+
+func (x FindProvidersBatchRequest) AsBool() (bool, error) {
+	return false, pd1.ErrNA
+}
+
+func (x FindProvidersBatchRequest) AsInt() (int64, error) {
+	return 0, pd1.ErrNA
+}
+
+func (x FindProvidersBatchRequest) AsFloat() (float64, error) {
+	return 0, pd1.ErrNA
+}
+
+func (x FindProvidersBatchRequest) AsString() (string, error) {
+	return "", pd1.ErrNA
+}
+
+func (x FindProvidersBatchRequest) AsBytes() ([]byte, error) {
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersBatchRequest) AsLink() (pd3.Link, error) {
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersBatchRequest) Prototype() pd3.NodePrototype {
+	return nil
+}
+
+// -- protocol type FindProvidersBatchResponse --
+
+type FindProvidersBatchResponse struct {
+	Key       LinkToAny
+	Providers ProvidersList
+	Error     ErrorsList
+}
+
+func (x FindProvidersBatchResponse) Node() pd3.Node {
+	return x
+}
 
-			}
-			// if the request is cachable, compute an etag and send the collected results to http
-			if isReqCachable {
-				defer func() {
-					result := resultWriter.(*pd6.Buffer).Bytes()
-					etag, err := pd14.ETag(result)
-					if err != nil {
-						logger_server_DelegatedRouting.Errorf("etag generation (%v)", err)
-						writer.Header()["Error"] = []string{err.Error()}
-						writer.WriteHeader(500)
-						return
+func (x *FindProvidersBatchResponse) Parse(n pd3.Node) error {
+	if n.Kind() != pd3.Kind_Map {
+		return pd1.ErrNA
+	}
+	iter := n.MapIterator()
+	fieldMap := map[string]pd1.ParseFunc{
+		"Key":       x.Key.Parse,
+		"Providers": x.Providers.Parse,
+		"Error":     x.Error.Parse,
+	}
+	for !iter.Done() {
+		if kn, vn, err := iter.Next(); err != nil {
+			return err
+		} else {
+			if k, err := kn.AsString(); err != nil {
+				return pd2.Errorf("structure map key is not a string")
+			} else {
+				_ = vn
+				switch k {
+				case "Key":
+					if _, notParsed := fieldMap["Key"]; !notParsed {
+						return pd2.Errorf("field %s already parsed", "Key")
 					}
-					// if the request has an If-None-Match header, respond appropriately
-					ifNoneMatchValue := request.Header["If-None-Match"]
-					if len(ifNoneMatchValue) == 1 && ifNoneMatchValue[0] == etag {
-						writer.WriteHeader(304)
-					} else {
-						writer.Header()["ETag"] = []string{etag}
-						writer.Write(result)
-						if f, ok := writer.(pd4.Flusher); ok {
-							f.Flush()
-						}
+					if err := x.Key.Parse(vn); err != nil {
+						return err
 					}
-				}()
-			}
-			for {
-				select {
-				case <-request.Context().Done():
-					return
-				case resp, ok := <-ch:
-					if !ok {
-						return
+					delete(fieldMap, "Key")
+				case "Providers":
+					if _, notParsed := fieldMap["Providers"]; !notParsed {
+						return pd2.Errorf("field %s already parsed", "Providers")
 					}
-					var env *AnonInductive5
-					if resp.Err != nil {
-						env = &AnonInductive5{Error: &DelegatedRouting_Error{Code: pd1.String(resp.Err.Error())}}
-					} else {
-						env = &AnonInductive5{PutIPNS: resp.Resp}
+					if err := x.Providers.Parse(vn); err != nil {
+						return err
 					}
-					var buf pd6.Buffer
-					if err = pd12.EncodeStreaming(&buf, env, pd9.Encode); err != nil {
-						logger_server_DelegatedRouting.Errorf("cannot encode response (%v)", err)
-						continue
+					delete(fieldMap, "Providers")
+				case "Error":
+					if _, notParsed := fieldMap["Error"]; !notParsed {
+						return pd2.Errorf("field %s already parsed", "Error")
 					}
-					buf.WriteByte("\n"[0])
-					resultWriter.Write(buf.Bytes())
-					if f, ok := resultWriter.(pd4.Flusher); ok {
-						f.Flush()
+					if err := x.Error.Parse(vn); err != nil {
+						return err
 					}
+					delete(fieldMap, "Error")
+
 				}
 			}
+		}
+	}
+	for _, fieldParse := range fieldMap {
+		if err := fieldParse(pd3.Null); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		case env.Provide != nil:
+type FindProvidersBatchResponse_MapIterator struct {
+	i int64
+	s *FindProvidersBatchResponse
+}
 
-			if isReqCachable {
-				logger_server_DelegatedRouting.Errorf("non-cachable method called with http GET")
-				writer.Header()["Error"] = []string{"non-cachable method called with GET"}
-				writer.WriteHeader(500)
-				return
-			}
+func (x *FindProvidersBatchResponse_MapIterator) Next() (key pd3.Node, value pd3.Node, err error) {
+	x.i++
+	switch x.i {
+	case 0:
+		return pd1.String("Key"), x.s.Key.Node(), nil
+	case 1:
+		return pd1.String("Providers"), x.s.Providers.Node(), nil
+	case 2:
+		return pd1.String("Error"), x.s.Error.Node(), nil
 
-			ch, err := s.Provide(request.Context(), env.Provide)
-			if err != nil {
-				logger_server_DelegatedRouting.Errorf("service rejected request (%v)", err)
-				writer.Header()["Error"] = []string{err.Error()}
-				writer.WriteHeader(500)
-				return
-			}
+	}
+	return nil, nil, pd1.ErrNA
+}
 
-			// if the request is cachable, collect all async results in a buffer, otherwise write them directly to http
-			var resultWriter pd11.Writer
-			if isReqCachable {
-				resultWriter = new(pd6.Buffer)
-			} else {
-				resultWriter = writer
-				writer.WriteHeader(200)
-				if f, ok := writer.(pd4.Flusher); ok {
-					f.Flush()
-				}
+func (x *FindProvidersBatchResponse_MapIterator) Done() bool {
+	return x.i+1 >= 3
+}
 
-			}
-			// if the request is cachable, compute an etag and send the collected results to http
-			if isReqCachable {
-				defer func() {
-					result := resultWriter.(*pd6.Buffer).Bytes()
-					etag, err := pd14.ETag(result)
-					if err != nil {
-						logger_server_DelegatedRouting.Errorf("etag generation (%v)", err)
-						writer.Header()["Error"] = []string{err.Error()}
-						writer.WriteHeader(500)
-						return
-					}
-					// if the request has an If-None-Match header, respond appropriately
-					ifNoneMatchValue := request.Header["If-None-Match"]
-					if len(ifNoneMatchValue) == 1 && ifNoneMatchValue[0] == etag {
-						writer.WriteHeader(304)
-					} else {
-						writer.Header()["ETag"] = []string{etag}
-						writer.Write(result)
-						if f, ok := writer.(pd4.Flusher); ok {
-							f.Flush()
-						}
-					}
-				}()
-			}
-			for {
-				select {
-				case <-request.Context().Done():
-					return
-				case resp, ok := <-ch:
-					if !ok {
-						return
-					}
-					var env *AnonInductive5
-					if resp.Err != nil {
-						env = &AnonInductive5{Error: &DelegatedRouting_Error{Code: pd1.String(resp.Err.Error())}}
-					} else {
-						env = &AnonInductive5{Provide: resp.Resp}
-					}
-					var buf pd6.Buffer
-					if err = pd12.EncodeStreaming(&buf, env, pd9.Encode); err != nil {
-						logger_server_DelegatedRouting.Errorf("cannot encode response (%v)", err)
-						continue
-					}
-					buf.WriteByte("\n"[0])
-					resultWriter.Write(buf.Bytes())
-					if f, ok := resultWriter.(pd4.Flusher); ok {
-						f.Flush()
-					}
-				}
-			}
+func (x FindProvidersBatchResponse) Kind() pd3.Kind {
+	return pd3.Kind_Map
+}
 
-		case env.Identify != nil:
-			var env *AnonInductive5
-			env = &AnonInductive5{
-				Identify: &DelegatedRouting_IdentifyResult{
-					Methods: []pd1.String{
-						"FindProviders",
-						"GetIPNS",
-						"PutIPNS",
-						"Provide",
-					},
-				},
-			}
-			var buf pd6.Buffer
-			if err = pd12.EncodeStreaming(&buf, env, pd9.Encode); err != nil {
-				logger_server_DelegatedRouting.Errorf("cannot encode identify response (%v)", err)
-				writer.WriteHeader(500)
-				return
-			}
-			buf.WriteByte("\n"[0])
+func (x FindProvidersBatchResponse) LookupByString(key string) (pd3.Node, error) {
+	switch key {
+	case "Key":
+		return x.Key.Node(), nil
+	case "Providers":
+		return x.Providers.Node(), nil
+	case "Error":
+		return x.Error.Node(), nil
 
-			// compute etag, since Identify is cachable
-			result := buf.Bytes()
-			etag, err := pd14.ETag(result)
-			if err != nil {
-				logger_server_DelegatedRouting.Errorf("etag generation (%v)", err)
-				writer.Header()["Error"] = []string{err.Error()}
-				writer.WriteHeader(500)
-				return
-			}
-			// if the request has an If-None-Match header, respond appropriately
-			ifNoneMatchValue := request.Header["If-None-Match"]
-			if len(ifNoneMatchValue) == 1 && ifNoneMatchValue[0] == etag {
-				writer.WriteHeader(304)
-			} else {
-				writer.Header()["ETag"] = []string{etag}
-				writer.Write(result)
-				if f, ok := writer.(pd4.Flusher); ok {
-					f.Flush()
-				}
-			}
+	}
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersBatchResponse) LookupByNode(key pd3.Node) (pd3.Node, error) {
+	switch key.Kind() {
+	case pd3.Kind_String:
+		if s, err := key.AsString(); err != nil {
+			return nil, err
+		} else {
+			return x.LookupByString(s)
+		}
+	case pd3.Kind_Int:
+		if i, err := key.AsInt(); err != nil {
+			return nil, err
+		} else {
+			return x.LookupByIndex(i)
+		}
+	}
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersBatchResponse) LookupByIndex(idx int64) (pd3.Node, error) {
+	switch idx {
+	case 0:
+		return x.Key.Node(), nil
+	case 1:
+		return x.Providers.Node(), nil
+	case 2:
+		return x.Error.Node(), nil
+
+	}
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersBatchResponse) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
+	switch seg.String() {
+	case "0", "Key":
+		return x.Key.Node(), nil
+	case "1", "Providers":
+		return x.Providers.Node(), nil
+	case "2", "Error":
+		return x.Error.Node(), nil
 
-		default:
-			logger_server_DelegatedRouting.Errorf("missing or unknown request")
-			writer.WriteHeader(404)
-		}
 	}
+	return nil, pd1.ErrNA
 }
 
-// -- protocol type FindProvidersRequest --
+func (x FindProvidersBatchResponse) MapIterator() pd3.MapIterator {
+	return &FindProvidersBatchResponse_MapIterator{-1, &x}
+}
 
-type FindProvidersRequest struct {
-	Key LinkToAny
+func (x FindProvidersBatchResponse) ListIterator() pd3.ListIterator {
+	return nil
 }
 
-func (x FindProvidersRequest) Node() pd3.Node {
+func (x FindProvidersBatchResponse) Length() int64 {
+	return 3
+}
+
+func (x FindProvidersBatchResponse) IsAbsent() bool {
+	return false
+}
+
+func (x FindProvidersBatchResponse) IsNull() bool {
+	return false
+}
+
+func (x FindProvidersBatchResponse) AsBool() (bool, error) {
+	return false, pd1.ErrNA
+}
+
+func (x FindProvidersBatchResponse) AsInt() (int64, error) {
+	return 0, pd1.ErrNA
+}
+
+func (x FindProvidersBatchResponse) AsFloat() (float64, error) {
+	return 0, pd1.ErrNA
+}
+
+func (x FindProvidersBatchResponse) AsString() (string, error) {
+	return "", pd1.ErrNA
+}
+
+func (x FindProvidersBatchResponse) AsBytes() ([]byte, error) {
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersBatchResponse) AsLink() (pd3.Link, error) {
+	return nil, pd1.ErrNA
+}
+
+func (x FindProvidersBatchResponse) Prototype() pd3.NodePrototype {
+	return nil
+}
+
+// -- protocol type FindPeerRequest --
+
+type FindPeerRequest struct {
+	ID pd1.Bytes
+}
+
+func (x FindPeerRequest) Node() pd3.Node {
 	return x
 }
 
-func (x *FindProvidersRequest) Parse(n pd3.Node) error {
+func (x *FindPeerRequest) Parse(n pd3.Node) error {
 	if n.Kind() != pd3.Kind_Map {
 		return pd1.ErrNA
 	}
 	iter := n.MapIterator()
 	fieldMap := map[string]pd1.ParseFunc{
-		"Key": x.Key.Parse,
+		"ID": x.ID.Parse,
 	}
 	for !iter.Done() {
 		if kn, vn, err := iter.Next(); err != nil {
@@ -2288,14 +3746,14 @@ func (x *FindProvidersRequest) Parse(n pd3.Node) error {
 			} else {
 				_ = vn
 				switch k {
-				case "Key":
-					if _, notParsed := fieldMap["Key"]; !notParsed {
-						return pd2.Errorf("field %s already parsed", "Key")
+				case "ID":
+					if _, notParsed := fieldMap["ID"]; !notParsed {
+						return pd2.Errorf("field %s already parsed", "ID")
 					}
-					if err := x.Key.Parse(vn); err != nil {
+					if err := x.ID.Parse(vn); err != nil {
 						return err
 					}
-					delete(fieldMap, "Key")
+					delete(fieldMap, "ID")
 
 				}
 			}
@@ -2309,39 +3767,39 @@ func (x *FindProvidersRequest) Parse(n pd3.Node) error {
 	return nil
 }
 
-type FindProvidersRequest_MapIterator struct {
+type FindPeerRequest_MapIterator struct {
 	i int64
-	s *FindProvidersRequest
+	s *FindPeerRequest
 }
 
-func (x *FindProvidersRequest_MapIterator) Next() (key pd3.Node, value pd3.Node, err error) {
+func (x *FindPeerRequest_MapIterator) Next() (key pd3.Node, value pd3.Node, err error) {
 	x.i++
 	switch x.i {
 	case 0:
-		return pd1.String("Key"), x.s.Key.Node(), nil
+		return pd1.String("ID"), x.s.ID.Node(), nil
 
 	}
 	return nil, nil, pd1.ErrNA
 }
 
-func (x *FindProvidersRequest_MapIterator) Done() bool {
+func (x *FindPeerRequest_MapIterator) Done() bool {
 	return x.i+1 >= 1
 }
 
-func (x FindProvidersRequest) Kind() pd3.Kind {
+func (x FindPeerRequest) Kind() pd3.Kind {
 	return pd3.Kind_Map
 }
 
-func (x FindProvidersRequest) LookupByString(key string) (pd3.Node, error) {
+func (x FindPeerRequest) LookupByString(key string) (pd3.Node, error) {
 	switch key {
-	case "Key":
-		return x.Key.Node(), nil
+	case "ID":
+		return x.ID.Node(), nil
 
 	}
 	return nil, pd1.ErrNA
 }
 
-func (x FindProvidersRequest) LookupByNode(key pd3.Node) (pd3.Node, error) {
+func (x FindPeerRequest) LookupByNode(key pd3.Node) (pd3.Node, error) {
 	switch key.Kind() {
 	case pd3.Kind_String:
 		if s, err := key.AsString(); err != nil {
@@ -2359,81 +3817,81 @@ func (x FindProvidersRequest) LookupByNode(key pd3.Node) (pd3.Node, error) {
 	return nil, pd1.ErrNA
 }
 
-func (x FindProvidersRequest) LookupByIndex(idx int64) (pd3.Node, error) {
+func (x FindPeerRequest) LookupByIndex(idx int64) (pd3.Node, error) {
 	switch idx {
 	case 0:
-		return x.Key.Node(), nil
+		return x.ID.Node(), nil
 
 	}
 	return nil, pd1.ErrNA
 }
 
-func (x FindProvidersRequest) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
+func (x FindPeerRequest) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
 	switch seg.String() {
-	case "0", "Key":
-		return x.Key.Node(), nil
+	case "0", "ID":
+		return x.ID.Node(), nil
 
 	}
 	return nil, pd1.ErrNA
 }
 
-func (x FindProvidersRequest) MapIterator() pd3.MapIterator {
-	return &FindProvidersRequest_MapIterator{-1, &x}
+func (x FindPeerRequest) MapIterator() pd3.MapIterator {
+	return &FindPeerRequest_MapIterator{-1, &x}
 }
 
-func (x FindProvidersRequest) ListIterator() pd3.ListIterator {
+func (x FindPeerRequest) ListIterator() pd3.ListIterator {
 	return nil
 }
 
-func (x FindProvidersRequest) Length() int64 {
+func (x FindPeerRequest) Length() int64 {
 	return 1
 }
 
-func (x FindProvidersRequest) IsAbsent() bool {
+func (x FindPeerRequest) IsAbsent() bool {
 	return false
 }
 
-func (x FindProvidersRequest) IsNull() bool {
+func (x FindPeerRequest) IsNull() bool {
 	return false
 }
 
-func (x FindProvidersRequest) AsBool() (bool, error) {
+func (x FindPeerRequest) AsBool() (bool, error) {
 	return false, pd1.ErrNA
 }
 
-func (x FindProvidersRequest) AsInt() (int64, error) {
+func (x FindPeerRequest) AsInt() (int64, error) {
 	return 0, pd1.ErrNA
 }
 
-func (x FindProvidersRequest) AsFloat() (float64, error) {
+func (x FindPeerRequest) AsFloat() (float64, error) {
 	return 0, pd1.ErrNA
 }
 
-func (x FindProvidersRequest) AsString() (string, error) {
+func (x FindPeerRequest) AsString() (string, error) {
 	return "", pd1.ErrNA
 }
 
-func (x FindProvidersRequest) AsBytes() ([]byte, error) {
+func (x FindPeerRequest) AsBytes() ([]byte, error) {
 	return nil, pd1.ErrNA
 }
 
-func (x FindProvidersRequest) AsLink() (pd3.Link, error) {
+func (x FindPeerRequest) AsLink() (pd3.Link, error) {
 	return nil, pd1.ErrNA
 }
 
-func (x FindProvidersRequest) Prototype() pd3.NodePrototype {
+func (x FindPeerRequest) Prototype() pd3.NodePrototype {
 	return nil
 }
 
-// -- protocol type ProvidersList --
+// -- protocol type PeersList --
 
-type ProvidersList []Provider
+type PeersList []Peer
 
-func (v ProvidersList) Node() pd3.Node {
+func (v PeersList) Node() pd3.Node {
 	return v
 }
 
-func (v *ProvidersList) Parse(n pd3.Node) error {
+func (v *PeersList) Parse(n pd3.Node) error {
 	if n.Kind() == pd3.Kind_Null {
 		*v = nil
 		return nil
@@ -2441,7 +3899,7 @@ func (v *ProvidersList) Parse(n pd3.Node) error {
 	if n.Kind() != pd3.Kind_List {
 		return pd1.ErrNA
 	} else {
-		*v = make(ProvidersList, n.Length())
+		*v = make(PeersList, n.Length())
 		iter := n.ListIterator()
 		for !iter.Done() {
 			if i, n, err := iter.Next(); err != nil {
@@ -2454,19 +3912,19 @@ func (v *ProvidersList) Parse(n pd3.Node) error {
 	}
 }
 
-func (ProvidersList) Kind() pd3.Kind {
+func (PeersList) Kind() pd3.Kind {
 	return pd3.Kind_List
 }
 
-func (ProvidersList) LookupByString(string) (pd3.Node, error) {
+func (PeersList) LookupByString(string) (pd3.Node, error) {
 	return nil, pd1.ErrNA
 }
 
-func (ProvidersList) LookupByNode(key pd3.Node) (pd3.Node, error) {
+func (PeersList) LookupByNode(key pd3.Node) (pd3.Node, error) {
 	return nil, pd1.ErrNA
 }
 
-func (v ProvidersList) LookupByIndex(i int64) (pd3.Node, error) {
+func (v PeersList) LookupByIndex(i int64) (pd3.Node, error) {
 	if i < 0 || i >= v.Length() {
 		return nil, pd1.ErrBounds
 	} else {
@@ -2474,7 +3932,7 @@ func (v ProvidersList) LookupByIndex(i int64) (pd3.Node, error) {
 	}
 }
 
-func (v ProvidersList) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
+func (v PeersList) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
 	if i, err := seg.Index(); err != nil {
 		return nil, pd1.ErrNA
 	} else {
@@ -2482,60 +3940,60 @@ func (v ProvidersList) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
 	}
 }
 
-func (ProvidersList) MapIterator() pd3.MapIterator {
+func (PeersList) MapIterator() pd3.MapIterator {
 	return nil
 }
 
-func (v ProvidersList) ListIterator() pd3.ListIterator {
-	return &ProvidersList_ListIterator{v, 0}
+func (v PeersList) ListIterator() pd3.ListIterator {
+	return &PeersList_ListIterator{v, 0}
 }
 
-func (v ProvidersList) Length() int64 {
+func (v PeersList) Length() int64 {
 	return int64(len(v))
 }
 
-func (ProvidersList) IsAbsent() bool {
+func (PeersList) IsAbsent() bool {
 	return false
 }
 
-func (ProvidersList) IsNull() bool {
+func (PeersList) IsNull() bool {
 	return false
 }
 
-func (v ProvidersList) AsBool() (bool, error) {
+func (v PeersList) AsBool() (bool, error) {
 	return false, pd1.ErrNA
 }
 
-func (ProvidersList) AsInt() (int64, error) {
+func (PeersList) AsInt() (int64, error) {
 	return 0, pd1.ErrNA
 }
 
-func (ProvidersList) AsFloat() (float64, error) {
+func (PeersList) AsFloat() (float64, error) {
 	return 0, pd1.ErrNA
 }
 
-func (ProvidersList) AsString() (string, error) {
+func (PeersList) AsString() (string, error) {
 	return "", pd1.ErrNA
 }
 
-func (ProvidersList) AsBytes() ([]byte, error) {
+func (PeersList) AsBytes() ([]byte, error) {
 	return nil, pd1.ErrNA
 }
 
-func (ProvidersList) AsLink() (pd3.Link, error) {
+func (PeersList) AsLink() (pd3.Link, error) {
 	return nil, pd1.ErrNA
 }
 
-func (ProvidersList) Prototype() pd3.NodePrototype {
+func (PeersList) Prototype() pd3.NodePrototype {
 	return nil // not needed
 }
 
-type ProvidersList_ListIterator struct {
-	list ProvidersList
+type PeersList_ListIterator struct {
+	list PeersList
 	at   int64
 }
 
-func (iter *ProvidersList_ListIterator) Next() (int64, pd3.Node, error) {
+func (iter *PeersList_ListIterator) Next() (int64, pd3.Node, error) {
 	if iter.Done() {
 		return -1, nil, pd1.ErrBounds
 	}
@@ -2545,27 +4003,27 @@ func (iter *ProvidersList_ListIterator) Next() (int64, pd3.Node, error) {
 	return i, v.Node(), nil
 }
 
-func (iter *ProvidersList_ListIterator) Done() bool {
+func (iter *PeersList_ListIterator) Done() bool {
 	return iter.at >= iter.list.Length()
 }
 
-// -- protocol type FindProvidersResponse --
+// -- protocol type FindPeerResponse --
 
-type FindProvidersResponse struct {
-	Providers ProvidersList
+type FindPeerResponse struct {
+	Peers PeersList
 }
 
-func (x FindProvidersResponse) Node() pd3.Node {
+func (x FindPeerResponse) Node() pd3.Node {
 	return x
 }
 
-func (x *FindProvidersResponse) Parse(n pd3.Node) error {
+func (x *FindPeerResponse) Parse(n pd3.Node) error {
 	if n.Kind() != pd3.Kind_Map {
 		return pd1.ErrNA
 	}
 	iter := n.MapIterator()
 	fieldMap := map[string]pd1.ParseFunc{
-		"Providers": x.Providers.Parse,
+		"Peers": x.Peers.Parse,
 	}
 	for !iter.Done() {
 		if kn, vn, err := iter.Next(); err != nil {
@@ -2576,14 +4034,14 @@ func (x *FindProvidersResponse) Parse(n pd3.Node) error {
 			} else {
 				_ = vn
 				switch k {
-				case "Providers":
-					if _, notParsed := fieldMap["Providers"]; !notParsed {
-						return pd2.Errorf("field %s already parsed", "Providers")
+				case "Peers":
+					if _, notParsed := fieldMap["Peers"]; !notParsed {
+						return pd2.Errorf("field %s already parsed", "Peers")
 					}
-					if err := x.Providers.Parse(vn); err != nil {
+					if err := x.Peers.Parse(vn); err != nil {
 						return err
 					}
-					delete(fieldMap, "Providers")
+					delete(fieldMap, "Peers")
 
 				}
 			}
@@ -2597,39 +4055,39 @@ func (x *FindProvidersResponse) Parse(n pd3.Node) error {
 	return nil
 }
 
-type FindProvidersResponse_MapIterator struct {
+type FindPeerResponse_MapIterator struct {
 	i int64
-	s *FindProvidersResponse
+	s *FindPeerResponse
 }
 
-func (x *FindProvidersResponse_MapIterator) Next() (key pd3.Node, value pd3.Node, err error) {
+func (x *FindPeerResponse_MapIterator) Next() (key pd3.Node, value pd3.Node, err error) {
 	x.i++
 	switch x.i {
 	case 0:
-		return pd1.String("Providers"), x.s.Providers.Node(), nil
+		return pd1.String("Peers"), x.s.Peers.Node(), nil
 
 	}
 	return nil, nil, pd1.ErrNA
 }
 
-func (x *FindProvidersResponse_MapIterator) Done() bool {
+func (x *FindPeerResponse_MapIterator) Done() bool {
 	return x.i+1 >= 1
 }
 
-func (x FindProvidersResponse) Kind() pd3.Kind {
+func (x FindPeerResponse) Kind() pd3.Kind {
 	return pd3.Kind_Map
 }
 
-func (x FindProvidersResponse) LookupByString(key string) (pd3.Node, error) {
+func (x FindPeerResponse) LookupByString(key string) (pd3.Node, error) {
 	switch key {
-	case "Providers":
-		return x.Providers.Node(), nil
+	case "Peers":
+		return x.Peers.Node(), nil
 
 	}
 	return nil, pd1.ErrNA
 }
 
-func (x FindProvidersResponse) LookupByNode(key pd3.Node) (pd3.Node, error) {
+func (x FindPeerResponse) LookupByNode(key pd3.Node) (pd3.Node, error) {
 	switch key.Kind() {
 	case pd3.Kind_String:
 		if s, err := key.AsString(); err != nil {
@@ -2647,69 +4105,69 @@ func (x FindProvidersResponse) LookupByNode(key pd3.Node) (pd3.Node, error) {
 	return nil, pd1.ErrNA
 }
 
-func (x FindProvidersResponse) LookupByIndex(idx int64) (pd3.Node, error) {
+func (x FindPeerResponse) LookupByIndex(idx int64) (pd3.Node, error) {
 	switch idx {
 	case 0:
-		return x.Providers.Node(), nil
+		return x.Peers.Node(), nil
 
 	}
 	return nil, pd1.ErrNA
 }
 
-func (x FindProvidersResponse) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
+func (x FindPeerResponse) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
 	switch seg.String() {
-	case "0", "Providers":
-		return x.Providers.Node(), nil
+	case "0", "Peers":
+		return x.Peers.Node(), nil
 
 	}
 	return nil, pd1.ErrNA
 }
 
-func (x FindProvidersResponse) MapIterator() pd3.MapIterator {
-	return &FindProvidersResponse_MapIterator{-1, &x}
+func (x FindPeerResponse) MapIterator() pd3.MapIterator {
+	return &FindPeerResponse_MapIterator{-1, &x}
 }
 
-func (x FindProvidersResponse) ListIterator() pd3.ListIterator {
+func (x FindPeerResponse) ListIterator() pd3.ListIterator {
 	return nil
 }
 
-func (x FindProvidersResponse) Length() int64 {
+func (x FindPeerResponse) Length() int64 {
 	return 1
 }
 
-func (x FindProvidersResponse) IsAbsent() bool {
+func (x FindPeerResponse) IsAbsent() bool {
 	return false
 }
 
-func (x FindProvidersResponse) IsNull() bool {
+func (x FindPeerResponse) IsNull() bool {
 	return false
 }
 
-func (x FindProvidersResponse) AsBool() (bool, error) {
+func (x FindPeerResponse) AsBool() (bool, error) {
 	return false, pd1.ErrNA
 }
 
-func (x FindProvidersResponse) AsInt() (int64, error) {
+func (x FindPeerResponse) AsInt() (int64, error) {
 	return 0, pd1.ErrNA
 }
 
-func (x FindProvidersResponse) AsFloat() (float64, error) {
+func (x FindPeerResponse) AsFloat() (float64, error) {
 	return 0, pd1.ErrNA
 }
 
-func (x FindProvidersResponse) AsString() (string, error) {
+func (x FindPeerResponse) AsString() (string, error) {
 	return "", pd1.ErrNA
 }
 
-func (x FindProvidersResponse) AsBytes() ([]byte, error) {
+func (x FindPeerResponse) AsBytes() ([]byte, error) {
 	return nil, pd1.ErrNA
 }
 
-func (x FindProvidersResponse) AsLink() (pd3.Link, error) {
+func (x FindPeerResponse) AsLink() (pd3.Link, error) {
 	return nil, pd1.ErrNA
 }
 
-func (x FindProvidersResponse) Prototype() pd3.NodePrototype {
+func (x FindPeerResponse) Prototype() pd3.NodePrototype {
 	return nil
 }
 
@@ -3368,15 +4826,15 @@ func (x PutIPNSResponse) Prototype() pd3.NodePrototype {
 	return nil
 }
 
-// -- protocol type AnonList14 --
+// -- protocol type AnonList20 --
 
-type AnonList14 []LinkToAny
+type AnonList20 []LinkToAny
 
-func (v AnonList14) Node() pd3.Node {
+func (v AnonList20) Node() pd3.Node {
 	return v
 }
 
-func (v *AnonList14) Parse(n pd3.Node) error {
+func (v *AnonList20) Parse(n pd3.Node) error {
 	if n.Kind() == pd3.Kind_Null {
 		*v = nil
 		return nil
@@ -3384,7 +4842,7 @@ func (v *AnonList14) Parse(n pd3.Node) error {
 	if n.Kind() != pd3.Kind_List {
 		return pd1.ErrNA
 	} else {
-		*v = make(AnonList14, n.Length())
+		*v = make(AnonList20, n.Length())
 		iter := n.ListIterator()
 		for !iter.Done() {
 			if i, n, err := iter.Next(); err != nil {
@@ -3397,19 +4855,19 @@ func (v *AnonList14) Parse(n pd3.Node) error {
 	}
 }
 
-func (AnonList14) Kind() pd3.Kind {
+func (AnonList20) Kind() pd3.Kind {
 	return pd3.Kind_List
 }
 
-func (AnonList14) LookupByString(string) (pd3.Node, error) {
+func (AnonList20) LookupByString(string) (pd3.Node, error) {
 	return nil, pd1.ErrNA
 }
 
-func (AnonList14) LookupByNode(key pd3.Node) (pd3.Node, error) {
+func (AnonList20) LookupByNode(key pd3.Node) (pd3.Node, error) {
 	return nil, pd1.ErrNA
 }
 
-func (v AnonList14) LookupByIndex(i int64) (pd3.Node, error) {
+func (v AnonList20) LookupByIndex(i int64) (pd3.Node, error) {
 	if i < 0 || i >= v.Length() {
 		return nil, pd1.ErrBounds
 	} else {
@@ -3417,7 +4875,7 @@ func (v AnonList14) LookupByIndex(i int64) (pd3.Node, error) {
 	}
 }
 
-func (v AnonList14) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
+func (v AnonList20) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
 	if i, err := seg.Index(); err != nil {
 		return nil, pd1.ErrNA
 	} else {
@@ -3425,60 +4883,60 @@ func (v AnonList14) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
 	}
 }
 
-func (AnonList14) MapIterator() pd3.MapIterator {
+func (AnonList20) MapIterator() pd3.MapIterator {
 	return nil
 }
 
-func (v AnonList14) ListIterator() pd3.ListIterator {
-	return &AnonList14_ListIterator{v, 0}
+func (v AnonList20) ListIterator() pd3.ListIterator {
+	return &AnonList20_ListIterator{v, 0}
 }
 
-func (v AnonList14) Length() int64 {
+func (v AnonList20) Length() int64 {
 	return int64(len(v))
 }
 
-func (AnonList14) IsAbsent() bool {
+func (AnonList20) IsAbsent() bool {
 	return false
 }
 
-func (AnonList14) IsNull() bool {
+func (AnonList20) IsNull() bool {
 	return false
 }
 
-func (v AnonList14) AsBool() (bool, error) {
+func (v AnonList20) AsBool() (bool, error) {
 	return false, pd1.ErrNA
 }
 
-func (AnonList14) AsInt() (int64, error) {
+func (AnonList20) AsInt() (int64, error) {
 	return 0, pd1.ErrNA
 }
 
-func (AnonList14) AsFloat() (float64, error) {
+func (AnonList20) AsFloat() (float64, error) {
 	return 0, pd1.ErrNA
 }
 
-func (AnonList14) AsString() (string, error) {
+func (AnonList20) AsString() (string, error) {
 	return "", pd1.ErrNA
 }
 
-func (AnonList14) AsBytes() ([]byte, error) {
+func (AnonList20) AsBytes() ([]byte, error) {
 	return nil, pd1.ErrNA
 }
 
-func (AnonList14) AsLink() (pd3.Link, error) {
+func (AnonList20) AsLink() (pd3.Link, error) {
 	return nil, pd1.ErrNA
 }
 
-func (AnonList14) Prototype() pd3.NodePrototype {
+func (AnonList20) Prototype() pd3.NodePrototype {
 	return nil // not needed
 }
 
-type AnonList14_ListIterator struct {
-	list AnonList14
+type AnonList20_ListIterator struct {
+	list AnonList20
 	at   int64
 }
 
-func (iter *AnonList14_ListIterator) Next() (int64, pd3.Node, error) {
+func (iter *AnonList20_ListIterator) Next() (int64, pd3.Node, error) {
 	if iter.Done() {
 		return -1, nil, pd1.ErrBounds
 	}
@@ -3488,14 +4946,14 @@ func (iter *AnonList14_ListIterator) Next() (int64, pd3.Node, error) {
 	return i, v.Node(), nil
 }
 
-func (iter *AnonList14_ListIterator) Done() bool {
+func (iter *AnonList20_ListIterator) Done() bool {
 	return iter.at >= iter.list.Length()
 }
 
 // -- protocol type ProvideRequest --
 
 type ProvideRequest struct {
-	Key         AnonList14
+	Key         AnonList20
 	Provider    Provider
 	Timestamp   pd1.Int
 	AdvisoryTTL pd1.Int
@@ -4457,15 +5915,15 @@ func (x Node) Prototype() pd3.NodePrototype {
 	return nil
 }
 
-// -- protocol type AnonList21 --
+// -- protocol type AnonList27 --
 
-type AnonList21 []pd1.Bytes
+type AnonList27 []pd1.Bytes
 
-func (v AnonList21) Node() pd3.Node {
+func (v AnonList27) Node() pd3.Node {
 	return v
 }
 
-func (v *AnonList21) Parse(n pd3.Node) error {
+func (v *AnonList27) Parse(n pd3.Node) error {
 	if n.Kind() == pd3.Kind_Null {
 		*v = nil
 		return nil
@@ -4473,7 +5931,7 @@ func (v *AnonList21) Parse(n pd3.Node) error {
 	if n.Kind() != pd3.Kind_List {
 		return pd1.ErrNA
 	} else {
-		*v = make(AnonList21, n.Length())
+		*v = make(AnonList27, n.Length())
 		iter := n.ListIterator()
 		for !iter.Done() {
 			if i, n, err := iter.Next(); err != nil {
@@ -4486,19 +5944,19 @@ func (v *AnonList21) Parse(n pd3.Node) error {
 	}
 }
 
-func (AnonList21) Kind() pd3.Kind {
+func (AnonList27) Kind() pd3.Kind {
 	return pd3.Kind_List
 }
 
-func (AnonList21) LookupByString(string) (pd3.Node, error) {
+func (AnonList27) LookupByString(string) (pd3.Node, error) {
 	return nil, pd1.ErrNA
 }
 
-func (AnonList21) LookupByNode(key pd3.Node) (pd3.Node, error) {
+func (AnonList27) LookupByNode(key pd3.Node) (pd3.Node, error) {
 	return nil, pd1.ErrNA
 }
 
-func (v AnonList21) LookupByIndex(i int64) (pd3.Node, error) {
+func (v AnonList27) LookupByIndex(i int64) (pd3.Node, error) {
 	if i < 0 || i >= v.Length() {
 		return nil, pd1.ErrBounds
 	} else {
@@ -4506,7 +5964,7 @@ func (v AnonList21) LookupByIndex(i int64) (pd3.Node, error) {
 	}
 }
 
-func (v AnonList21) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
+func (v AnonList27) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
 	if i, err := seg.Index(); err != nil {
 		return nil, pd1.ErrNA
 	} else {
@@ -4514,60 +5972,60 @@ func (v AnonList21) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
 	}
 }
 
-func (AnonList21) MapIterator() pd3.MapIterator {
+func (AnonList27) MapIterator() pd3.MapIterator {
 	return nil
 }
 
-func (v AnonList21) ListIterator() pd3.ListIterator {
-	return &AnonList21_ListIterator{v, 0}
+func (v AnonList27) ListIterator() pd3.ListIterator {
+	return &AnonList27_ListIterator{v, 0}
 }
 
-func (v AnonList21) Length() int64 {
+func (v AnonList27) Length() int64 {
 	return int64(len(v))
 }
 
-func (AnonList21) IsAbsent() bool {
+func (AnonList27) IsAbsent() bool {
 	return false
 }
 
-func (AnonList21) IsNull() bool {
+func (AnonList27) IsNull() bool {
 	return false
 }
 
-func (v AnonList21) AsBool() (bool, error) {
+func (v AnonList27) AsBool() (bool, error) {
 	return false, pd1.ErrNA
 }
 
-func (AnonList21) AsInt() (int64, error) {
+func (AnonList27) AsInt() (int64, error) {
 	return 0, pd1.ErrNA
 }
 
-func (AnonList21) AsFloat() (float64, error) {
+func (AnonList27) AsFloat() (float64, error) {
 	return 0, pd1.ErrNA
 }
 
-func (AnonList21) AsString() (string, error) {
+func (AnonList27) AsString() (string, error) {
 	return "", pd1.ErrNA
 }
 
-func (AnonList21) AsBytes() ([]byte, error) {
+func (AnonList27) AsBytes() ([]byte, error) {
 	return nil, pd1.ErrNA
 }
 
-func (AnonList21) AsLink() (pd3.Link, error) {
+func (AnonList27) AsLink() (pd3.Link, error) {
 	return nil, pd1.ErrNA
 }
 
-func (AnonList21) Prototype() pd3.NodePrototype {
+func (AnonList27) Prototype() pd3.NodePrototype {
 	return nil // not needed
 }
 
-type AnonList21_ListIterator struct {
-	list AnonList21
+type AnonList27_ListIterator struct {
+	list AnonList27
 	at   int64
 }
 
-func (iter *AnonList21_ListIterator) Next() (int64, pd3.Node, error) {
+func (iter *AnonList27_ListIterator) Next() (int64, pd3.Node, error) {
 	if iter.Done() {
 		return -1, nil, pd1.ErrBounds
 	}
@@ -4577,7 +6035,7 @@ func (iter *AnonList21_ListIterator) Next() (int64, pd3.Node, error) {
 	return i, v.Node(), nil
 }
 
-func (iter *AnonList21_ListIterator) Done() bool {
+func (iter *AnonList27_ListIterator) Done() bool {
 	return iter.at >= iter.list.Length()
 }
 
@@ -4585,7 +6043,7 @@ func (iter *AnonList21_ListIterator) Done() bool {
 
 type Peer struct {
 	ID             pd1.Bytes
-	Multiaddresses AnonList21
+	Multiaddresses AnonList27
 }
 
 func (x Peer) Node() pd3.Node {
@@ -5288,3 +6746,127 @@ func (x GraphSyncFILv1Protocol) AsLink() (pd3.Link, error) {
 func (x GraphSyncFILv1Protocol) Prototype() pd3.NodePrototype {
 	return nil
 }
+
+// -- protocol type ErrorsList --
+
+type ErrorsList []pd1.String
+
+func (v ErrorsList) Node() pd3.Node {
+	return v
+}
+
+func (v *ErrorsList) Parse(n pd3.Node) error {
+	if n.Kind() == pd3.Kind_Null {
+		*v = nil
+		return nil
+	}
+	if n.Kind() != pd3.Kind_List {
+		return pd1.ErrNA
+	} else {
+		*v = make(ErrorsList, n.Length())
+		iter := n.ListIterator()
+		for !iter.Done() {
+			if i, n, err := iter.Next(); err != nil {
+				return pd1.ErrNA
+			} else if err = (*v)[i].Parse(n); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (ErrorsList) Kind() pd3.Kind {
+	return pd3.Kind_List
+}
+
+func (ErrorsList) LookupByString(string) (pd3.Node, error) {
+	return nil, pd1.ErrNA
+}
+
+func (ErrorsList) LookupByNode(key pd3.Node) (pd3.Node, error) {
+	return nil, pd1.ErrNA
+}
+
+func (v ErrorsList) LookupByIndex(i int64) (pd3.Node, error) {
+	if i < 0 || i >= v.Length() {
+		return nil, pd1.ErrBounds
+	} else {
+		return v[i].Node(), nil
+	}
+}
+
+func (v ErrorsList) LookupBySegment(seg pd3.PathSegment) (pd3.Node, error) {
+	if i, err := seg.Index(); err != nil {
+		return nil, pd1.ErrNA
+	} else {
+		return v.LookupByIndex(i)
+	}
+}
+
+func (ErrorsList) MapIterator() pd3.MapIterator {
+	return nil
+}
+
+func (v ErrorsList) ListIterator() pd3.ListIterator {
+	return &ErrorsList_ListIterator{v, 0}
+}
+
+func (v ErrorsList) Length() int64 {
+	return int64(len(v))
+}
+
+func (ErrorsList) IsAbsent() bool {
+	return false
+}
+
+func (ErrorsList) IsNull() bool {
+	return false
+}
+
+func (v ErrorsList) AsBool() (bool, error) {
+	return false, pd1.ErrNA
+}
+
+func (ErrorsList) AsInt() (int64, error) {
+	return 0, pd1.ErrNA
+}
+
+func (ErrorsList) AsFloat() (float64, error) {
+	return 0, pd1.ErrNA
+}
+
+func (ErrorsList) AsString() (string, error) {
+	return "", pd1.ErrNA
+}
+
+func (ErrorsList) AsBytes() ([]byte, error) {
+	return nil, pd1.ErrNA
+}
+
+func (ErrorsList) AsLink() (pd3.Link, error) {
+	return nil, pd1.ErrNA
+}
+
+func (ErrorsList) Prototype() pd3.NodePrototype {
+	return nil // not needed
+}
+
+type ErrorsList_ListIterator struct {
+	list ErrorsList
+	at   int64
+}
+
+func (iter *ErrorsList_ListIterator) Next() (int64, pd3.Node, error) {
+	if iter.Done() {
+		return -1, nil, pd1.ErrBounds
+	}
+	v := iter.list[iter.at]
+	i := int64(iter.at)
+	iter.at++
+	return i, v.Node(), nil
+}
+
+func (iter *ErrorsList_ListIterator) Done() bool {
+	return iter.at >= iter.list.Length()
+}
@@ -26,6 +26,21 @@ var proto = defs.Defs{
 					},
 					Cachable: true,
 				},
+				defs.Method{
+					Name: "FindProvidersBatch",
+					Type: defs.Fn{
+						Arg:    defs.Ref{Name: "FindProvidersBatchRequest"},
+						Return: defs.Ref{Name: "FindProvidersBatchResponse"},
+					},
+				},
+				defs.Method{
+					Name: "FindPeer",
+					Type: defs.Fn{
+						Arg:    defs.Ref{Name: "FindPeerRequest"},
+						Return: defs.Ref{Name: "FindPeerResponse"},
+					},
+					Cachable: true,
+				},
 				defs.Method{
 					Name: "GetIPNS",
 					Type: defs.Fn{
@@ -82,6 +97,64 @@ var proto = defs.Defs{
 		},
 	},
 
+	// FindProvidersBatch request type
+	defs.Named{
+		Name: "FindProvidersBatchRequest",
+		Type: defs.Structure{
+			Fields: defs.Fields{
+				defs.Field{
+					Name:   "Keys",
+					GoName: "Keys",
+					Type: defs.Named{
+						Name: "LinksList",
+						Type: defs.List{Element: defs.Ref{Name: "LinkToAny"}},
+					},
+				},
+			},
+		},
+	},
+
+	// FindProvidersBatch response type, tagged with the key it answers.
+	// The lookup of a key which failed is answered with its error in Error, which is empty otherwise.
+	// Error is a list so that responses without it, from servers which predate it, can be parsed.
+	defs.Named{
+		Name: "FindProvidersBatchResponse",
+		Type: defs.Structure{
+			Fields: defs.Fields{
+				defs.Field{Name: "Key", GoName: "Key", Type: defs.Ref{Name: "LinkToAny"}},
+				defs.Field{Name: "Providers", GoName: "Providers", Type: defs.Ref{Name: "ProvidersList"}},
+				defs.Field{Name: "Error", GoName: "Error", Type: defs.Ref{Name: "ErrorsList"}},
+			},
+		},
+	},
+
+	// FindPeer request type
+	defs.Named{
+		Name: "FindPeerRequest",
+		Type: defs.Structure{
+			Fields: defs.Fields{
+				defs.Field{Name: "ID", GoName: "ID", Type: defs.Bytes{}},
+			},
+		},
+	},
+
+	// FindPeer response type
+	defs.Named{
+		Name: "FindPeerResponse",
+		Type: defs.Structure{
+			Fields: defs.Fields{
+				defs.Field{
+					Name:   "Peers",
+					GoName: "Peers",
+					Type: defs.Named{
+						Name: "PeersList",
+						Type: defs.List{Element: defs.Ref{Name: "Peer"}},
+					},
+				},
+			},
+		},
+	},
+
 	// GetIPNS request type
 	defs.Named{
 		Name: "GetIPNSRequest",
@@ -226,6 +299,11 @@ var proto = defs.Defs{
 			},
 		},
 	},
+
+	defs.Named{
+		Name: "ErrorsList",
+		Type: defs.List{Element: defs.String{}},
+	},
 }
 
 var logger = log.Logger("proto generator")
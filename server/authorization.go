@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// ProvideAuthorizer decides whether a provide request, whose signature has been verified, may be passed to the service.
+type ProvideAuthorizer interface {
+	// AuthorizeProvide returns nil if req is authorized. Errors which are not a client.RejectionError
+	// are returned to the client as client.ErrProvideUnauthorized.
+	AuthorizeProvide(ctx context.Context, req *client.ProvideRequest) error
+}
+
+// ProvideAuthorizerFunc authorizes provide requests with a callback.
+type ProvideAuthorizerFunc func(ctx context.Context, req *client.ProvideRequest) error
+
+func (f ProvideAuthorizerFunc) AuthorizeProvide(ctx context.Context, req *client.ProvideRequest) error {
+	return f(ctx, req)
+}
+
+// WithProvideAuthorizer makes the handler pass only the provide requests authorized by a to the service.
+// When the option is given several times, requests must be authorized by every authorizer, in the order given.
+func WithProvideAuthorizer(a ProvideAuthorizer) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.authorizers = append(cfg.authorizers, a)
+	}
+}
+
+// checkProvide returns the rejection of a verified provide request by the freshness policy or the authorizers, or nil.
+// If the request is accepted, release must be called if the service rejects it.
+func (drs *delegatedRoutingServer) checkProvide(ctx context.Context, req *client.ProvideRequest) (release func(), err error) {
+	release, err = drs.freshness.check(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := drs.authorizeProvide(ctx, req); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// authorizeProvide returns the rejection of req by the first authorizer which does not authorize it, or nil.
+func (drs *delegatedRoutingServer) authorizeProvide(ctx context.Context, req *client.ProvideRequest) error {
+	for _, a := range drs.authorizers {
+		err := a.AuthorizeProvide(ctx, req)
+		if err == nil {
+			continue
+		}
+		var rejection *client.RejectionError
+		if errors.As(err, &rejection) {
+			return rejection
+		}
+		return client.ErrProvideUnauthorized.WithReason(err.Error())
+	}
+	return nil
+}
+
+type allowlistAuthorizer map[peer.ID]bool
+
+// NewAllowlistAuthorizer returns an authorizer of the provide requests of the given peers only.
+func NewAllowlistAuthorizer(ids ...peer.ID) ProvideAuthorizer {
+	a := allowlistAuthorizer{}
+	for _, id := range ids {
+		a[id] = true
+	}
+	return a
+}
+
+func (a allowlistAuthorizer) AuthorizeProvide(ctx context.Context, req *client.ProvideRequest) error {
+	if !a[req.Provider.Peer.ID] {
+		return client.ErrProvideUnauthorized.WithReason(fmt.Sprintf("peer %v is not allowed", req.Provider.Peer.ID))
+	}
+	return nil
+}
+
+type quotaAuthorizer struct {
+	maxKeys int
+	window  time.Duration
+	clock   Clock
+
+	lk        sync.Mutex
+	windows   map[peer.ID]*quotaWindow
+	lastPrune time.Time
+}
+
+type quotaWindow struct {
+	start time.Time
+	keys  int
+}
+
+// NewKeyQuotaAuthorizer returns an authorizer of at most maxKeys provided keys per peer in each window.
+// Windows start with the first request of a peer after the previous window has ended.
+// Requests which would exceed the quota are rejected as a whole with client.ErrProvideQuotaExceeded,
+// and do not count towards it. A nil clock stands for the system clock.
+func NewKeyQuotaAuthorizer(maxKeys int, window time.Duration, clock Clock) ProvideAuthorizer {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &quotaAuthorizer{maxKeys: maxKeys, window: window, clock: clock, windows: map[peer.ID]*quotaWindow{}}
+}
+
+func (a *quotaAuthorizer) AuthorizeProvide(ctx context.Context, req *client.ProvideRequest) error {
+	now := a.clock.Now()
+	id := req.Provider.Peer.ID
+
+	a.lk.Lock()
+	defer a.lk.Unlock()
+	a.prune(now)
+	w, ok := a.windows[id]
+	if !ok || !now.Before(w.start.Add(a.window)) {
+		w = &quotaWindow{start: now}
+		a.windows[id] = w
+	}
+	if w.keys+len(req.Key) > a.maxKeys {
+		return client.ErrProvideQuotaExceeded.WithReason(fmt.Sprintf("%d keys provided out of %d until %v",
+			w.keys, a.maxKeys, w.start.Add(a.window).UTC()))
+	}
+	w.keys += len(req.Key)
+	return nil
+}
+
+// prune forgets the ended windows, at most once per window.
+func (a *quotaAuthorizer) prune(now time.Time) {
+	if now.Sub(a.lastPrune) < a.window {
+		return
+	}
+	a.lastPrune = now
+	for id, w := range a.windows {
+		if !now.Before(w.start.Add(a.window)) {
+			delete(a.windows, id)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/klauspost/compress/zstd"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+// WithCompression makes the handler compress successful responses with zstd or gzip, preferring zstd,
+// if the client lists either in its Accept-Encoding header.
+// Compression is off by default: it pays off for long provider lists over slow links,
+// but adds latency to small responses, and Go clients accept gzip unless told otherwise.
+func WithCompression() HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.compression = true
+	}
+}
+
+// compressResponses compresses successful responses if enabled, as described by WithCompression.
+// The compressor is flushed whenever the protocol handler flushes, so that streamed results are not delayed.
+func compressResponses(enabled bool, next http.Handler) http.HandlerFunc {
+	if !enabled {
+		return next.ServeHTTP
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		// Accept-Encoding shares the list syntax of Accept
+		accept := r.Header.Get("Accept-Encoding")
+		var encoding string
+		switch {
+		case client.AcceptsMediaType(accept, "zstd"):
+			encoding = "zstd"
+		case client.AcceptsMediaType(accept, "gzip"):
+			encoding = "gzip"
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+		r = untagETags(r, encoding)
+		cw := &compressedResponseWriter{ResponseWriter: w, encoding: encoding}
+		defer func() {
+			if err := cw.close(); err != nil {
+				logger.Errorf("finishing %s compressed response (%v)", encoding, err)
+			}
+			if cw.compressed.w != nil {
+				stats.RecordWithTags(r.Context(),
+					[]tag.Mutator{tag.Upsert(keyEncoding, encoding)},
+					measureUncompressedBytes.M(cw.uncompressed),
+					measureCompressedBytes.M(cw.compressed.n),
+				)
+			}
+		}()
+		next.ServeHTTP(cw, r)
+	}
+}
+
+// compressor is implemented by the gzip and zstd writers.
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressors are costly to allocate, so they are reused across responses.
+var compressorPools = map[string]*sync.Pool{
+	"zstd": {New: func() interface{} {
+		enc, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			panic(err) // only fails for invalid options
+		}
+		return enc
+	}},
+	"gzip": {New: func() interface{} { return gzip.NewWriter(nil) }},
+}
+
+// compressedResponseWriter compresses the body of successful responses.
+// Other responses, which carry no body, are passed through unchanged.
+type compressedResponseWriter struct {
+	http.ResponseWriter
+	encoding    string
+	wroteHeader bool
+
+	enc          compressor // nil if the response is not compressed
+	compressed   countingWriter
+	uncompressed int64
+}
+
+func (w *compressedResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if code == http.StatusOK {
+		w.compressed.w = w.ResponseWriter
+		w.enc = compressorPools[w.encoding].Get().(compressor)
+		w.enc.Reset(&w.compressed)
+		tagETag(w.Header(), w.encoding)
+		w.Header().Set("Content-Encoding", w.encoding)
+		w.Header().Del("Content-Length")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *compressedResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.enc == nil {
+		return w.ResponseWriter.Write(p)
+	}
+	n, err := w.enc.Write(p)
+	w.uncompressed += int64(n)
+	return n, err
+}
+
+func (w *compressedResponseWriter) Flush() {
+	if w.enc != nil {
+		if err := w.enc.Flush(); err != nil {
+			logger.Errorf("flushing %s compressor (%v)", w.encoding, err)
+		}
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// close writes the end of the compressed stream and returns the compressor to its pool.
+func (w *compressedResponseWriter) close() error {
+	if w.enc == nil {
+		return nil
+	}
+	err := w.enc.Close()
+	w.enc.Reset(nil)
+	compressorPools[w.encoding].Put(w.enc)
+	w.enc = nil
+	return err
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
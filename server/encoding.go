@@ -0,0 +1,132 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/codec/dagjson"
+)
+
+// negotiateEncoding lets clients send requests and receive responses DAG-CBOR encoded, by transcoding
+// between DAG-CBOR and the DAG-JSON spoken by the protocol handler.
+// Requests are DAG-CBOR if their Content-Type says so, responses if the client lists DAG-CBOR in its Accept header.
+// Other clients are served DAG-JSON.
+func negotiateEncoding(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+
+		if r.Method == http.MethodPost && client.IsMediaType(r.Header.Get("Content-Type"), client.MediaTypeDagCBOR) {
+			msg, err := io.ReadAll(r.Body)
+			if err != nil {
+				logger.Errorf("reading request body (%v)", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if msg, err = transcodeDagCBORToDagJSON(msg); err != nil {
+				logger.Errorf("received request not decodeable as DAG-CBOR (%v)", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			r = r.Clone(r.Context())
+			r.Body = io.NopCloser(bytes.NewReader(msg))
+			r.ContentLength = int64(len(msg))
+			r.Header.Set("Content-Type", client.MediaTypeDagJSON+"; version=1")
+		}
+
+		if client.AcceptsMediaType(r.Header.Get("Accept"), client.MediaTypeDagCBOR) {
+			w = &dagCBORResponseWriter{ResponseWriter: w}
+			r = untagETags(r, "dag-cbor")
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// dagCBORResponseWriter transcodes the new line separated DAG-JSON results written by the protocol handler
+// into concatenated DAG-CBOR values. Each complete result is written through as soon as it is received.
+type dagCBORResponseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+	pending     []byte
+}
+
+func (w *dagCBORResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	tagETag(w.Header(), "dag-cbor")
+	w.Header().Set("Content-Type", client.MediaTypeDagCBOR+"; version=1")
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *dagCBORResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		n, err := ipld.Decode(w.pending[:i], dagjson.Decode)
+		if err != nil {
+			return 0, err
+		}
+		w.pending = w.pending[i+1:]
+		msg, err := ipld.Encode(n, dagcbor.Encode)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := w.ResponseWriter.Write(msg); err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (w *dagCBORResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// tagETag suffixes the ETag set by the protocol handler in h with the encoding of the response body.
+// The protocol handler computes ETags on the DAG-JSON results it writes, so responses which are encoded
+// otherwise on their way to the client, by transcoding or compression, need an ETag of their own.
+func tagETag(h http.Header, encoding string) {
+	// the protocol handler does not canonicalize the header name
+	if etag, ok := h["ETag"]; ok && len(etag) == 1 {
+		h["ETag"] = []string{etag[0] + "-" + encoding}
+	}
+}
+
+// untagETags returns r with the encoding suffix set by tagETag removed from the ETags of its If-None-Match header,
+// before the protocol handler compares them with the ETag of the response. ETags of other encodings are dropped.
+func untagETags(r *http.Request, encoding string) *http.Request {
+	etags := r.Header.Values("If-None-Match")
+	if len(etags) == 0 {
+		return r
+	}
+	r = r.Clone(r.Context())
+	r.Header.Del("If-None-Match")
+	for _, etag := range etags {
+		if strings.HasSuffix(etag, "-"+encoding) {
+			r.Header.Add("If-None-Match", strings.TrimSuffix(etag, "-"+encoding))
+		}
+	}
+	return r
+}
+
+func transcodeDagCBORToDagJSON(msg []byte) ([]byte, error) {
+	n, err := ipld.Decode(msg, dagcbor.Decode)
+	if err != nil {
+		return nil, err
+	}
+	return ipld.Encode(n, dagjson.Encode)
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"net/http"
+	"sync"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-delegated-routing/client"
@@ -16,28 +17,64 @@ var logger = logging.Logger("service/server/delegatedrouting")
 
 type DelegatedRoutingService interface {
 	FindProviders(ctx context.Context, key cid.Cid) (<-chan client.FindProvidersAsyncResult, error)
+	FindPeer(ctx context.Context, id peer.ID) (<-chan client.FindPeerAsyncResult, error)
 	GetIPNS(ctx context.Context, id []byte) (<-chan client.GetIPNSAsyncResult, error)
 	PutIPNS(ctx context.Context, id []byte, record []byte) (<-chan client.PutIPNSAsyncResult, error)
 	Provide(ctx context.Context, req *client.ProvideRequest) (<-chan client.ProvideAsyncResult, error)
 }
 
-func DelegatedRoutingAsyncHandler(svc DelegatedRoutingService) http.HandlerFunc {
-	drs := &delegatedRoutingServer{svc}
-	return proto.DelegatedRouting_AsyncHandler(drs)
+// HandlerOption configures optional behavior of the handler returned by DelegatedRoutingAsyncHandler.
+type HandlerOption func(*handlerConfig)
+
+type handlerConfig struct {
+	limits       client.Limits
+	validateIPNS bool
+	freshness    FreshnessPolicy
+	clock        Clock
+	authorizers  []ProvideAuthorizer
+	rateLimits   []RateLimit
+	compression  bool
+}
+
+func DelegatedRoutingAsyncHandler(svc DelegatedRoutingService, opts ...HandlerOption) http.HandlerFunc {
+	cfg := handlerConfig{clock: systemClock{}}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	drs := &delegatedRoutingServer{
+		service:        instrumentedService{svc},
+		records:        instrumentedService{svc},
+		uninstrumented: svc,
+		maxProviders:   cfg.limits.MaxProviders,
+		validateIPNS:   cfg.validateIPNS,
+		freshness:      newFreshnessChecker(cfg.freshness, cfg.clock),
+		authorizers:    cfg.authorizers,
+	}
+	rl := newRateLimiter(cfg.rateLimits, cfg.clock)
+	return traceRequests(recordRequests(rl.beforeBody(compressResponses(cfg.compression, limitRequests(cfg.limits, negotiateEncoding(cacheEnvelopes(rl.afterBody(restrictMethods(svc, proto.DelegatedRouting_AsyncHandler(drs))))))))))
 }
 
 type delegatedRoutingServer struct {
 	service DelegatedRoutingService
+	records ProviderRecordsService
+	// uninstrumented serves the reads which the handler makes on its own behalf, such as that of checkIPNS,
+	// so that they are not recorded as calls of the clients.
+	uninstrumented DelegatedRoutingService
+	maxProviders   int
+	validateIPNS   bool
+	freshness      *freshnessChecker
+	authorizers    []ProvideAuthorizer
 }
 
 func (drs *delegatedRoutingServer) GetIPNS(ctx context.Context, req *proto.GetIPNSRequest) (<-chan *proto.DelegatedRouting_GetIPNS_AsyncResult, error) {
+	setRequestMethod(ctx, "GetIPNS")
 	rch := make(chan *proto.DelegatedRouting_GetIPNS_AsyncResult)
 	go func() {
 		defer close(rch)
 		id := req.ID
 		ch, err := drs.service.GetIPNS(ctx, id)
 		if err != nil {
-			logger.Errorf("get ipns function rejected request (%w)", err)
+			sendResult(ctx, rch, &proto.DelegatedRouting_GetIPNS_AsyncResult{Err: rejectRequest(ctx, "get ipns", err)})
 			return
 		}
 
@@ -51,8 +88,7 @@ func (drs *delegatedRoutingServer) GetIPNS(ctx context.Context, req *proto.GetIP
 				}
 				var resp *proto.DelegatedRouting_GetIPNS_AsyncResult
 				if x.Err != nil {
-					logger.Infof("get ipns function returned error (%w)", x.Err)
-					resp = &proto.DelegatedRouting_GetIPNS_AsyncResult{Err: x.Err}
+					resp = &proto.DelegatedRouting_GetIPNS_AsyncResult{Err: rejectRequest(ctx, "get ipns", x.Err)}
 				} else {
 					resp = &proto.DelegatedRouting_GetIPNS_AsyncResult{Resp: &proto.GetIPNSResponse{Record: x.Record}}
 				}
@@ -69,13 +105,20 @@ func (drs *delegatedRoutingServer) GetIPNS(ctx context.Context, req *proto.GetIP
 }
 
 func (drs *delegatedRoutingServer) PutIPNS(ctx context.Context, req *proto.PutIPNSRequest) (<-chan *proto.DelegatedRouting_PutIPNS_AsyncResult, error) {
+	setRequestMethod(ctx, "PutIPNS")
 	rch := make(chan *proto.DelegatedRouting_PutIPNS_AsyncResult)
 	go func() {
 		defer close(rch)
 		id, record := req.ID, req.Record
+		if drs.validateIPNS {
+			if err := drs.checkIPNS(ctx, id, record); err != nil {
+				sendResult(ctx, rch, &proto.DelegatedRouting_PutIPNS_AsyncResult{Err: rejectRequest(ctx, "put ipns", err)})
+				return
+			}
+		}
 		ch, err := drs.service.PutIPNS(ctx, id, record)
 		if err != nil {
-			logger.Errorf("put ipns function rejected request (%w)", err)
+			sendResult(ctx, rch, &proto.DelegatedRouting_PutIPNS_AsyncResult{Err: rejectRequest(ctx, "put ipns", err)})
 			return
 		}
 
@@ -89,8 +132,7 @@ func (drs *delegatedRoutingServer) PutIPNS(ctx context.Context, req *proto.PutIP
 				}
 				var resp *proto.DelegatedRouting_PutIPNS_AsyncResult
 				if x.Err != nil {
-					logger.Infof("put ipns function returned error (%w)", x.Err)
-					resp = &proto.DelegatedRouting_PutIPNS_AsyncResult{Err: x.Err}
+					resp = &proto.DelegatedRouting_PutIPNS_AsyncResult{Err: rejectRequest(ctx, "put ipns", x.Err)}
 				} else {
 					resp = &proto.DelegatedRouting_PutIPNS_AsyncResult{Resp: &proto.PutIPNSResponse{}}
 				}
@@ -107,33 +149,146 @@ func (drs *delegatedRoutingServer) PutIPNS(ctx context.Context, req *proto.PutIP
 }
 
 func (drs *delegatedRoutingServer) FindProviders(ctx context.Context, req *proto.FindProvidersRequest) (<-chan *proto.DelegatedRouting_FindProviders_AsyncResult, error) {
+	setRequestMethod(ctx, "FindProviders")
 	rch := make(chan *proto.DelegatedRouting_FindProviders_AsyncResult)
 	go func() {
 		defer close(rch)
 		pcids := parseCidsFromFindProvidersRequest(req)
 		for _, c := range pcids {
-			ch, err := drs.service.FindProviders(ctx, c)
-			if err != nil {
-				logger.Errorf("find providers function rejected request (%w)", err)
-				continue
-			}
+			ok := drs.findProviders(ctx, c, func(x client.FindProviderRecordsAsyncResult) bool {
+				var resps []*proto.DelegatedRouting_FindProviders_AsyncResult
+				if x.Err != nil {
+					resps = append(resps, &proto.DelegatedRouting_FindProviders_AsyncResult{Err: x.Err})
+				} else {
+					for _, provs := range splitList(x.Providers, drs.maxProviders) {
+						resps = append(resps, buildFindProvidersResponse(c, provs))
+					}
+				}
 
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case x, ok := <-ch:
-					if !ok {
-						return
+				for _, resp := range resps {
+					select {
+					case <-ctx.Done():
+						return false
+					case rch <- resp:
 					}
-					var resp *proto.DelegatedRouting_FindProviders_AsyncResult
+				}
+				return true
+			})
+			if !ok {
+				return
+			}
+		}
+	}()
+	return rch, nil
+}
+
+// findProvidersBatchConcurrency bounds the number of keys of a batch which are looked up at the same time.
+const findProvidersBatchConcurrency = 8
+
+func (drs *delegatedRoutingServer) FindProvidersBatch(ctx context.Context, req *proto.FindProvidersBatchRequest) (<-chan *proto.DelegatedRouting_FindProvidersBatch_AsyncResult, error) {
+	setRequestMethod(ctx, "FindProvidersBatch")
+	rch := make(chan *proto.DelegatedRouting_FindProvidersBatch_AsyncResult)
+	go func() {
+		defer close(rch)
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, findProvidersBatchConcurrency)
+		defer wg.Wait()
+		for _, c := range parseCidsFromFindProvidersBatchRequest(req) {
+			select {
+			case <-ctx.Done():
+				return
+			case sem <- struct{}{}:
+			}
+			wg.Add(1)
+			go func(c cid.Cid) {
+				defer func() { <-sem; wg.Done() }()
+				drs.findProviders(ctx, c, func(x client.FindProviderRecordsAsyncResult) bool {
+					var resps []*proto.DelegatedRouting_FindProvidersBatch_AsyncResult
 					if x.Err != nil {
-						logger.Infof("find providers function returned error (%w)", x.Err)
-						resp = &proto.DelegatedRouting_FindProviders_AsyncResult{Err: x.Err}
+						resps = append(resps, buildFindProvidersBatchError(c, x.Err))
 					} else {
-						resp = buildFindProvidersResponse(c, x.AddrInfo)
+						for _, provs := range splitList(x.Providers, drs.maxProviders) {
+							resps = append(resps, buildFindProvidersBatchResponse(c, provs))
+						}
 					}
 
+					for _, resp := range resps {
+						select {
+						case <-ctx.Done():
+							return false
+						case rch <- resp:
+						}
+					}
+					return true
+				})
+			}(c)
+		}
+	}()
+	return rch, nil
+}
+
+// findProviders passes the provider records found by the service for key c to fn, until fn returns false or the results end.
+// A rejection of the lookup by the service is passed to fn as the error of the only result,
+// and the errors of results are passed on as rejections.
+// It returns false if the lookup was cut short by the context or by fn.
+func (drs *delegatedRoutingServer) findProviders(ctx context.Context, c cid.Cid, fn func(client.FindProviderRecordsAsyncResult) bool) bool {
+	ch, err := drs.records.FindProviderRecords(ctx, c)
+	if err != nil {
+		return fn(client.FindProviderRecordsAsyncResult{Err: rejectRequest(ctx, "find providers", err)})
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case x, ok := <-ch:
+			if !ok {
+				return true
+			}
+			if x.Err != nil {
+				x.Err = rejectRequest(ctx, "find providers", x.Err)
+			}
+			if !fn(x) {
+				return false
+			}
+		}
+	}
+}
+
+func (drs *delegatedRoutingServer) FindPeer(ctx context.Context, req *proto.FindPeerRequest) (<-chan *proto.DelegatedRouting_FindPeer_AsyncResult, error) {
+	setRequestMethod(ctx, "FindPeer")
+	rch := make(chan *proto.DelegatedRouting_FindPeer_AsyncResult)
+	go func() {
+		defer close(rch)
+		id, err := peer.IDFromBytes(req.ID)
+		if err != nil {
+			err = client.ErrInvalidRequest.WithReason("invalid peer ID: " + err.Error())
+			sendResult(ctx, rch, &proto.DelegatedRouting_FindPeer_AsyncResult{Err: rejectRequest(ctx, "find peer", err)})
+			return
+		}
+		ch, err := drs.service.FindPeer(ctx, id)
+		if err != nil {
+			sendResult(ctx, rch, &proto.DelegatedRouting_FindPeer_AsyncResult{Err: rejectRequest(ctx, "find peer", err)})
+			return
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case x, ok := <-ch:
+				if !ok {
+					return
+				}
+				var resps []*proto.DelegatedRouting_FindPeer_AsyncResult
+				if x.Err != nil {
+					resps = append(resps, &proto.DelegatedRouting_FindPeer_AsyncResult{Err: rejectRequest(ctx, "find peer", x.Err)})
+				} else {
+					for _, infos := range splitList(x.AddrInfo, drs.maxProviders) {
+						resps = append(resps, buildFindPeerResponse(infos))
+					}
+				}
+
+				for _, resp := range resps {
 					select {
 					case <-ctx.Done():
 						return
@@ -147,17 +302,23 @@ func (drs *delegatedRoutingServer) FindProviders(ctx context.Context, req *proto
 }
 
 func (drs *delegatedRoutingServer) Provide(ctx context.Context, req *proto.ProvideRequest) (<-chan *proto.DelegatedRouting_Provide_AsyncResult, error) {
+	setRequestMethod(ctx, "Provide")
 	rch := make(chan *proto.DelegatedRouting_Provide_AsyncResult)
 	go func() {
 		defer close(rch)
-		pr, err := client.ParseProvideRequest(req)
+		pr, err := parseProvideRequest(ctx, req)
+		var release func()
+		if err == nil {
+			release, err = drs.checkProvide(ctx, pr)
+		}
 		if err != nil {
-			logger.Errorf("Provide function rejected request (%w)", err)
+			sendResult(ctx, rch, &proto.DelegatedRouting_Provide_AsyncResult{Err: rejectRequest(ctx, "provide", err)})
 			return
 		}
 		ch, err := drs.service.Provide(ctx, pr)
 		if err != nil {
-			logger.Errorf("Provide function rejected request (%w)", err)
+			release()
+			sendResult(ctx, rch, &proto.DelegatedRouting_Provide_AsyncResult{Err: rejectRequest(ctx, "provide", err)})
 			return
 		}
 
@@ -171,8 +332,8 @@ func (drs *delegatedRoutingServer) Provide(ctx context.Context, req *proto.Provi
 				}
 				var protoResp *proto.DelegatedRouting_Provide_AsyncResult
 				if resp.Err != nil {
-					logger.Infof("find providers function returned error (%w)", resp.Err)
-					protoResp = &proto.DelegatedRouting_Provide_AsyncResult{Err: resp.Err}
+					release()
+					protoResp = &proto.DelegatedRouting_Provide_AsyncResult{Err: rejectRequest(ctx, "provide", resp.Err)}
 				} else {
 					protoResp = &proto.DelegatedRouting_Provide_AsyncResult{Resp: &proto.ProvideResponse{AdvisoryTTL: values.Int(resp.AdvisoryTTL)}}
 				}
@@ -192,17 +353,68 @@ func parseCidsFromFindProvidersRequest(req *proto.FindProvidersRequest) []cid.Ci
 	return []cid.Cid{cid.Cid(req.Key)}
 }
 
-func buildFindProvidersResponse(key cid.Cid, addrInfo []peer.AddrInfo) *proto.DelegatedRouting_FindProviders_AsyncResult {
-	provs := make(proto.ProvidersList, len(addrInfo))
-	bitswapProto := proto.TransferProtocol{Bitswap: &proto.BitswapProtocol{}}
-	for i, addrInfo := range addrInfo {
-		provs[i] = proto.Provider{
-			ProviderNode:  proto.Node{Peer: buildPeerFromAddrInfo(addrInfo)},
-			ProviderProto: proto.TransferProtocolList{bitswapProto},
-		}
+func buildFindProvidersResponse(key cid.Cid, provs []client.Provider) *proto.DelegatedRouting_FindProviders_AsyncResult {
+	protoProvs := make(proto.ProvidersList, len(provs))
+	for i := range provs {
+		protoProvs[i] = buildProvider(&provs[i])
 	}
 	return &proto.DelegatedRouting_FindProviders_AsyncResult{
-		Resp: &proto.FindProvidersResponse{Providers: provs},
+		Resp: &proto.FindProvidersResponse{Providers: protoProvs},
+	}
+}
+
+// buildProvider encodes prov, leaving out the transfer protocols which the protocol cannot represent.
+func buildProvider(prov *client.Provider) proto.Provider {
+	pp := proto.Provider{
+		ProviderNode:  proto.Node{Peer: buildPeerFromAddrInfo(prov.Peer)},
+		ProviderProto: proto.TransferProtocolList{},
+	}
+	for i := range prov.ProviderProto {
+		tp := prov.ProviderProto[i].ToProto()
+		if tp.Bitswap == nil && tp.GraphSyncFILv1 == nil {
+			logger.Infof("leaving out transfer protocol %v of provider %v", prov.ProviderProto[i].Codec, prov.Peer.ID)
+			continue
+		}
+		pp.ProviderProto = append(pp.ProviderProto, tp)
+	}
+	return pp
+}
+
+func parseCidsFromFindProvidersBatchRequest(req *proto.FindProvidersBatchRequest) []cid.Cid {
+	cids := make([]cid.Cid, len(req.Keys))
+	for i, key := range req.Keys {
+		cids[i] = cid.Cid(key)
+	}
+	return cids
+}
+
+func buildFindProvidersBatchResponse(key cid.Cid, provs []client.Provider) *proto.DelegatedRouting_FindProvidersBatch_AsyncResult {
+	return &proto.DelegatedRouting_FindProvidersBatch_AsyncResult{
+		Resp: &proto.FindProvidersBatchResponse{
+			Key:       proto.LinkToAny(key),
+			Providers: buildFindProvidersResponse(key, provs).Resp.Providers,
+		},
+	}
+}
+
+// buildFindProvidersBatchError answers the failed lookup of key with a response rather than an error,
+// so that the client can tell which key failed.
+func buildFindProvidersBatchError(key cid.Cid, err error) *proto.DelegatedRouting_FindProvidersBatch_AsyncResult {
+	return &proto.DelegatedRouting_FindProvidersBatch_AsyncResult{
+		Resp: &proto.FindProvidersBatchResponse{
+			Key:   proto.LinkToAny(key),
+			Error: proto.ErrorsList{values.String(err.Error())},
+		},
+	}
+}
+
+func buildFindPeerResponse(addrInfo []peer.AddrInfo) *proto.DelegatedRouting_FindPeer_AsyncResult {
+	peers := make(proto.PeersList, len(addrInfo))
+	for i, addrInfo := range addrInfo {
+		peers[i] = *buildPeerFromAddrInfo(addrInfo)
+	}
+	return &proto.DelegatedRouting_FindPeer_AsyncResult{
+		Resp: &proto.FindPeerResponse{Peers: peers},
 	}
 }
 
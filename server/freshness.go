@@ -0,0 +1,121 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Clock tells the current time to the handler.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// WithClock makes the handler tell the time with c rather than the system clock.
+func WithClock(c Clock) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.clock = c
+	}
+}
+
+// FreshnessPolicy protects the server against replays of signed provide requests.
+// A zero value disables the corresponding check.
+type FreshnessPolicy struct {
+	// MaxClockSkew is how far in the future the timestamp of a request may be.
+	MaxClockSkew time.Duration
+	// MaxAge is how far in the past the timestamp of a request may be.
+	MaxAge time.Duration
+	// HighWaterMark rejects the requests of a peer which are not newer than the newest request accepted from it.
+	// Requests have timestamps with a resolution of a second, so at most one request of a peer is accepted per second.
+	// The handler remembers the newest timestamp of each peer for MaxAge, or for as long as it runs if MaxAge is zero.
+	HighWaterMark bool
+}
+
+// WithFreshnessPolicy makes the handler reject the provide requests which do not comply with p,
+// with client.ErrProvideFromFuture, client.ErrProvideTooOld or client.ErrProvideReplayed.
+func WithFreshnessPolicy(p FreshnessPolicy) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.freshness = p
+	}
+}
+
+// freshnessChecker enforces a FreshnessPolicy.
+type freshnessChecker struct {
+	policy FreshnessPolicy
+	clock  Clock
+
+	lk        sync.Mutex
+	newest    map[peer.ID]time.Time
+	lastPrune time.Time
+}
+
+func newFreshnessChecker(p FreshnessPolicy, clock Clock) *freshnessChecker {
+	if p == (FreshnessPolicy{}) {
+		return nil
+	}
+	return &freshnessChecker{policy: p, clock: clock, newest: map[peer.ID]time.Time{}}
+}
+
+// check returns the rejection of req, or nil if it is fresh. A fresh request claims the high-water mark of its
+// provider at once, so that concurrent replays of it are rejected; release gives the mark back if the request
+// is rejected later on, by the authorizers or the service.
+func (fc *freshnessChecker) check(req *client.ProvideRequest) (release func(), err error) {
+	release = func() {}
+	if fc == nil {
+		return release, nil
+	}
+	now := fc.clock.Now()
+	ts := time.Unix(req.Timestamp, 0)
+	if fc.policy.MaxClockSkew > 0 && ts.After(now.Add(fc.policy.MaxClockSkew)) {
+		return nil, client.ErrProvideFromFuture.WithReason(fmt.Sprintf("timestamp %v is %v ahead", ts.UTC(), ts.Sub(now)))
+	}
+	if fc.policy.MaxAge > 0 && ts.Before(now.Add(-fc.policy.MaxAge)) {
+		return nil, client.ErrProvideTooOld.WithReason(fmt.Sprintf("timestamp %v is %v old", ts.UTC(), now.Sub(ts)))
+	}
+	if !fc.policy.HighWaterMark {
+		return release, nil
+	}
+
+	fc.lk.Lock()
+	defer fc.lk.Unlock()
+	id := req.Provider.Peer.ID
+	prev, claimed := fc.newest[id]
+	if claimed && !ts.After(prev) {
+		return nil, client.ErrProvideReplayed.WithReason(fmt.Sprintf("timestamp %v is not newer than %v", ts.UTC(), prev.UTC()))
+	}
+	fc.newest[id] = ts
+	fc.prune(now)
+	return func() {
+		fc.lk.Lock()
+		defer fc.lk.Unlock()
+		// a newer request may have claimed the mark meanwhile
+		if newest, ok := fc.newest[id]; !ok || !newest.Equal(ts) {
+			return
+		}
+		if claimed {
+			fc.newest[id] = prev
+		} else {
+			delete(fc.newest, id)
+		}
+	}, nil
+}
+
+// prune forgets the high-water marks which the age check supersedes, at most once per MaxAge.
+func (fc *freshnessChecker) prune(now time.Time) {
+	if fc.policy.MaxAge <= 0 || now.Sub(fc.lastPrune) < fc.policy.MaxAge {
+		return
+	}
+	fc.lastPrune = now
+	for id, newest := range fc.newest {
+		if newest.Before(now.Add(-fc.policy.MaxAge)) {
+			delete(fc.newest, id)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestInfo collects what is learned about a request while it is served, for the request metrics.
+type requestInfo struct {
+	lk     sync.Mutex
+	method string
+	err    error
+}
+
+type requestInfoKey struct{}
+
+// setRequestMethod records the protocol method called by the request served with ctx.
+func setRequestMethod(ctx context.Context, method string) {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.lk.Lock()
+		info.method = method
+		info.lk.Unlock()
+	}
+}
+
+// setRequestError records the first error of the service calls made for the request served with ctx.
+func setRequestError(ctx context.Context, err error) {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.lk.Lock()
+		if info.err == nil {
+			info.err = err
+		}
+		info.lk.Unlock()
+	}
+}
+
+// recordRequests records the number of requests by method and error class, as well as cache hits and decode failures.
+// Requests which fail before the protocol method is known are recorded with the method "Unknown".
+func recordRequests(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		info := &requestInfo{}
+		sw := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info)))
+
+		info.lk.Lock()
+		method, err := info.method, info.err
+		info.lk.Unlock()
+		if method == "" {
+			method = "Unknown"
+		}
+		tags := []tag.Mutator{tag.Upsert(keyMethod, method)}
+
+		switch sw.status {
+		case http.StatusNotModified:
+			stats.RecordWithTags(r.Context(), tags, measureCacheHits.M(1))
+		case http.StatusBadRequest:
+			stats.RecordWithTags(r.Context(), tags, measureDecodeFailures.M(1))
+		}
+		stats.RecordWithTags(r.Context(),
+			append(tags, tag.Upsert(keyError, statusErrStr(sw.status, err))),
+			measureRequests.M(1),
+		)
+	}
+}
+
+// statusErrStr classifies the outcome of a request like client.MetricsErrStr classifies the error seen by the client.
+func statusErrStr(status int, err error) string {
+	switch {
+	case status == http.StatusBadRequest || status == http.StatusNotFound:
+		return "Schema"
+	case status == http.StatusRequestEntityTooLarge:
+		return "LimitExceeded"
+	case status == http.StatusTooManyRequests:
+		return "RateLimited"
+	case status >= 500:
+		return "Service"
+	case err != nil:
+		return client.MetricsErrStr(err)
+	}
+	return "None"
+}
+
+// instrumentedService traces and measures each call to the wrapped service, until the results of the call end.
+type instrumentedService struct {
+	DelegatedRoutingService
+}
+
+func (s instrumentedService) FindProviders(ctx context.Context, key cid.Cid) (<-chan client.FindProvidersAsyncResult, error) {
+	ctx, call := startCall(ctx, "FindProviders", attribute.Stringer("key", key))
+	ch, err := s.DelegatedRoutingService.FindProviders(ctx, key)
+	return instrumentResults(ctx, call, ch, err, func(r client.FindProvidersAsyncResult) error { return r.Err })
+}
+
+func (s instrumentedService) FindProviderRecords(ctx context.Context, key cid.Cid) (<-chan client.FindProviderRecordsAsyncResult, error) {
+	ctx, call := startCall(ctx, "FindProviders", attribute.Stringer("key", key))
+	ch, err := providerRecordsOf(s.DelegatedRoutingService).FindProviderRecords(ctx, key)
+	return instrumentResults(ctx, call, ch, err, func(r client.FindProviderRecordsAsyncResult) error { return r.Err })
+}
+
+func (s instrumentedService) FindPeer(ctx context.Context, id peer.ID) (<-chan client.FindPeerAsyncResult, error) {
+	ctx, call := startCall(ctx, "FindPeer", attribute.Stringer("peer", id))
+	ch, err := s.DelegatedRoutingService.FindPeer(ctx, id)
+	return instrumentResults(ctx, call, ch, err, func(r client.FindPeerAsyncResult) error { return r.Err })
+}
+
+func (s instrumentedService) GetIPNS(ctx context.Context, id []byte) (<-chan client.GetIPNSAsyncResult, error) {
+	ctx, call := startCall(ctx, "GetIPNS")
+	ch, err := s.DelegatedRoutingService.GetIPNS(ctx, id)
+	return instrumentResults(ctx, call, ch, err, func(r client.GetIPNSAsyncResult) error { return r.Err })
+}
+
+func (s instrumentedService) PutIPNS(ctx context.Context, id []byte, record []byte) (<-chan client.PutIPNSAsyncResult, error) {
+	ctx, call := startCall(ctx, "PutIPNS")
+	ch, err := s.DelegatedRoutingService.PutIPNS(ctx, id, record)
+	return instrumentResults(ctx, call, ch, err, func(r client.PutIPNSAsyncResult) error { return r.Err })
+}
+
+func (s instrumentedService) Provide(ctx context.Context, req *client.ProvideRequest) (<-chan client.ProvideAsyncResult, error) {
+	ctx, call := startCall(ctx, "Provide", attribute.Int("keys", len(req.Key)))
+	ch, err := s.DelegatedRoutingService.Provide(ctx, req)
+	return instrumentResults(ctx, call, ch, err, func(r client.ProvideAsyncResult) error { return r.Err })
+}
+
+// serviceCall is the span and the metrics of a call to the service.
+type serviceCall struct {
+	method  string
+	span    trace.Span
+	start   time.Time
+	results int
+	errs    int
+	err     error
+}
+
+func startCall(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, *serviceCall) {
+	ctx, span := tracer.Start(ctx, "DelegatedRoutingService."+method, trace.WithAttributes(attrs...))
+	return ctx, &serviceCall{method: method, span: span, start: time.Now()}
+}
+
+// result accounts for a result of the call, which failed if err is not nil.
+func (c *serviceCall) result(ctx context.Context, err error) {
+	if c.results == 0 {
+		// the time to first result is tagged with the error of the first result, as the outcome of the call is not known yet
+		errStr := "None"
+		if err != nil {
+			errStr = client.MetricsErrStr(err)
+		}
+		stats.RecordWithTags(ctx,
+			[]tag.Mutator{tag.Upsert(keyMethod, c.method), tag.Upsert(keyError, errStr)},
+			measureTimeToFirstResult.M(float64(time.Since(c.start).Milliseconds())),
+		)
+	}
+	c.results++
+	if err != nil {
+		c.errs++
+		c.fail(ctx, err)
+		c.span.RecordError(err)
+	}
+}
+
+// fail records err as the error of the call, unless an earlier error was recorded.
+func (c *serviceCall) fail(ctx context.Context, err error) {
+	if c.err == nil {
+		c.err = err
+		setRequestError(ctx, err)
+	}
+}
+
+// end ends the span of the call and records its metrics.
+func (c *serviceCall) end(ctx context.Context) {
+	errStr := "None"
+	if c.err != nil {
+		errStr = client.MetricsErrStr(c.err)
+		c.span.SetStatus(codes.Error, c.err.Error())
+	}
+	c.span.SetAttributes(attribute.Int("results", c.results), attribute.Int("errors", c.errs))
+	c.span.End()
+
+	stats.RecordWithTags(ctx,
+		[]tag.Mutator{tag.Upsert(keyMethod, c.method), tag.Upsert(keyError, errStr)},
+		measureStreamDuration.M(float64(time.Since(c.start).Milliseconds())),
+		measureStreamResults.M(int64(c.results)),
+	)
+}
+
+// instrumentResults forwards the results of a service call, accounting for them in call,
+// and ends call once the results end or ctx is done.
+func instrumentResults[T any](ctx context.Context, call *serviceCall, ch <-chan T, err error, errOf func(T) error) (<-chan T, error) {
+	if err != nil {
+		call.fail(ctx, err)
+		call.span.RecordError(err)
+		call.end(ctx)
+		return nil, err
+	}
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		defer call.end(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				call.fail(ctx, ctx.Err())
+				return
+			case r, ok := <-ch:
+				if !ok {
+					return
+				}
+				call.result(ctx, errOf(r))
+				select {
+				case <-ctx.Done():
+					call.fail(ctx, ctx.Err())
+					return
+				case out <- r:
+				}
+			}
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ipfs/boxo/ipns"
+	ipns_pb "github.com/ipfs/boxo/ipns/pb"
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// WithIPNSValidation makes the handler validate the records of PutIPNS requests before passing them to the service.
+// Records which do not validate against their name, expired records, and records which the Select method of the
+// IPNS validator would not prefer to the record stored by the service, that is with a lower sequence number, or
+// the same sequence number and an earlier EOL, are rejected with client.ErrIPNSInvalidRecord,
+// client.ErrIPNSExpiredRecord and client.ErrIPNSStaleRecord respectively.
+//
+// The stored record is read with GetIPNS before PutIPNS is called, so concurrent puts to the same name
+// may both pass the check. The check spares the service records it would reject, but the service must
+// still select between the stored and the new record when storing, as memory.Service and persistent.Service
+// do with the Select method of their IPNS validator.
+func WithIPNSValidation() HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.validateIPNS = true
+	}
+}
+
+// checkIPNS returns the rejection of the IPNS record rec for the name id, or nil if it may be stored.
+// It is not atomic with the following PutIPNS, see WithIPNSValidation.
+func (drs *delegatedRoutingServer) checkIPNS(ctx context.Context, id []byte, rec []byte) error {
+	if err := (ipns.Validator{}).Validate(ipns.RecordKey(peer.ID(id)), rec); err != nil {
+		if errors.Is(err, ipns.ErrExpiredRecord) {
+			return client.ErrIPNSExpiredRecord
+		}
+		return client.ErrIPNSInvalidRecord.WithReason(err.Error())
+	}
+
+	stored, err := drs.storedIPNS(ctx, id)
+	if err != nil || stored == nil {
+		return err
+	}
+	if bytes.Equal(stored, rec) {
+		return nil
+	}
+	best, err := (ipns.Validator{}).Select(ipns.RecordKey(peer.ID(id)), [][]byte{stored, rec})
+	if err != nil {
+		// a stored record which cannot be decoded does not prevent its replacement
+		logger.Infof("ignoring invalid stored ipns record (%v)", err)
+		return nil
+	}
+	if best == 1 {
+		return nil
+	}
+	var oldEntry ipns_pb.IpnsEntry
+	_ = oldEntry.Unmarshal(stored)
+	oldEOL, _ := ipns.GetEOL(&oldEntry)
+	return client.ErrIPNSStaleRecord.WithReason(fmt.Sprintf("the stored record with sequence %d, valid until %s, is preferred",
+		oldEntry.GetSequence(), oldEOL.UTC().Format(time.RFC3339)))
+}
+
+// storedIPNS returns the IPNS record stored by the service for the name id, or nil if there is none.
+func (drs *delegatedRoutingServer) storedIPNS(ctx context.Context, id []byte) ([]byte, error) {
+	ch, err := drs.uninstrumented.GetIPNS(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var stored []byte
+	for r := range ch {
+		if r.Err == nil && stored == nil {
+			stored = r.Record
+		}
+	}
+	return stored, nil
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/ipfs/go-delegated-routing/client"
+)
+
+// WithLimits makes the handler reject requests larger than l.MaxRequestBytes
+// and split results with more than l.MaxProviders providers or peers into several results.
+// The other limits apply to clients only.
+func WithLimits(l client.Limits) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.limits = l
+	}
+}
+
+// limitRequests rejects requests whose body, or query for cachable methods, exceeds l.MaxRequestBytes.
+// Rejected requests are answered with 413 Request Entity Too Large, and client.RequestLimitCode followed by
+// the limit in the Error header.
+func limitRequests(l client.Limits, next http.Handler) http.HandlerFunc {
+	if l.MaxRequestBytes <= 0 {
+		return next.ServeHTTP
+	}
+	reject := func(w http.ResponseWriter) {
+		err := &client.LimitError{Limit: "request bytes", Max: l.MaxRequestBytes}
+		logger.Infof("rejecting request (%v)", err)
+		w.Header()["Error"] = []string{client.RequestLimitCode + ": " + strconv.FormatInt(l.MaxRequestBytes, 10)}
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			if int64(len(r.URL.Query().Get("q"))) > l.MaxRequestBytes {
+				reject(w)
+				return
+			}
+		case http.MethodPost:
+			msg, err := io.ReadAll(io.LimitReader(r.Body, l.MaxRequestBytes+1))
+			if err != nil {
+				logger.Errorf("reading request body (%v)", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if int64(len(msg)) > l.MaxRequestBytes {
+				reject(w)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(msg))
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// splitList splits xs into consecutive lists of at most n elements.
+// It returns xs as the only list if n is not positive.
+func splitList[T any](xs []T, n int) [][]T {
+	if n <= 0 || len(xs) <= n {
+		return [][]T{xs}
+	}
+	lists := make([][]T, 0, (len(xs)+n-1)/n)
+	for len(xs) > n {
+		lists = append(lists, xs[:n])
+		xs = xs[n:]
+	}
+	return append(lists, xs)
+}
@@ -0,0 +1,206 @@
+// Package memory provides an in-memory implementation of server.DelegatedRoutingService.
+//
+// It stores the verified provide requests it receives, keyed by multihash and expired by their AdvisoryTTL,
+// and the IPNS records it receives, keeping the best record of each name according to the IPNS validator.
+// It is usable as a standalone router for small deployments and as a fixture in tests:
+//
+//	s := httptest.NewServer(server.DelegatedRoutingAsyncHandler(memory.NewService()))
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ipfs/boxo/ipns"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/ipfs/go-delegated-routing/server"
+	record "github.com/libp2p/go-libp2p-record"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// DefaultAdvisoryTTL is granted to provide requests which do not ask for a positive AdvisoryTTL.
+const DefaultAdvisoryTTL = client.DefaultReprovideTTL
+
+var _ server.ProviderRecordsService = (*Service)(nil)
+
+// Service is an in-memory delegated routing service. It is safe for concurrent use.
+type Service struct {
+	validator record.Validator
+
+	lk        sync.Mutex
+	providers map[string]map[peer.ID]*providerRecord // by multihash
+	ipns      map[string][]byte                      // by name
+}
+
+type providerRecord struct {
+	provider client.Provider
+	expires  time.Time
+}
+
+// NewService creates an empty in-memory service.
+func NewService() *Service {
+	return &Service{
+		validator: ipns.Validator{},
+		providers: map[string]map[peer.ID]*providerRecord{},
+		ipns:      map[string][]byte{},
+	}
+}
+
+// FindProviders returns the addresses of the unexpired providers of the multihash of key, in a single result.
+func (s *Service) FindProviders(ctx context.Context, key cid.Cid) (<-chan client.FindProvidersAsyncResult, error) {
+	provs := s.findProviders(key)
+	if len(provs) == 0 {
+		return results[client.FindProvidersAsyncResult](), nil
+	}
+	infos := make([]peer.AddrInfo, len(provs))
+	for i, prov := range provs {
+		infos[i] = prov.Peer
+	}
+	return results(client.FindProvidersAsyncResult{AddrInfo: infos}), nil
+}
+
+// FindProviderRecords returns the unexpired providers of the multihash of key, with their transfer protocols.
+func (s *Service) FindProviderRecords(ctx context.Context, key cid.Cid) (<-chan client.FindProviderRecordsAsyncResult, error) {
+	provs := s.findProviders(key)
+	if len(provs) == 0 {
+		return results[client.FindProviderRecordsAsyncResult](), nil
+	}
+	return results(client.FindProviderRecordsAsyncResult{Providers: provs}), nil
+}
+
+func (s *Service) findProviders(key cid.Cid) []client.Provider {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	now := time.Now()
+	var provs []client.Provider
+	for id, rec := range s.providers[string(key.Hash())] {
+		if now.After(rec.expires) {
+			s.removeProvider(key.Hash(), id)
+			continue
+		}
+		provs = append(provs, rec.provider)
+	}
+	return provs
+}
+
+// FindPeer returns the addresses of id announced in its unexpired provide requests.
+func (s *Service) FindPeer(ctx context.Context, id peer.ID) (<-chan client.FindPeerAsyncResult, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	now := time.Now()
+	var latest *providerRecord
+	for _, recs := range s.providers {
+		if rec, ok := recs[id]; ok && now.Before(rec.expires) && (latest == nil || rec.expires.After(latest.expires)) {
+			latest = rec
+		}
+	}
+	if latest == nil {
+		return results[client.FindPeerAsyncResult](), nil
+	}
+	return results(client.FindPeerAsyncResult{AddrInfo: []peer.AddrInfo{latest.provider.Peer}}), nil
+}
+
+// GetIPNS returns the best IPNS record stored for the name id.
+func (s *Service) GetIPNS(ctx context.Context, id []byte) (<-chan client.GetIPNSAsyncResult, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	rec, ok := s.ipns[string(id)]
+	if !ok {
+		return results[client.GetIPNSAsyncResult](), nil
+	}
+	return results(client.GetIPNSAsyncResult{Record: rec}), nil
+}
+
+// PutIPNS stores the IPNS record for the name id, after validating it.
+// The stored record is only replaced if the validator selects the new record over it.
+func (s *Service) PutIPNS(ctx context.Context, id []byte, rec []byte) (<-chan client.PutIPNSAsyncResult, error) {
+	key := ipns.RecordKey(peer.ID(id))
+	if err := s.validator.Validate(key, rec); err != nil {
+		return results(client.PutIPNSAsyncResult{Err: err}), nil
+	}
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if old, ok := s.ipns[string(id)]; ok {
+		best, err := s.validator.Select(key, [][]byte{old, rec})
+		if err != nil {
+			return results(client.PutIPNSAsyncResult{Err: err}), nil
+		}
+		if best == 0 {
+			return results(client.PutIPNSAsyncResult{}), nil
+		}
+	}
+	s.ipns[string(id)] = rec
+	return results(client.PutIPNSAsyncResult{}), nil
+}
+
+// Provide verifies the signature of req and stores its provider for each of its keys,
+// replacing earlier records of the same provider. The records expire after the granted AdvisoryTTL.
+func (s *Service) Provide(ctx context.Context, req *client.ProvideRequest) (<-chan client.ProvideAsyncResult, error) {
+	if req.Provider == nil {
+		return results(client.ProvideAsyncResult{Err: errors.New("provide request has no provider")}), nil
+	}
+	if err := req.Verify(); err != nil {
+		return results(client.ProvideAsyncResult{Err: err}), nil
+	}
+	ttl := req.AdvisoryTTL
+	if ttl <= 0 {
+		ttl = DefaultAdvisoryTTL
+	}
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	expires := time.Now().Add(ttl)
+	for _, key := range req.Key {
+		recs, ok := s.providers[string(key.Hash())]
+		if !ok {
+			recs = map[peer.ID]*providerRecord{}
+			s.providers[string(key.Hash())] = recs
+		}
+		recs[req.Provider.Peer.ID] = &providerRecord{provider: *req.Provider, expires: expires}
+	}
+	return results(client.ProvideAsyncResult{AdvisoryTTL: ttl}), nil
+}
+
+// Purge removes the expired provider records. Expired records are not returned by lookups,
+// but are only removed when their multihash is looked up. Long running services should call
+// Purge periodically to release the memory of multihashes which are not looked up.
+func (s *Service) Purge() {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	now := time.Now()
+	for mh, recs := range s.providers {
+		for id, rec := range recs {
+			if now.After(rec.expires) {
+				s.removeProvider([]byte(mh), id)
+			}
+		}
+	}
+}
+
+func (s *Service) removeProvider(mh []byte, id peer.ID) {
+	recs := s.providers[string(mh)]
+	delete(recs, id)
+	if len(recs) == 0 {
+		delete(s.providers, string(mh))
+	}
+}
+
+// results returns a closed channel holding rs.
+func results[T any](rs ...T) <-chan T {
+	ch := make(chan T, len(rs))
+	for _, r := range rs {
+		ch <- r
+	}
+	close(ch)
+	return ch
+}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/ipfs/go-delegated-routing/client"
+	proto "github.com/ipfs/go-delegated-routing/gen/proto"
+	"github.com/ipld/edelweiss/services"
+	"github.com/ipld/edelweiss/values"
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/codec/dagjson"
+	"github.com/ipld/go-ipld-prime/datamodel"
+)
+
+// SupportedMethodsService is implemented by services which support only some of the protocol methods.
+// The handler of such a service advertises only the supported methods in its Identify responses,
+// and answers requests to other methods with 404 Not Found, which clients memoize as unsupported.
+type SupportedMethodsService interface {
+	// SupportedMethods returns the names of the supported methods: "FindProviders", "FindProvidersBatch",
+	// "FindPeer", "GetIPNS", "PutIPNS" or "Provide". Identify is always supported.
+	// Building the handler of a service panics if it names other methods.
+	SupportedMethods() []string
+}
+
+// envelopeKey is the context key of the envelopeCache of a request.
+type envelopeKey struct{}
+
+// envelopeCache holds the call envelope of a request, so that the handlers which inspect it before
+// the protocol handler parse it once between them.
+type envelopeCache struct {
+	once sync.Once
+	env  *proto.AnonInductive4
+	err  error
+
+	provideOnce sync.Once
+	provide     *client.ProvideRequest
+	provideErr  error
+}
+
+// cacheEnvelopes lets the handlers after it share the call envelope returned by parseEnvelope.
+func cacheEnvelopes(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), envelopeKey{}, &envelopeCache{})))
+	}
+}
+
+// parseEnvelope returns the call envelope of a request, parsed like the protocol handler, leaving the request body unread.
+// Requests served behind cacheEnvelopes are parsed at most once.
+func parseEnvelope(r *http.Request) (*proto.AnonInductive4, error) {
+	c, ok := r.Context().Value(envelopeKey{}).(*envelopeCache)
+	if !ok {
+		return decodeEnvelope(r)
+	}
+	c.once.Do(func() { c.env, c.err = decodeEnvelope(r) })
+	return c.env, c.err
+}
+
+// parseProvideRequest parses and verifies the provide request req of the request served with ctx.
+// Requests served behind cacheEnvelopes are verified at most once, so that KeyByProvider does not add a verification.
+func parseProvideRequest(ctx context.Context, req *proto.ProvideRequest) (*client.ProvideRequest, error) {
+	c, ok := ctx.Value(envelopeKey{}).(*envelopeCache)
+	if !ok {
+		return client.ParseProvideRequest(req)
+	}
+	c.provideOnce.Do(func() { c.provide, c.provideErr = client.ParseProvideRequest(req) })
+	return c.provide, c.provideErr
+}
+
+// decodeEnvelope parses the call envelope of a request.
+// Cachable calls are sent with GET and a DAG-CBOR query, other calls with POST and a DAG-JSON body.
+func decodeEnvelope(r *http.Request) (*proto.AnonInductive4, error) {
+	var n datamodel.Node
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		n, err = ipld.Decode([]byte(r.URL.Query().Get("q")), dagcbor.Decode)
+	case http.MethodPost:
+		var msg []byte
+		msg, err = io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(msg))
+		if err != nil {
+			return nil, err
+		}
+		n, err = ipld.Decode(msg, dagjson.Decode)
+	default:
+		return nil, errors.New("http method not supported")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var env proto.AnonInductive4
+	if err := env.Parse(n); err != nil {
+		return nil, err
+	}
+	return &env, nil
+}
+
+// envelopeMethod returns the name of the method called by env.
+func envelopeMethod(env *proto.AnonInductive4) string {
+	switch {
+	case env.Identify != nil:
+		return "Identify"
+	case env.FindProviders != nil:
+		return "FindProviders"
+	case env.FindProvidersBatch != nil:
+		return "FindProvidersBatch"
+	case env.FindPeer != nil:
+		return "FindPeer"
+	case env.GetIPNS != nil:
+		return "GetIPNS"
+	case env.PutIPNS != nil:
+		return "PutIPNS"
+	case env.Provide != nil:
+		return "Provide"
+	}
+	return ""
+}
+
+// protocolMethods are the methods a SupportedMethodsService may support.
+var protocolMethods = map[string]bool{
+	"FindProviders":      true,
+	"FindProvidersBatch": true,
+	"FindPeer":           true,
+	"GetIPNS":            true,
+	"PutIPNS":            true,
+	"Provide":            true,
+}
+
+// restrictMethods answers Identify requests with the methods supported by svc, and requests to other methods
+// with 404 Not Found, if svc is a SupportedMethodsService. Requests which cannot be parsed are left to next.
+// It panics if svc names a method which is not a protocol method.
+func restrictMethods(svc DelegatedRoutingService, next http.Handler) http.HandlerFunc {
+	sms, ok := svc.(SupportedMethodsService)
+	if !ok {
+		return next.ServeHTTP
+	}
+	supported := map[string]bool{}
+	identify := &proto.DelegatedRouting_IdentifyResult{Methods: []values.String{}}
+	for _, method := range sms.SupportedMethods() {
+		if !protocolMethods[method] {
+			panic(fmt.Sprintf("unknown delegated routing method %q in SupportedMethods", method))
+		}
+		if !supported[method] {
+			supported[method] = true
+			identify.Methods = append(identify.Methods, values.String(method))
+		}
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		env, err := parseEnvelope(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		switch method := envelopeMethod(env); {
+		case method == "Identify":
+			setRequestMethod(r.Context(), method)
+			writeIdentify(w, r, identify)
+		case !supported[method]:
+			setRequestMethod(r.Context(), method)
+			logger.Infof("rejecting request to unsupported method %s", method)
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	}
+}
+
+// writeIdentify writes an Identify response like the protocol handler, with an ETag.
+func writeIdentify(w http.ResponseWriter, r *http.Request, identify *proto.DelegatedRouting_IdentifyResult) {
+	var buf bytes.Buffer
+	if err := ipld.EncodeStreaming(&buf, &proto.AnonInductive5{Identify: identify}, dagjson.Encode); err != nil {
+		logger.Errorf("cannot encode identify response (%v)", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	buf.WriteByte('\n')
+	result := buf.Bytes()
+	etag, err := services.ETag(result)
+	if err != nil {
+		logger.Errorf("etag generation (%v)", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header()["Content-Type"] = []string{client.MediaTypeDagJSON + "; version=1"}
+	if inm := r.Header["If-None-Match"]; len(inm) == 1 && inm[0] == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header()["ETag"] = []string{etag}
+	w.Write(result)
+}
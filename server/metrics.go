@@ -0,0 +1,78 @@
+package server
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var (
+	defaultDurationDistribution = view.Distribution(0, 1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000, 20000)
+	defaultCountDistribution    = view.Distribution(0, 1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000)
+
+	measureRequests          = stats.Int64("delegated_routing/server/requests", "The number of requests served", stats.UnitDimensionless)
+	measureTimeToFirstResult = stats.Float64("delegated_routing/server/time_to_first_result", "The time from a service call to its first result", stats.UnitMilliseconds)
+	measureStreamDuration    = stats.Float64("delegated_routing/server/stream_duration", "The time from a service call to the end of its results", stats.UnitMilliseconds)
+	measureStreamResults     = stats.Int64("delegated_routing/server/stream_results", "The number of results of a service call", stats.UnitDimensionless)
+	measureCacheHits         = stats.Int64("delegated_routing/server/cache_hits", "The number of cachable requests answered with 304 Not Modified", stats.UnitDimensionless)
+	measureDecodeFailures    = stats.Int64("delegated_routing/server/decode_failures", "The number of requests which could not be decoded", stats.UnitDimensionless)
+
+	measureUncompressedBytes = stats.Int64("delegated_routing/server/uncompressed_bytes", "The number of response bytes written before compression", stats.UnitBytes)
+	measureCompressedBytes   = stats.Int64("delegated_routing/server/compressed_bytes", "The number of response bytes sent after compression", stats.UnitBytes)
+
+	keyMethod   = tag.MustNewKey("method")
+	keyError    = tag.MustNewKey("error")
+	keyEncoding = tag.MustNewKey("encoding")
+
+	requestsView = &view.View{
+		Measure:     measureRequests,
+		TagKeys:     []tag.Key{keyMethod, keyError},
+		Aggregation: view.Sum(),
+	}
+	timeToFirstResultView = &view.View{
+		Measure:     measureTimeToFirstResult,
+		TagKeys:     []tag.Key{keyMethod, keyError},
+		Aggregation: defaultDurationDistribution,
+	}
+	streamDurationView = &view.View{
+		Measure:     measureStreamDuration,
+		TagKeys:     []tag.Key{keyMethod, keyError},
+		Aggregation: defaultDurationDistribution,
+	}
+	streamResultsView = &view.View{
+		Measure:     measureStreamResults,
+		TagKeys:     []tag.Key{keyMethod, keyError},
+		Aggregation: defaultCountDistribution,
+	}
+	cacheHitsView = &view.View{
+		Measure:     measureCacheHits,
+		TagKeys:     []tag.Key{keyMethod},
+		Aggregation: view.Sum(),
+	}
+	decodeFailuresView = &view.View{
+		Measure:     measureDecodeFailures,
+		TagKeys:     []tag.Key{keyMethod},
+		Aggregation: view.Sum(),
+	}
+	uncompressedBytesView = &view.View{
+		Measure:     measureUncompressedBytes,
+		TagKeys:     []tag.Key{keyEncoding},
+		Aggregation: view.Sum(),
+	}
+	compressedBytesView = &view.View{
+		Measure:     measureCompressedBytes,
+		TagKeys:     []tag.Key{keyEncoding},
+		Aggregation: view.Sum(),
+	}
+
+	DefaultViews = []*view.View{
+		requestsView,
+		timeToFirstResultView,
+		streamDurationView,
+		streamResultsView,
+		cacheHitsView,
+		decodeFailuresView,
+		uncompressedBytesView,
+		compressedBytesView,
+	}
+)
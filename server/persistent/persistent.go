@@ -0,0 +1,455 @@
+// Package persistent provides a server.DelegatedRoutingService which keeps its state in a datastore,
+// so that provider records and IPNS records survive restarts.
+//
+// Provider records are indexed by multihash and by peer ID. The addresses and transfer protocols of a provider
+// are stored once per peer, and are replaced whenever the peer sends a newer signed provide request.
+// Expired provider records are garbage collected in the background once the service is started:
+//
+//	svc := persistent.NewService(dssync.MutexWrap(ds), persistent.WithMaxAdvisoryTTL(12*time.Hour))
+//	svc.Start()
+//	defer svc.Close()
+//	handler := server.DelegatedRoutingAsyncHandler(svc)
+package persistent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ipfs/boxo/ipns"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/ipfs/go-delegated-routing/server"
+	logging "github.com/ipfs/go-log/v2"
+	record "github.com/libp2p/go-libp2p-record"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multihash"
+)
+
+var logger = logging.Logger("service/server/delegatedrouting/persistent")
+
+const (
+	// DefaultMaxAdvisoryTTL is the default maximum AdvisoryTTL granted to provide requests.
+	// Requests which do not ask for a positive AdvisoryTTL are granted the maximum.
+	DefaultMaxAdvisoryTTL = 24 * time.Hour
+	// DefaultGCInterval is the default interval at which expired provider records are garbage collected.
+	DefaultGCInterval = time.Hour
+
+	// gcBatchSize is the number of expired provider records deleted at once, while writes are held.
+	gcBatchSize = 1000
+)
+
+// Option configures optional behavior of a Service.
+type Option func(*Service)
+
+// WithMaxAdvisoryTTL sets the maximum AdvisoryTTL granted to provide requests.
+func WithMaxAdvisoryTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		if ttl > 0 {
+			s.maxTTL = ttl
+		}
+	}
+}
+
+// WithGCInterval sets the interval at which expired provider records are garbage collected.
+func WithGCInterval(d time.Duration) Option {
+	return func(s *Service) {
+		if d > 0 {
+			s.gcInterval = d
+		}
+	}
+}
+
+var _ server.ProviderRecordsService = (*Service)(nil)
+
+// Service is a delegated routing service backed by a datastore, which must be safe for concurrent use.
+type Service struct {
+	ds         datastore.Batching
+	validator  record.Validator
+	maxTTL     time.Duration
+	gcInterval time.Duration
+
+	lk sync.Mutex // serializes writes
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewService creates a service storing its state in ds. Call Start to garbage collect expired records in the background.
+func NewService(ds datastore.Batching, opts ...Option) *Service {
+	s := &Service{
+		ds:         ds,
+		validator:  ipns.Validator{},
+		maxTTL:     DefaultMaxAdvisoryTTL,
+		gcInterval: DefaultGCInterval,
+		done:       make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// Start begins garbage collecting expired provider records in the background.
+func (s *Service) Start() {
+	s.startOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+		go s.run(ctx)
+	})
+}
+
+// Close stops garbage collection and waits for an ongoing collection to return.
+func (s *Service) Close() {
+	s.closeOnce.Do(func() {
+		s.startOnce.Do(func() { close(s.done) })
+		if s.cancel != nil {
+			s.cancel()
+			<-s.done
+		}
+	})
+}
+
+func (s *Service) run(ctx context.Context) {
+	defer close(s.done)
+	ticker := time.NewTicker(s.gcInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.GC(ctx); err != nil && ctx.Err() == nil {
+			logger.Errorf("cannot garbage collect provider records (%v)", err)
+		}
+	}
+}
+
+// FindProviders returns the unexpired providers of the multihash of key, in a single result.
+func (s *Service) FindProviders(ctx context.Context, key cid.Cid) (<-chan client.FindProvidersAsyncResult, error) {
+	provs, err := s.findProviders(ctx, key.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if len(provs) == 0 {
+		return results[client.FindProvidersAsyncResult](), nil
+	}
+	infos := make([]peer.AddrInfo, len(provs))
+	for i, prov := range provs {
+		infos[i] = prov.Peer
+	}
+	return results(client.FindProvidersAsyncResult{AddrInfo: infos}), nil
+}
+
+// FindProviderRecords returns the unexpired providers of the multihash of key, with their transfer protocols.
+func (s *Service) FindProviderRecords(ctx context.Context, key cid.Cid) (<-chan client.FindProviderRecordsAsyncResult, error) {
+	provs, err := s.findProviders(ctx, key.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if len(provs) == 0 {
+		return results[client.FindProviderRecordsAsyncResult](), nil
+	}
+	return results(client.FindProviderRecordsAsyncResult{Providers: provs}), nil
+}
+
+func (s *Service) findProviders(ctx context.Context, mh multihash.Multihash) ([]client.Provider, error) {
+	res, err := s.ds.Query(ctx, query.Query{Prefix: providersPrefix.ChildString(mh.B58String()).String()})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	now := time.Now().UnixNano()
+	var provs []client.Provider
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		id, err := peer.Decode(datastore.RawKey(r.Key).BaseNamespace())
+		if err != nil {
+			logger.Infof("ignoring provider record with invalid key %s (%v)", r.Key, err)
+			continue
+		}
+		rec, err := decodeProviderRecord(r.Value)
+		if err != nil {
+			logger.Infof("ignoring invalid provider record %s (%v)", r.Key, err)
+			continue
+		}
+		if rec.Expires < now {
+			continue
+		}
+		prec, err := s.getPeerRecord(ctx, id)
+		if err != nil {
+			if errors.Is(err, datastore.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		provs = append(provs, prec.provider(id))
+	}
+	return provs, nil
+}
+
+// FindPeer returns the addresses of id announced in its latest provide request, as long as it provides a key.
+func (s *Service) FindPeer(ctx context.Context, id peer.ID) (<-chan client.FindPeerAsyncResult, error) {
+	rec, err := s.getPeerRecord(ctx, id)
+	if errors.Is(err, datastore.ErrNotFound) {
+		return results[client.FindPeerAsyncResult](), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results(client.FindPeerAsyncResult{AddrInfo: []peer.AddrInfo{rec.provider(id).Peer}}), nil
+}
+
+func (s *Service) getPeerRecord(ctx context.Context, id peer.ID) (*peerRecord, error) {
+	value, err := s.ds.Get(ctx, peerKey(id))
+	if err != nil {
+		return nil, err
+	}
+	return decodePeerRecord(value)
+}
+
+// GetIPNS returns the IPNS record stored for the name id.
+func (s *Service) GetIPNS(ctx context.Context, id []byte) (<-chan client.GetIPNSAsyncResult, error) {
+	value, err := s.ds.Get(ctx, ipnsKey(peer.ID(id)))
+	if errors.Is(err, datastore.ErrNotFound) {
+		return results[client.GetIPNSAsyncResult](), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results(client.GetIPNSAsyncResult{Record: value}), nil
+}
+
+// PutIPNS stores the IPNS record for the name id, after validating it.
+// The stored record is only replaced if the validator selects the new record over it.
+func (s *Service) PutIPNS(ctx context.Context, id []byte, rec []byte) (<-chan client.PutIPNSAsyncResult, error) {
+	key := ipns.RecordKey(peer.ID(id))
+	if err := s.validator.Validate(key, rec); err != nil {
+		return results(client.PutIPNSAsyncResult{Err: err}), nil
+	}
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	dsKey := ipnsKey(peer.ID(id))
+	old, err := s.ds.Get(ctx, dsKey)
+	switch {
+	case errors.Is(err, datastore.ErrNotFound):
+	case err != nil:
+		return nil, err
+	default:
+		best, err := s.validator.Select(key, [][]byte{old, rec})
+		if err != nil {
+			return results(client.PutIPNSAsyncResult{Err: err}), nil
+		}
+		if best == 0 {
+			return results(client.PutIPNSAsyncResult{}), nil
+		}
+	}
+	if err := s.ds.Put(ctx, dsKey, rec); err != nil {
+		return nil, err
+	}
+	return results(client.PutIPNSAsyncResult{}), nil
+}
+
+// Provide verifies the signature of req and records its provider for each of its keys, until the granted AdvisoryTTL
+// expires. The addresses and transfer protocols of the provider, and the expiry of each key, replace the stored ones,
+// unless those come from a provide request with a later timestamp.
+func (s *Service) Provide(ctx context.Context, req *client.ProvideRequest) (<-chan client.ProvideAsyncResult, error) {
+	if req.Provider == nil {
+		return results(client.ProvideAsyncResult{Err: errors.New("provide request has no provider")}), nil
+	}
+	if err := req.Verify(); err != nil {
+		return results(client.ProvideAsyncResult{Err: err}), nil
+	}
+	ttl := req.AdvisoryTTL
+	if ttl <= 0 || ttl > s.maxTTL {
+		ttl = s.maxTTL
+	}
+	id := req.Provider.Peer.ID
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	old, err := s.getPeerRecord(ctx, id)
+	if err != nil && !errors.Is(err, datastore.ErrNotFound) {
+		return nil, err
+	}
+	if old == nil || req.Timestamp >= old.Timestamp {
+		value, err := encodePeerRecord(newPeerRecord(req))
+		if err != nil {
+			return nil, err
+		}
+		if err := batch.Put(ctx, peerKey(id), value); err != nil {
+			return nil, err
+		}
+	}
+	value, err := encodeProviderRecord(&providerRecord{Expires: time.Now().Add(ttl).UnixNano(), Timestamp: &req.Timestamp})
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range req.Key {
+		stored, err := s.ds.Get(ctx, providerKey(key.Hash(), id))
+		switch {
+		case errors.Is(err, datastore.ErrNotFound):
+		case err != nil:
+			return nil, err
+		default:
+			if rec, err := decodeProviderRecord(stored); err == nil && rec.Timestamp != nil && *rec.Timestamp > req.Timestamp {
+				continue
+			}
+		}
+		if err := batch.Put(ctx, providerKey(key.Hash(), id), value); err != nil {
+			return nil, err
+		}
+		if err := batch.Put(ctx, peerKeyKey(id, key.Hash()), []byte{}); err != nil {
+			return nil, err
+		}
+	}
+	if err := batch.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return results(client.ProvideAsyncResult{AdvisoryTTL: ttl}), nil
+}
+
+// GC deletes the expired provider records, and the records of peers which no longer provide any key.
+// It is called periodically once the service is started. The expired records are found without holding writes,
+// which are then held while each batch of them is deleted.
+func (s *Service) GC(ctx context.Context) error {
+	res, err := s.ds.Query(ctx, query.Query{Prefix: providersPrefix.String()})
+	if err != nil {
+		return err
+	}
+	var expired []expiredRecord
+	now := time.Now().UnixNano()
+	for r := range res.Next() {
+		if r.Error != nil {
+			res.Close()
+			return r.Error
+		}
+		rec, err := decodeProviderRecord(r.Value)
+		if err != nil || rec.Expires >= now {
+			continue
+		}
+		ns := datastore.RawKey(r.Key).Namespaces()
+		if len(ns) < 2 {
+			continue
+		}
+		mh, err := multihash.FromB58String(ns[len(ns)-2])
+		if err != nil {
+			continue
+		}
+		id, err := peer.Decode(ns[len(ns)-1])
+		if err != nil {
+			continue
+		}
+		expired = append(expired, expiredRecord{mh: mh, id: id})
+	}
+	res.Close()
+
+	collected := 0
+	for len(expired) > 0 {
+		n := len(expired)
+		if n > gcBatchSize {
+			n = gcBatchSize
+		}
+		deleted, err := s.collect(ctx, expired[:n], now)
+		collected += deleted
+		if err != nil {
+			return err
+		}
+		expired = expired[n:]
+	}
+	if collected > 0 {
+		logger.Infof("garbage collected %d expired provider records", collected)
+	}
+	return nil
+}
+
+// expiredRecord identifies a provider record found expired by GC.
+type expiredRecord struct {
+	mh multihash.Multihash
+	id peer.ID
+}
+
+// collect deletes the records which are still expired at now, since a provide request may have renewed them
+// after they were found, and the records of their peers if they no longer provide any key.
+// It returns the number of provider records deleted.
+func (s *Service) collect(ctx context.Context, expired []expiredRecord, now int64) (int, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	peers := map[peer.ID]struct{}{}
+	for _, e := range expired {
+		value, err := s.ds.Get(ctx, providerKey(e.mh, e.id))
+		switch {
+		case errors.Is(err, datastore.ErrNotFound):
+			continue
+		case err != nil:
+			return 0, err
+		}
+		if rec, err := decodeProviderRecord(value); err == nil && rec.Expires >= now {
+			continue
+		}
+		if err := batch.Delete(ctx, providerKey(e.mh, e.id)); err != nil {
+			return 0, err
+		}
+		if err := batch.Delete(ctx, peerKeyKey(e.id, e.mh)); err != nil {
+			return 0, err
+		}
+		deleted++
+		peers[e.id] = struct{}{}
+	}
+	if err := batch.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	batch, err = s.ds.Batch(ctx)
+	if err != nil {
+		return deleted, err
+	}
+	for id := range peers {
+		res, err := s.ds.Query(ctx, query.Query{Prefix: peerKeysPrefix.ChildString(id.String()).String(), KeysOnly: true, Limit: 1})
+		if err != nil {
+			return deleted, err
+		}
+		rest, err := res.Rest()
+		if err != nil {
+			return deleted, err
+		}
+		if len(rest) == 0 {
+			if err := batch.Delete(ctx, peerKey(id)); err != nil {
+				return deleted, err
+			}
+		}
+	}
+	return deleted, batch.Commit(ctx)
+}
+
+// results returns a closed channel holding rs.
+func results[T any](rs ...T) <-chan T {
+	ch := make(chan T, len(rs))
+	for _, r := range rs {
+		ch <- r
+	}
+	close(ch)
+	return ch
+}
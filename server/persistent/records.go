@@ -0,0 +1,131 @@
+package persistent
+
+import (
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-multihash"
+)
+
+var (
+	// providersPrefix holds a providerRecord for each provider of a multihash, at /<multihash>/<peer ID>.
+	providersPrefix = datastore.NewKey("/delegated-routing/providers")
+	// peerKeysPrefix indexes the multihashes provided by a peer, at /<peer ID>/<multihash>.
+	peerKeysPrefix = datastore.NewKey("/delegated-routing/peer-keys")
+	// peersPrefix holds the peerRecord of each provider, at /<peer ID>.
+	peersPrefix = datastore.NewKey("/delegated-routing/peers")
+	// ipnsPrefix holds the IPNS record of each name, at /<peer ID>.
+	ipnsPrefix = datastore.NewKey("/delegated-routing/ipns")
+)
+
+func providerKey(mh multihash.Multihash, id peer.ID) datastore.Key {
+	return providersPrefix.ChildString(mh.B58String()).ChildString(id.String())
+}
+
+func peerKeyKey(id peer.ID, mh multihash.Multihash) datastore.Key {
+	return peerKeysPrefix.ChildString(id.String()).ChildString(mh.B58String())
+}
+
+func peerKey(id peer.ID) datastore.Key {
+	return peersPrefix.ChildString(id.String())
+}
+
+func ipnsKey(id peer.ID) datastore.Key {
+	return ipnsPrefix.ChildString(id.String())
+}
+
+// providerRecord is the persisted state of a provider of a multihash.
+type providerRecord struct {
+	Expires   int64  // unix nanoseconds
+	Timestamp *int64 // the timestamp of the signed provide request, nil in records stored without it
+}
+
+// peerRecord is the persisted state of a provider, taken from its latest provide request.
+type peerRecord struct {
+	Timestamp int64 // the timestamp of the signed provide request
+	Addrs     [][]byte
+	Protocols []transferProtocolRecord
+}
+
+type transferProtocolRecord struct {
+	Codec   int64
+	Payload []byte
+}
+
+var recordsSchema, recordsSchemaErr = ipld.LoadSchemaBytes([]byte(`
+		type ProviderRecord struct {
+			Expires   Int
+			Timestamp optional Int
+		}
+		type PeerRecord struct {
+			Timestamp Int
+			Addrs     [Bytes]
+			Protocols [TransferProtocol]
+		}
+		type TransferProtocol struct {
+			Codec   Int
+			Payload Bytes
+		}
+	`))
+
+func init() {
+	if recordsSchemaErr != nil {
+		panic(recordsSchemaErr)
+	}
+}
+
+func encodeProviderRecord(rec *providerRecord) ([]byte, error) {
+	return ipld.Marshal(dagcbor.Encode, rec, recordsSchema.TypeByName("ProviderRecord"))
+}
+
+func decodeProviderRecord(value []byte) (*providerRecord, error) {
+	var rec providerRecord
+	if _, err := ipld.Unmarshal(value, dagcbor.Decode, &rec, recordsSchema.TypeByName("ProviderRecord")); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func encodePeerRecord(rec *peerRecord) ([]byte, error) {
+	return ipld.Marshal(dagcbor.Encode, rec, recordsSchema.TypeByName("PeerRecord"))
+}
+
+func decodePeerRecord(value []byte) (*peerRecord, error) {
+	var rec peerRecord
+	if _, err := ipld.Unmarshal(value, dagcbor.Decode, &rec, recordsSchema.TypeByName("PeerRecord")); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func newPeerRecord(req *client.ProvideRequest) *peerRecord {
+	rec := &peerRecord{Timestamp: req.Timestamp, Addrs: [][]byte{}, Protocols: []transferProtocolRecord{}}
+	for _, addr := range req.Provider.Peer.Addrs {
+		rec.Addrs = append(rec.Addrs, addr.Bytes())
+	}
+	for _, tp := range req.Provider.ProviderProto {
+		rec.Protocols = append(rec.Protocols, transferProtocolRecord{Codec: int64(tp.Codec), Payload: tp.Payload})
+	}
+	return rec
+}
+
+// provider returns the provider with the given ID described by rec.
+func (rec *peerRecord) provider(id peer.ID) client.Provider {
+	prov := client.Provider{Peer: peer.AddrInfo{ID: id}, ProviderProto: []client.TransferProtocol{}}
+	for _, b := range rec.Addrs {
+		addr, err := multiaddr.NewMultiaddrBytes(b)
+		if err != nil {
+			logger.Infof("ignoring invalid stored address of peer %v (%v)", id, err)
+			continue
+		}
+		prov.Peer.Addrs = append(prov.Peer.Addrs, addr)
+	}
+	for _, tp := range rec.Protocols {
+		prov.ProviderProto = append(prov.ProviderProto, client.TransferProtocol{Codec: multicodec.Code(tp.Codec), Payload: tp.Payload})
+	}
+	return prov
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multicodec"
+)
+
+// ProviderRecordsService is a DelegatedRoutingService which finds providers along with their transfer protocols.
+// The handler answers FindProviders requests with FindProviderRecords, and sends the transfer protocols of each
+// provider as they are. The providers found by the FindProviders method of other services are sent as Bitswap providers.
+type ProviderRecordsService interface {
+	DelegatedRoutingService
+	FindProviderRecords(ctx context.Context, key cid.Cid) (<-chan client.FindProviderRecordsAsyncResult, error)
+}
+
+// providerRecordsOf returns svc as a ProviderRecordsService, adapting it if it only finds provider addresses.
+func providerRecordsOf(svc DelegatedRoutingService) ProviderRecordsService {
+	if prs, ok := svc.(ProviderRecordsService); ok {
+		return prs
+	}
+	return bitswapProviders{svc}
+}
+
+// bitswapProviders adapts a service which only finds the addresses of providers, advertising them as Bitswap providers.
+type bitswapProviders struct {
+	DelegatedRoutingService
+}
+
+func (s bitswapProviders) FindProviderRecords(ctx context.Context, key cid.Cid) (<-chan client.FindProviderRecordsAsyncResult, error) {
+	ch, err := s.FindProviders(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan client.FindProviderRecordsAsyncResult)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case x, ok := <-ch:
+				if !ok {
+					return
+				}
+				r := client.FindProviderRecordsAsyncResult{Err: x.Err}
+				if x.Err == nil {
+					r.Providers = bitswapProvidersOf(x.AddrInfo)
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case out <- r:
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func bitswapProvidersOf(infos []peer.AddrInfo) []client.Provider {
+	provs := make([]client.Provider, len(infos))
+	for i, info := range infos {
+		provs[i] = client.Provider{
+			Peer:          info,
+			ProviderProto: []client.TransferProtocol{{Codec: multicodec.TransportBitswap}},
+		}
+	}
+	return provs
+}
@@ -0,0 +1,269 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-delegated-routing/client"
+)
+
+// RateLimitKey returns the key of the token bucket charged for a request.
+// Requests with an empty key are not limited. It may be called more than once for a request.
+type RateLimitKey func(r *http.Request) string
+
+// bodyKey is returned by the keys which depend on the request body when they are called before the body is read,
+// so that the buckets of the other keys are checked first.
+const bodyKey = "\x00body"
+
+// beforeBodyKey is the context key which marks the requests whose body is not read yet.
+type beforeBodyKey struct{}
+
+// pendingChargeKey is the context key which marks the requests whose buckets are charged once their body is read.
+type pendingChargeKey struct{}
+
+// KeyByRemoteIP keys requests by the IP address of the remote end of the connection.
+func KeyByRemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "ip/" + r.RemoteAddr
+	}
+	return "ip/" + host
+}
+
+// KeyByHeader keys requests by the value of the given header, such as an API key.
+// Requests without the header are not limited.
+func KeyByHeader(name string) RateLimitKey {
+	return func(r *http.Request) string {
+		value := r.Header.Get(name)
+		if value == "" {
+			return ""
+		}
+		return "header/" + value
+	}
+}
+
+// KeyByProvider keys Provide requests with a valid signature by the peer ID of their provider,
+// and other requests with fallback. The signature is verified once for the request, along with its handling,
+// after the buckets of the other limits, which do not depend on the request body, have been checked.
+func KeyByProvider(fallback RateLimitKey) RateLimitKey {
+	return func(r *http.Request) string {
+		if r.Method == http.MethodPost && r.Context().Value(beforeBodyKey{}) != nil {
+			return bodyKey
+		}
+		if id, ok := signedProvider(r); ok {
+			return "peer/" + id
+		}
+		return fallback(r)
+	}
+}
+
+// signedProvider returns the provider of a Provide request with a valid signature, leaving the request body unread.
+func signedProvider(r *http.Request) (string, bool) {
+	if r.Method != http.MethodPost {
+		return "", false
+	}
+	env, err := parseEnvelope(r)
+	if err != nil || env.Provide == nil {
+		return "", false
+	}
+	req, err := parseProvideRequest(r.Context(), env.Provide)
+	if err != nil {
+		return "", false
+	}
+	return req.Provider.Peer.ID.String(), true
+}
+
+// RateLimit configures token buckets which throttle the requests of each client.
+// Every request charges a token from the bucket of its key. Requests finding their bucket empty
+// are answered with 429 Too Many Requests and a Retry-After header.
+//
+// With several limits, a request charges the buckets of all of them, or none if any of them is empty.
+// The buckets of the keys which do not depend on the request body, such as KeyByRemoteIP and KeyByHeader,
+// are checked before the body is read, so that throttled requests are not parsed or verified.
+type RateLimit struct {
+	// Rate is the number of tokens added to each bucket per second.
+	Rate float64
+	// Burst is the capacity of each bucket.
+	Burst int
+	// Key selects the bucket charged for a request.
+	Key RateLimitKey
+}
+
+// WithRateLimit makes the handler throttle requests according to l. When the option is given several times,
+// requests are throttled by each limit, e.g. by remote IP and by API key.
+// It panics if the Rate of l is not positive and finite, its Burst is less than 1 or it has no Key.
+func WithRateLimit(l RateLimit) HandlerOption {
+	switch {
+	case !(l.Rate > 0) || math.IsInf(l.Rate, 1):
+		panic(fmt.Sprintf("rate limit rate must be positive and finite, got %v", l.Rate))
+	case l.Burst < 1:
+		panic(fmt.Sprintf("rate limit burst must be at least 1, got %d", l.Burst))
+	case l.Key == nil:
+		panic("rate limit key must not be nil")
+	}
+	return func(cfg *handlerConfig) {
+		cfg.rateLimits = append(cfg.rateLimits, l)
+	}
+}
+
+// tokenBuckets holds the buckets of a RateLimit.
+type tokenBuckets struct {
+	limit RateLimit
+	clock Clock
+
+	lk        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// bucket returns the bucket of key, refilled until now. tb.lk must be held.
+func (tb *tokenBuckets) bucket(key string, now time.Time) *tokenBucket {
+	b, ok := tb.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(tb.limit.Burst), last: now}
+		tb.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(tb.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*tb.limit.Rate)
+	b.last = now
+	return b
+}
+
+// prune forgets the buckets which have refilled, at most once per refill period.
+func (tb *tokenBuckets) prune(now time.Time) {
+	refill := time.Duration(float64(tb.limit.Burst) / tb.limit.Rate * float64(time.Second))
+	if now.Sub(tb.lastPrune) < refill {
+		return
+	}
+	tb.lastPrune = now
+	for key, b := range tb.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(tb.buckets, key)
+		}
+	}
+}
+
+// rateLimiter throttles requests by the buckets of several limits.
+type rateLimiter struct {
+	clock   Clock
+	buckets []*tokenBuckets
+}
+
+// newRateLimiter returns the limiter of limits, or nil if there are none.
+func newRateLimiter(limits []RateLimit, clock Clock) *rateLimiter {
+	if len(limits) == 0 {
+		return nil
+	}
+	rl := &rateLimiter{clock: clock, buckets: make([]*tokenBuckets, len(limits))}
+	for i, l := range limits {
+		rl.buckets[i] = &tokenBuckets{limit: l, clock: clock, buckets: map[string]*tokenBucket{}}
+	}
+	return rl
+}
+
+// keys returns the key of each limit for r.
+func (rl *rateLimiter) keys(r *http.Request) []string {
+	keys := make([]string, len(rl.buckets))
+	for i, tb := range rl.buckets {
+		keys[i] = tb.limit.Key(r)
+	}
+	return keys
+}
+
+// take charges a token from the bucket of each key, given by limit, if none of them is empty, and returns true.
+// Empty keys and bodyKey are skipped. Otherwise no bucket is charged, and take returns false, the key of the
+// bucket which is empty for the longest time and that time. If charge is false, the buckets are only checked.
+func (rl *rateLimiter) take(keys []string, charge bool) (bool, string, time.Duration) {
+	now := rl.clock.Now()
+	// the buckets are locked in the same order by every request
+	for _, tb := range rl.buckets {
+		tb.lk.Lock()
+		defer tb.lk.Unlock()
+	}
+	var throttled string
+	var wait time.Duration
+	buckets := make([]*tokenBucket, 0, len(keys))
+	for i, key := range keys {
+		if key == "" || key == bodyKey {
+			continue
+		}
+		tb := rl.buckets[i]
+		tb.prune(now)
+		b := tb.bucket(key, now)
+		buckets = append(buckets, b)
+		if b.tokens < 1 {
+			if w := time.Duration((1 - b.tokens) / tb.limit.Rate * float64(time.Second)); w > wait {
+				throttled, wait = key, w
+			}
+		}
+	}
+	if throttled != "" {
+		return false, throttled, wait
+	}
+	if charge {
+		for _, b := range buckets {
+			b.tokens--
+		}
+	}
+	return true, "", 0
+}
+
+// beforeBody throttles requests by the limits whose keys do not depend on the request body, before it is read.
+// Requests which are also limited by keys depending on the body are charged by afterBody.
+func (rl *rateLimiter) beforeBody(next http.Handler) http.HandlerFunc {
+	if rl == nil {
+		return next.ServeHTTP
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys := rl.keys(r.WithContext(context.WithValue(r.Context(), beforeBodyKey{}, true)))
+		pending := false
+		for _, key := range keys {
+			pending = pending || key == bodyKey
+		}
+		if ok, key, wait := rl.take(keys, !pending); !ok {
+			throttle(w, key, wait)
+			return
+		}
+		if pending {
+			r = r.WithContext(context.WithValue(r.Context(), pendingChargeKey{}, true))
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// afterBody charges the requests left to it by beforeBody, once their body can be parsed.
+func (rl *rateLimiter) afterBody(next http.Handler) http.HandlerFunc {
+	if rl == nil {
+		return next.ServeHTTP
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(pendingChargeKey{}) == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if ok, key, wait := rl.take(rl.keys(r), true); !ok {
+			throttle(w, key, wait)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// throttle answers a request throttled by the bucket of key with 429 Too Many Requests,
+// a Retry-After header in whole seconds and client.RateLimitCode in the Error header.
+func throttle(w http.ResponseWriter, key string, wait time.Duration) {
+	logger.Infof("throttling request of %s", key)
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	w.Header()["Error"] = []string{client.RateLimitCode}
+	w.WriteHeader(http.StatusTooManyRequests)
+}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ipfs/go-delegated-routing/client"
+)
+
+// rejectRequest logs and records the rejection of the request served with ctx, and returns the error reported
+// to the client. Rejections which are not a client.RejectionError are reported as client.ErrServiceRejected.
+func rejectRequest(ctx context.Context, method string, err error) error {
+	var rejection *client.RejectionError
+	if !errors.As(err, &rejection) {
+		rejection = client.ErrServiceRejected.WithReason(err.Error())
+	}
+	logger.Infof("rejecting %s request (%v)", method, rejection)
+	setRequestError(ctx, rejection)
+	return rejection
+}
+
+// sendResult sends r on rch, unless ctx is done first.
+func sendResult[T any](ctx context.Context, rch chan<- T, r T) {
+	select {
+	case <-ctx.Done():
+	case rch <- r:
+	}
+}
@@ -0,0 +1,79 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/ipfs/go-delegated-routing/server"
+	"github.com/ipfs/go-delegated-routing/server/memory"
+)
+
+func TestProvideAllowlist(t *testing.T) {
+	allowed, allowedPriv := testProvider(t)
+	denied, deniedPriv := testProvider(t)
+	svc := memory.NewService()
+	authorizer := server.WithProvideAuthorizer(server.NewAllowlistAuthorizer(allowed.Peer.ID))
+	ctx := context.Background()
+
+	c, s := createClientAndServer(t, svc, allowed, allowedPriv, withServer(authorizer))
+	defer s.Close()
+	if _, err := c.Provide(ctx, []cid.Cid{testCid(t)}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	c, s = createClientAndServer(t, svc, denied, deniedPriv, withServer(authorizer))
+	defer s.Close()
+	_, err := c.Provide(ctx, []cid.Cid{testCid(t)}, time.Hour)
+	var rejection *client.RejectionError
+	if !errors.Is(err, client.ErrProvideUnauthorized) || !errors.As(err, &rejection) || rejection.Reason == "" {
+		t.Fatalf("expecting an unauthorized peer to be rejected with a reason, got %v", err)
+	}
+	if _, err := c.FindPeer(ctx, denied.Peer.ID); err == nil {
+		t.Fatal("expecting the rejected request not to reach the service")
+	}
+}
+
+func TestProvideKeyQuota(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	prov, priv := testProvider(t)
+	c, s := createClientAndServer(t, memory.NewService(), prov, priv,
+		withServer(server.WithProvideAuthorizer(server.NewKeyQuotaAuthorizer(3, time.Minute, clock))))
+	defer s.Close()
+	ctx := context.Background()
+
+	keys := []cid.Cid{testCid(t), cid.NewCidV1(cid.DagProtobuf, testCid(t).Hash())}
+	if _, err := c.Provide(ctx, keys, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Provide(ctx, keys, time.Hour); !errors.Is(err, client.ErrProvideQuotaExceeded) {
+		t.Fatalf("expecting a request over the quota to be rejected, got %v", err)
+	}
+	// the rejected request does not count towards the quota
+	if _, err := c.Provide(ctx, keys[:1], time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Set(clock.Now().Add(time.Minute))
+	if _, err := c.Provide(ctx, keys, time.Hour); err != nil {
+		t.Fatalf("expecting the quota to be restored in the next window, got %v", err)
+	}
+}
+
+func TestProvideAuthorizerFunc(t *testing.T) {
+	prov, priv := testProvider(t)
+	denyAll := server.ProvideAuthorizerFunc(func(ctx context.Context, req *client.ProvideRequest) error {
+		return errors.New("closed for maintenance")
+	})
+	c, s := createClientAndServer(t, memory.NewService(), prov, priv, withServer(server.WithProvideAuthorizer(denyAll)))
+	defer s.Close()
+
+	_, err := c.Provide(context.Background(), []cid.Cid{testCid(t)}, time.Hour)
+	var rejection *client.RejectionError
+	if !errors.As(err, &rejection) || rejection.Code != client.ErrProvideUnauthorized.Code || rejection.Reason != "closed for maintenance" {
+		t.Fatalf("expecting the callback error as the reason of the rejection, got %v", err)
+	}
+}
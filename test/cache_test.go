@@ -0,0 +1,110 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-delegated-routing/client"
+	proto "github.com/ipfs/go-delegated-routing/gen/proto"
+	"github.com/ipfs/go-delegated-routing/server"
+	"github.com/ipfs/go-delegated-routing/server/memory"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multihash"
+)
+
+// statusRecordingTransport records the status codes of the responses it forwards.
+type statusRecordingTransport struct {
+	lk       sync.Mutex
+	statuses []int
+}
+
+func (t *statusRecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err == nil {
+		t.lk.Lock()
+		t.statuses = append(t.statuses, resp.StatusCode)
+		t.lk.Unlock()
+	}
+	return resp, err
+}
+
+func TestFindProvidersCache(t *testing.T) {
+	s := httptest.NewServer(server.DelegatedRoutingAsyncHandler(testDelegatedRoutingService{}))
+	defer s.Close()
+
+	recorder := &statusRecordingTransport{}
+	const ttl = 200 * time.Millisecond
+	hc := &http.Client{Transport: client.NewCacheTransport(recorder, 16, ttl)}
+	q, err := proto.New_DelegatedRouting_Client(s.URL, proto.DelegatedRouting_Client_WithHTTPClient(hc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.NewClient(q, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := multihash.Sum([]byte("TEST"), multihash.SHA3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	findProviders := func() {
+		infos, err := c.FindProviders(context.Background(), cid.NewCidV1(cid.Raw, h))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(infos) != 1 || infos[0].ID != testAddrInfo.ID {
+			t.Fatalf("expecting %v, got %v", testAddrInfo, infos)
+		}
+	}
+
+	// the first call is a miss, the second is served from the cache
+	findProviders()
+	findProviders()
+	if len(recorder.statuses) != 1 || recorder.statuses[0] != http.StatusOK {
+		t.Fatalf("expecting a single 200 response, got %v", recorder.statuses)
+	}
+
+	// after the ttl expires, the cached response is revalidated with the server
+	time.Sleep(ttl)
+	findProviders()
+	if len(recorder.statuses) != 2 || recorder.statuses[1] != http.StatusNotModified {
+		t.Fatalf("expecting a 304 response, got %v", recorder.statuses)
+	}
+}
+
+func TestFindProvidersCacheIsPerEndpoint(t *testing.T) {
+	empty := httptest.NewServer(server.DelegatedRoutingAsyncHandler(memory.NewService()))
+	defer empty.Close()
+	full := httptest.NewServer(server.DelegatedRoutingAsyncHandler(testDelegatedRoutingService{}))
+	defer full.Close()
+
+	// the endpoints share the transport of their HTTP client, and so its cache
+	hc := &http.Client{Transport: client.NewCacheTransport(nil, 16, time.Minute)}
+	findProviders := func(endpoint string) []peer.AddrInfo {
+		q, err := proto.New_DelegatedRouting_Client(endpoint, proto.DelegatedRouting_Client_WithHTTPClient(hc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := client.NewClient(q, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		infos, err := c.FindProviders(context.Background(), testCid(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return infos
+	}
+	if infos := findProviders(empty.URL); len(infos) != 0 {
+		t.Fatalf("expecting no providers, got %v", infos)
+	}
+	if infos := findProviders(full.URL); len(infos) != 1 || infos[0].ID != testAddrInfo.ID {
+		t.Fatalf("expecting the response of the other endpoint not to be served from the cache, got %v", infos)
+	}
+}
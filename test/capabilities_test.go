@@ -0,0 +1,238 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-delegated-routing/client"
+	proto "github.com/ipfs/go-delegated-routing/gen/proto"
+	"github.com/ipfs/go-delegated-routing/server"
+	"github.com/ipfs/go-delegated-routing/server/memory"
+	"github.com/ipld/edelweiss/services"
+	"github.com/ipld/edelweiss/values"
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/codec/dagjson"
+)
+
+// identifyingHandler answers Identify calls with the configured methods and passes other calls to the test service.
+type identifyingHandler struct {
+	lk           sync.Mutex
+	methods      []string
+	numIdentify  int
+	numOtherCall int
+	handler      http.HandlerFunc
+	// gate, if set, holds Identify calls until it is closed
+	gate chan struct{}
+}
+
+func newIdentifyingHandler(methods ...string) *identifyingHandler {
+	return &identifyingHandler{
+		methods: methods,
+		handler: server.DelegatedRoutingAsyncHandler(testDelegatedRoutingService{}),
+	}
+}
+
+func (h *identifyingHandler) setMethods(methods ...string) {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+	h.methods = methods
+}
+
+func (h *identifyingHandler) counts() (numIdentify, numOtherCall int) {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+	return h.numIdentify, h.numOtherCall
+}
+
+func (h *identifyingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	env := &proto.AnonInductive4{}
+	if n, err := ipld.Decode([]byte(r.URL.Query().Get("q")), dagcbor.Decode); err != nil || env.Parse(n) != nil || env.Identify == nil {
+		h.lk.Lock()
+		h.numOtherCall++
+		h.lk.Unlock()
+		h.handler(w, r)
+		return
+	}
+
+	if h.gate != nil {
+		<-h.gate
+	}
+	h.lk.Lock()
+	h.numIdentify++
+	result := &proto.DelegatedRouting_IdentifyResult{}
+	for _, m := range h.methods {
+		result.Methods = append(result.Methods, values.String(m))
+	}
+	h.lk.Unlock()
+
+	var buf bytes.Buffer
+	if err := ipld.EncodeStreaming(&buf, &proto.AnonInductive5{Identify: result}, dagjson.Encode); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	buf.WriteByte('\n')
+	w.Write(buf.Bytes())
+}
+
+func TestCapabilityNegotiation(t *testing.T) {
+	h := newIdentifyingHandler("FindProviders", "PutIPNS")
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	q, err := proto.New_DelegatedRouting_Client(s.URL, proto.DelegatedRouting_Client_WithHTTPClient(s.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const refresh = 100 * time.Millisecond
+	c, err := client.NewClient(q, nil, nil, client.WithCapabilityNegotiation(true, refresh))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := h.counts(); n != 1 {
+		t.Errorf("expecting the server to be identified eagerly")
+	}
+
+	caps, err := c.Capabilities(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(caps) != 2 {
+		t.Errorf("expecting 2 capabilities, got %v", caps)
+	}
+
+	if _, err := c.FindProviders(context.Background(), testCid(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	var unsupported *client.UnsupportedMethodError
+	_, err = c.GetIPNS(context.Background(), []byte(testPeerIDFromIPNS))
+	if !errors.As(err, &unsupported) || unsupported.Method != "GetIPNS" {
+		t.Errorf("expecting GetIPNS to be unsupported, got %v", err)
+	}
+	if _, n := h.counts(); n != 1 {
+		t.Errorf("expecting only the FindProviders call to reach the server, got %d calls", n)
+	}
+
+	// a server upgrade is noticed once the capabilities are refreshed
+	h.setMethods("FindProviders", "GetIPNS", "PutIPNS")
+	time.Sleep(refresh)
+	if _, err := c.GetIPNS(context.Background(), []byte(testPeerIDFromIPNS)); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := h.counts(); n != 2 {
+		t.Errorf("expecting the server to be identified again, got %d identify calls", n)
+	}
+}
+
+// readOnlyService declares that it only supports the lookup methods of the memory service.
+type readOnlyService struct {
+	*memory.Service
+}
+
+func (readOnlyService) SupportedMethods() []string {
+	return []string{"FindProviders", "FindProvidersBatch", "FindPeer", "GetIPNS"}
+}
+
+func TestSupportedMethods(t *testing.T) {
+	prov, priv := testProvider(t)
+	c, s := createClientAndServer(t, readOnlyService{memory.NewService()}, prov, priv)
+	defer s.Close()
+	ctx := context.Background()
+
+	methods, err := c.Identify(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(methods, ",") != "FindProviders,FindProvidersBatch,FindPeer,GetIPNS" {
+		t.Fatalf("expecting only the supported methods to be advertised, got %v", methods)
+	}
+	if _, err := c.FindProviders(ctx, testCid(t)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Provide(ctx, []cid.Cid{testCid(t)}, time.Hour); !errors.Is(err, services.ErrSchema) {
+		t.Fatalf("expecting an unsupported method to be rejected as unknown, got %v", err)
+	}
+	if err := c.PutIPNS(ctx, []byte(testPeerIDFromIPNS), testIPNSRecord); !errors.Is(err, services.ErrSchema) {
+		t.Fatalf("expecting an unsupported method to be rejected as unknown, got %v", err)
+	}
+
+	// clients negotiating capabilities do not call unsupported methods
+	q, err := proto.New_DelegatedRouting_Client(s.URL, proto.DelegatedRouting_Client_WithHTTPClient(s.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err = client.NewClient(q, nil, nil, client.WithCapabilityNegotiation(true, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var unsupported *client.UnsupportedMethodError
+	if err := c.PutIPNS(ctx, []byte(testPeerIDFromIPNS), testIPNSRecord); !errors.As(err, &unsupported) {
+		t.Fatalf("expecting the client to know that PutIPNS is not supported, got %v", err)
+	}
+}
+
+// misspelledMethodsService names a method which does not exist.
+type misspelledMethodsService struct {
+	*memory.Service
+}
+
+func (misspelledMethodsService) SupportedMethods() []string {
+	return []string{"FindProviders", "FindPeers"}
+}
+
+func TestUnknownSupportedMethod(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expecting a handler supporting an unknown method not to be built")
+		}
+	}()
+	server.DelegatedRoutingAsyncHandler(misspelledMethodsService{memory.NewService()})
+}
+
+func TestConcurrentCapabilityRefresh(t *testing.T) {
+	h := newIdentifyingHandler("FindProviders")
+	h.gate = make(chan struct{})
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	q, err := proto.New_DelegatedRouting_Client(s.URL, proto.DelegatedRouting_Client_WithHTTPClient(s.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.NewClient(q, nil, nil, client.WithCapabilityNegotiation(false, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Capabilities(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	// callers waiting for the identification in flight give up with their context
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.Capabilities(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expecting the caller to stop waiting when its context is done, got %v", err)
+	}
+
+	close(h.gate)
+	wg.Wait()
+	if n, _ := h.counts(); n != 1 {
+		t.Errorf("expecting the server to be identified once, got %d identify calls", n)
+	}
+}
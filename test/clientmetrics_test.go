@@ -0,0 +1,55 @@
+package test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ipfs/go-delegated-routing/client"
+	proto "github.com/ipfs/go-delegated-routing/gen/proto"
+	"go.opencensus.io/stats/view"
+)
+
+func TestClientStreamMetrics(t *testing.T) {
+	if err := view.Register(client.StreamViews...); err != nil {
+		t.Fatal(err)
+	}
+	defer view.Unregister(client.StreamViews...)
+
+	findProviders := func(svc proto.DelegatedRouting_Server) {
+		s := httptest.NewServer(proto.DelegatedRouting_AsyncHandler(svc))
+		defer s.Close()
+		q, err := proto.New_DelegatedRouting_Client(s.URL, proto.DelegatedRouting_Client_WithHTTPClient(s.Client()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := client.NewClient(q, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range client.NewContentRoutingClient(c).FindProvidersAsync(context.Background(), testCid(t), 0) {
+		}
+	}
+	// the GraphSync provider is dropped by the Bitswap filter
+	findProviders(testServiceWithGraphSync{})
+	// the error is received in the middle of the stream
+	findProviders(testServiceWithErrors{})
+
+	for _, x := range []struct {
+		view string
+		key  string
+		min  int64
+	}{
+		{"delegated_routing/time_to_first_result", "Client.FindProvidersAsync", 1},
+		{"delegated_routing/time_to_first_result", "ContentRoutingClient.FindProvidersAsync", 1},
+		{"delegated_routing/providers", "ContentRoutingClient.FindProvidersAsync", 2},
+		{"delegated_routing/dropped_results", "Bitswap", 1},
+		{"delegated_routing/stream_errors", "Service,Client.FindProvidersAsync", 1},
+		{"delegated_routing/stream_errors", "Service,ContentRoutingClient.FindProvidersAsync", 1},
+	} {
+		counts := viewCounts(t, x.view)
+		if counts[x.key] < x.min {
+			t.Errorf("expecting at least %d of %s for %s, got %v", x.min, x.view, x.key, counts)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"runtime"
@@ -23,21 +24,87 @@ import (
 	"github.com/multiformats/go-multihash"
 )
 
-func createClientAndServer(t *testing.T, service server.DelegatedRoutingService, p *client.Provider, identity crypto.PrivKey) (*client.Client, *httptest.Server) {
+// clientTransport wraps the transport of a test client, e.g. in a client.RateLimitTransport.
+// Transports given later wrap those given earlier.
+type clientTransport func(http.RoundTripper) http.RoundTripper
+
+// serverHandler wraps the handler of a test server, e.g. to inject failures.
+type serverHandler func(http.Handler) http.Handler
+
+// testSetup holds the options of the client and server created by createClientAndServer.
+type testSetup struct {
+	server     []server.HandlerOption
+	client     []client.ClientOption
+	transport  client.TransportOptions
+	transports []clientTransport
+	handlers   []serverHandler
+}
+
+// testOption configures the client or server created by createClientAndServer.
+type testOption func(*testSetup)
+
+// withServer passes opts to the test server.
+func withServer(opts ...server.HandlerOption) testOption {
+	return func(s *testSetup) { s.server = append(s.server, opts...) }
+}
+
+// withClient passes opts to the test client.
+func withClient(opts ...client.ClientOption) testOption {
+	return func(s *testSetup) { s.client = append(s.client, opts...) }
+}
+
+// withTransportOptions selects the transports which client.NewTransport assembles for the test client.
+func withTransportOptions(o client.TransportOptions) testOption {
+	return func(s *testSetup) { s.transport = o }
+}
+
+// withTransport wraps the transport of the test client, as assembled by client.NewTransport.
+func withTransport(wraps ...clientTransport) testOption {
+	return func(s *testSetup) { s.transports = append(s.transports, wraps...) }
+}
+
+// withHandler wraps the handler of the test server.
+func withHandler(wraps ...serverHandler) testOption {
+	return func(s *testSetup) { s.handlers = append(s.handlers, wraps...) }
+}
+
+func newTestSetup(opts []testOption) testSetup {
+	var setup testSetup
+	for _, opt := range opts {
+		opt(&setup)
+	}
+	return setup
+}
+
+func createClientAndServer(t *testing.T, service server.DelegatedRoutingService, p *client.Provider, identity crypto.PrivKey, opts ...testOption) (*client.Client, *httptest.Server) {
 	// start a server
-	s := httptest.NewServer(server.DelegatedRoutingAsyncHandler(service))
+	setup := newTestSetup(opts)
+	var handler http.Handler = server.DelegatedRoutingAsyncHandler(service, setup.server...)
+	for _, wrap := range setup.handlers {
+		handler = wrap(handler)
+	}
+	s := httptest.NewServer(handler)
 
 	// start a client
-	q, err := proto.New_DelegatedRouting_Client(s.URL, proto.DelegatedRouting_Client_WithHTTPClient(s.Client()))
+	return createClient(t, s, p, identity, opts...), s
+}
+
+// createClient creates a client of s, ignoring the server options among opts.
+func createClient(t *testing.T, s *httptest.Server, p *client.Provider, identity crypto.PrivKey, opts ...testOption) *client.Client {
+	setup := newTestSetup(opts)
+	hc := &http.Client{Transport: client.NewTransport(s.Client().Transport, setup.transport)}
+	for _, wrap := range setup.transports {
+		hc = &http.Client{Transport: wrap(hc.Transport)}
+	}
+	q, err := proto.New_DelegatedRouting_Client(s.URL, proto.DelegatedRouting_Client_WithHTTPClient(hc))
 	if err != nil {
 		t.Fatal(err)
 	}
-	c, err := client.NewClient(q, p, identity)
+	c, err := client.NewClient(q, p, identity, setup.client...)
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	return c, s
+	return c
 }
 
 func testClientServer(t *testing.T, numIter int) (avgLatency time.Duration, deltaGo int, deltaMem uint64) {
@@ -372,6 +439,15 @@ func (testDelegatedRoutingService) FindProviders(ctx context.Context, key cid.Ci
 	return ch, nil
 }
 
+func (testDelegatedRoutingService) FindPeer(ctx context.Context, id peer.ID) (<-chan client.FindPeerAsyncResult, error) {
+	ch := make(chan client.FindPeerAsyncResult)
+	go func() {
+		ch <- client.FindPeerAsyncResult{AddrInfo: []peer.AddrInfo{{ID: id, Addrs: testAddrInfo.Addrs}}}
+		close(ch)
+	}()
+	return ch, nil
+}
+
 func (testDelegatedRoutingService) Provide(ctx context.Context, pr *client.ProvideRequest) (<-chan client.ProvideAsyncResult, error) {
 	ch := make(chan client.ProvideAsyncResult)
 	go func() {
@@ -412,6 +488,15 @@ func (s *hangingDelegatedRoutingService) FindProviders(ctx context.Context, key
 	return ch, nil
 }
 
+func (s *hangingDelegatedRoutingService) FindPeer(ctx context.Context, id peer.ID) (<-chan client.FindPeerAsyncResult, error) {
+	ch := make(chan client.FindPeerAsyncResult)
+	go func() {
+		<-ctx.Done()
+		close(ch)
+	}()
+	return ch, nil
+}
+
 func (s *hangingDelegatedRoutingService) Provide(ctx context.Context, pr *client.ProvideRequest) (<-chan client.ProvideAsyncResult, error) {
 	ch := make(chan client.ProvideAsyncResult)
 	go func() {
@@ -0,0 +1,126 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-delegated-routing/client"
+	proto "github.com/ipfs/go-delegated-routing/gen/proto"
+	"github.com/ipfs/go-delegated-routing/server"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// encodingRecordingTransport forces the accepted content encoding and records the encodings of the responses.
+type encodingRecordingTransport struct {
+	accept string
+
+	lk        sync.Mutex
+	encodings []string
+}
+
+func (t *encodingRecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Accept-Encoding", t.accept)
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err == nil {
+		t.lk.Lock()
+		t.encodings = append(t.encodings, resp.Header.Get("Content-Encoding"))
+		t.lk.Unlock()
+	}
+	return resp, err
+}
+
+// streamingService answers FindProviders with a single result and keeps the stream open until the request ends.
+type streamingService struct {
+	testDelegatedRoutingService
+}
+
+func (streamingService) FindProviders(ctx context.Context, key cid.Cid) (<-chan client.FindProvidersAsyncResult, error) {
+	ch := make(chan client.FindProvidersAsyncResult)
+	go func() {
+		defer close(ch)
+		select {
+		case <-ctx.Done():
+			return
+		case ch <- client.FindProvidersAsyncResult{AddrInfo: []peer.AddrInfo{*testAddrInfo}}:
+		}
+		<-ctx.Done()
+	}()
+	return ch, nil
+}
+
+func TestCompressedResponses(t *testing.T) {
+	for _, encoding := range []string{"zstd", "gzip"} {
+		t.Run(encoding, func(t *testing.T) {
+			s := httptest.NewServer(server.DelegatedRoutingAsyncHandler(streamingService{}, server.WithCompression()))
+			defer s.Close()
+
+			recorder := &encodingRecordingTransport{accept: encoding}
+			// the compressed response is decompressed before it is transcoded from DAG-CBOR and counted against the limits
+			opts := client.TransportOptions{Limits: client.DefaultLimits, DagCBOR: true, Compression: true}
+			hc := &http.Client{Transport: client.NewTransport(recorder, opts)}
+			q, err := proto.New_DelegatedRouting_Client(s.URL, proto.DelegatedRouting_Client_WithHTTPClient(hc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := client.NewClient(q, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the result is received while the stream is still open, so compression does not hold it back
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ch, err := c.FindProvidersBatch(ctx, []cid.Cid{testCid(t)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case r := <-ch:
+				if r.Err != nil {
+					t.Fatal(r.Err)
+				}
+				if len(r.AddrInfo) != 1 || r.AddrInfo[0].ID != testAddrInfo.ID {
+					t.Errorf("expecting %v, got %v", testAddrInfo, r.AddrInfo)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("streamed result was not flushed")
+			}
+
+			recorder.lk.Lock()
+			defer recorder.lk.Unlock()
+			if len(recorder.encodings) != 1 || recorder.encodings[0] != encoding {
+				t.Errorf("expecting a %s encoded response, got %q", encoding, recorder.encodings)
+			}
+		})
+	}
+}
+
+func TestResponsesUncompressedByDefault(t *testing.T) {
+	s := httptest.NewServer(server.DelegatedRoutingAsyncHandler(testDelegatedRoutingService{}))
+	defer s.Close()
+
+	recorder := &encodingRecordingTransport{accept: "zstd, gzip"}
+	hc := &http.Client{Transport: client.NewCompressionTransport(recorder)}
+	q, err := proto.New_DelegatedRouting_Client(s.URL, proto.DelegatedRouting_Client_WithHTTPClient(hc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.NewClient(q, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.FindProviders(context.Background(), testCid(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder.lk.Lock()
+	defer recorder.lk.Unlock()
+	if len(recorder.encodings) != 1 || recorder.encodings[0] != "" {
+		t.Errorf("expecting an uncompressed response, got %q", recorder.encodings)
+	}
+}
@@ -0,0 +1,172 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ipfs/go-delegated-routing/client"
+	proto "github.com/ipfs/go-delegated-routing/gen/proto"
+	"github.com/ipfs/go-delegated-routing/server"
+)
+
+// contentTypeRecordingTransport records the content types of the requests and responses it forwards.
+type contentTypeRecordingTransport struct {
+	lk       sync.Mutex
+	requests []string
+	replies  []string
+}
+
+func (t *contentTypeRecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err == nil {
+		t.lk.Lock()
+		t.requests = append(t.requests, req.Header.Get("Content-Type"))
+		t.replies = append(t.replies, resp.Header.Get("Content-Type"))
+		t.lk.Unlock()
+	}
+	return resp, err
+}
+
+func TestDagCBORNegotiation(t *testing.T) {
+	s := httptest.NewServer(server.DelegatedRoutingAsyncHandler(testDelegatedRoutingService{}))
+	defer s.Close()
+
+	recorder := &contentTypeRecordingTransport{}
+	hc := &http.Client{Transport: client.NewDagCBORTransport(recorder)}
+	q, err := proto.New_DelegatedRouting_Client(s.URL, proto.DelegatedRouting_Client_WithHTTPClient(hc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.NewClient(q, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	infos, err := c.FindProviders(ctx, testCid(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].ID != testAddrInfo.ID || !infos[0].Addrs[0].Equal(testMultiaddr) {
+		t.Fatalf("expecting %v, got %v", testAddrInfo, infos)
+	}
+	// the server is now known to speak DAG-CBOR, so POST bodies are DAG-CBOR encoded as well
+	record, err := c.GetIPNS(ctx, []byte(testPeerIDFromIPNS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(record, testIPNSRecord) {
+		t.Errorf("expecting %#v, got %#v", testIPNSRecord, record)
+	}
+
+	recorder.lk.Lock()
+	defer recorder.lk.Unlock()
+	for i, ct := range recorder.replies {
+		if !client.IsMediaType(ct, client.MediaTypeDagCBOR) {
+			t.Errorf("expecting response %d to be DAG-CBOR, got %q", i, ct)
+		}
+	}
+	if n := len(recorder.requests); n != 2 || !client.IsMediaType(recorder.requests[1], client.MediaTypeDagCBOR) {
+		t.Errorf("expecting the POST request to be DAG-CBOR, got %q", recorder.requests)
+	}
+}
+
+func TestDagJSONFallback(t *testing.T) {
+	// a server which does not negotiate encodings always answers DAG-JSON
+	s := httptest.NewServer(proto.DelegatedRouting_AsyncHandler(testServiceWithGraphSync{}))
+	defer s.Close()
+
+	recorder := &contentTypeRecordingTransport{}
+	hc := &http.Client{Transport: client.NewDagCBORTransport(recorder)}
+	q, err := proto.New_DelegatedRouting_Client(s.URL, proto.DelegatedRouting_Client_WithHTTPClient(hc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.NewClient(q, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provs, err := c.FindProviderRecords(context.Background(), testCid(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provs) != 2 {
+		t.Fatalf("expecting 2 providers, got %d", len(provs))
+	}
+	recorder.lk.Lock()
+	defer recorder.lk.Unlock()
+	if len(recorder.replies) != 1 || !client.IsMediaType(recorder.replies[0], client.MediaTypeDagJSON) {
+		t.Errorf("expecting a DAG-JSON response, got %q", recorder.replies)
+	}
+}
+
+// etagRecordingTransport records the URL and the ETag of the last response it forwards.
+type etagRecordingTransport struct {
+	lk   sync.Mutex
+	url  string
+	etag string
+}
+
+func (t *etagRecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err == nil {
+		t.lk.Lock()
+		t.url, t.etag = req.URL.String(), resp.Header.Get("ETag")
+		t.lk.Unlock()
+	}
+	return resp, err
+}
+
+func TestETagsDifferByEncoding(t *testing.T) {
+	s := httptest.NewServer(server.DelegatedRoutingAsyncHandler(testDelegatedRoutingService{}, server.WithCompression()))
+	defer s.Close()
+
+	lookup := func(o client.TransportOptions) (url, etag string) {
+		recorder := &etagRecordingTransport{}
+		hc := &http.Client{Transport: client.NewTransport(recorder, o)}
+		q, err := proto.New_DelegatedRouting_Client(s.URL, proto.DelegatedRouting_Client_WithHTTPClient(hc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := client.NewClient(q, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.FindProviders(context.Background(), testCid(t)); err != nil {
+			t.Fatal(err)
+		}
+		return recorder.url, recorder.etag
+	}
+	url, jsonETag := lookup(client.TransportOptions{})
+	_, cborETag := lookup(client.TransportOptions{DagCBOR: true})
+	_, compressedETag := lookup(client.TransportOptions{DagCBOR: true, Compression: true})
+	if jsonETag == "" || jsonETag == cborETag || cborETag == compressedETag || jsonETag == compressedETag {
+		t.Fatalf("expecting a distinct ETag for each encoding, got %q, %q and %q", jsonETag, cborETag, compressedETag)
+	}
+
+	revalidate := func(etag string) int {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", client.MediaTypeDagCBOR)
+		req.Header.Set("If-None-Match", etag)
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := revalidate(jsonETag); status != http.StatusOK {
+		t.Errorf("expecting the ETag of the DAG-JSON response not to revalidate the DAG-CBOR response, got %d", status)
+	}
+	if status := revalidate(cborETag); status != http.StatusNotModified {
+		t.Errorf("expecting the ETag of the DAG-CBOR response to revalidate it, got %d", status)
+	}
+}
@@ -72,6 +72,14 @@ func (testServiceWithUnknown) FindProviders(ctx context.Context, req *proto.Find
 	return respCh, nil
 }
 
+func (testServiceWithUnknown) FindProvidersBatch(ctx context.Context, req *proto.FindProvidersBatchRequest) (<-chan *proto.DelegatedRouting_FindProvidersBatch_AsyncResult, error) {
+	return nil, fmt.Errorf("FindProvidersBatch not supported by test service")
+}
+
+func (testServiceWithUnknown) FindPeer(ctx context.Context, req *proto.FindPeerRequest) (<-chan *proto.DelegatedRouting_FindPeer_AsyncResult, error) {
+	return nil, fmt.Errorf("FindPeer not supported by test service")
+}
+
 func (testServiceWithUnknown) GetIPNS(ctx context.Context, req *proto.GetIPNSRequest) (<-chan *proto.DelegatedRouting_GetIPNS_AsyncResult, error) {
 	return nil, fmt.Errorf("GetIPNS not supported by test service")
 }
@@ -0,0 +1,39 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-delegated-routing/client"
+)
+
+func TestFindPeer(t *testing.T) {
+	c, s := createClientAndServer(t, testDelegatedRoutingService{}, nil, nil)
+	defer s.Close()
+
+	info, err := client.NewPeerRoutingClient(c).FindPeer(context.Background(), testPeerIDFromIPNS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != testPeerIDFromIPNS {
+		t.Errorf("expecting %v, got %v", testPeerIDFromIPNS, info.ID)
+	}
+	if len(info.Addrs) != 1 || !info.Addrs[0].Equal(testMultiaddr) {
+		t.Errorf("expecting %v, got %v", testMultiaddr, info.Addrs)
+	}
+
+	ch, err := c.FindPeerAsync(context.Background(), testPeerIDFromIPNS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	num := 0
+	for r := range ch {
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+		num += len(r.AddrInfo)
+	}
+	if num != 1 {
+		t.Errorf("expecting 1 result, got %d", num)
+	}
+}
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-delegated-routing/client"
+	proto "github.com/ipfs/go-delegated-routing/gen/proto"
+	"github.com/multiformats/go-multihash"
+)
+
+func testBatchCids(t *testing.T, n int) []cid.Cid {
+	cids := make([]cid.Cid, n)
+	for i := range cids {
+		h, err := multihash.Sum([]byte{byte(i)}, multihash.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cids[i] = cid.NewCidV1(cid.Raw, h)
+	}
+	return cids
+}
+
+func checkBatchResults(t *testing.T, keys []cid.Cid, ch <-chan client.FindProvidersBatchAsyncResult) {
+	t.Helper()
+	answered := map[cid.Cid]int{}
+	for r := range ch {
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+		if len(r.AddrInfo) != 1 || r.AddrInfo[0].ID != testAddrInfo.ID {
+			t.Errorf("unexpected providers %v for key %v", r.AddrInfo, r.Key)
+		}
+		answered[r.Key]++
+	}
+	if len(answered) != len(keys) {
+		t.Fatalf("expecting %d answered keys, got %d", len(keys), len(answered))
+	}
+	for _, key := range keys {
+		if answered[key] != 1 {
+			t.Errorf("expecting 1 result for key %v, got %d", key, answered[key])
+		}
+	}
+}
+
+func TestFindProvidersBatch(t *testing.T) {
+	c, s := createClientAndServer(t, testDelegatedRoutingService{}, nil, nil)
+	defer s.Close()
+
+	keys := testBatchCids(t, 20)
+	ch, err := c.FindProvidersBatch(context.Background(), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBatchResults(t, keys, ch)
+}
+
+func TestFindProvidersBatchFallback(t *testing.T) {
+	h := newIdentifyingHandler("FindProviders")
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	q, err := proto.New_DelegatedRouting_Client(s.URL, proto.DelegatedRouting_Client_WithHTTPClient(s.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.NewClient(q, nil, nil, client.WithCapabilityNegotiation(true, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := testBatchCids(t, 3)
+	ch, err := c.FindProvidersBatch(context.Background(), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBatchResults(t, keys, ch)
+	if _, numOtherCall := h.counts(); numOtherCall != len(keys) {
+		t.Errorf("expecting one request per key, got %d", numOtherCall)
+	}
+}
+
+func TestFindProvidersBatchErrorsCarryKey(t *testing.T) {
+	c, s := createClientAndServer(t, resultRejectingService{}, nil, nil)
+	defer s.Close()
+
+	keys := testBatchCids(t, 3)
+	ch, err := c.FindProvidersBatch(context.Background(), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := map[cid.Cid]bool{}
+	for r := range ch {
+		expectRejection(t, "FindProvidersBatch", r.Err, client.ErrServiceRejected, errMaintenance.Error())
+		failed[r.Key] = true
+	}
+	for _, key := range keys {
+		if !failed[key] {
+			t.Errorf("expecting an error for key %v", key)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/ipfs/go-delegated-routing/server"
+	"github.com/ipfs/go-delegated-routing/server/memory"
+	"github.com/libp2p/go-libp2p/core/crypto"
+)
+
+type fakeClock struct {
+	lk  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Set(now time.Time) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	c.now = now
+}
+
+func TestProvideFreshness(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	policy := server.FreshnessPolicy{MaxClockSkew: time.Minute, MaxAge: time.Hour, HighWaterMark: true}
+	prov, priv := testProvider(t)
+	c, s := createClientAndServer(t, memory.NewService(), prov, priv, withServer(server.WithFreshnessPolicy(policy), server.WithClock(clock)))
+	defer s.Close()
+	ctx := context.Background()
+
+	other, otherPriv := testProvider(t)
+	signed := func(prov *client.Provider, priv crypto.PrivKey) *client.ProvideRequest {
+		req := &client.ProvideRequest{Key: []cid.Cid{testCid(t)}, Provider: prov, AdvisoryTTL: time.Hour}
+		if err := req.Sign(priv); err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+	provide := func(req *client.ProvideRequest) error {
+		ch, err := c.ProvideSignedRecord(ctx, req)
+		if err != nil {
+			return err
+		}
+		for r := range ch {
+			if r.Err != nil {
+				return r.Err
+			}
+		}
+		return nil
+	}
+
+	// request timestamps have a resolution of a second
+	older, otherOlder := signed(prov, priv), signed(other, otherPriv)
+	time.Sleep(1100 * time.Millisecond)
+	newer := signed(prov, priv)
+
+	clock.Set(time.Now().Add(-time.Hour))
+	if err := provide(newer); !errors.Is(err, client.ErrProvideFromFuture) {
+		t.Fatalf("expecting a request ahead of the server clock to be rejected, got %v", err)
+	}
+	clock.Set(time.Now().Add(2 * time.Hour))
+	if err := provide(newer); !errors.Is(err, client.ErrProvideTooOld) {
+		t.Fatalf("expecting a request behind the server clock to be rejected, got %v", err)
+	}
+
+	clock.Set(time.Now())
+	if err := provide(newer); err != nil {
+		t.Fatal(err)
+	}
+	if err := provide(older); !errors.Is(err, client.ErrProvideReplayed) {
+		t.Fatalf("expecting a request older than the newest accepted to be rejected, got %v", err)
+	}
+	if err := provide(newer); !errors.Is(err, client.ErrProvideReplayed) {
+		t.Fatalf("expecting a replay of the newest request accepted to be rejected, got %v", err)
+	}
+
+	// the high-water mark is per peer
+	if err := provide(otherOlder); err != nil {
+		t.Fatalf("expecting the request of another peer to be accepted, got %v", err)
+	}
+}
+
+func TestRejectedProvideKeepsHighWaterMark(t *testing.T) {
+	policy := server.FreshnessPolicy{MaxAge: time.Hour, HighWaterMark: true}
+	prov, priv := testProvider(t)
+
+	// request timestamps have a resolution of a second
+	older := &client.ProvideRequest{Key: []cid.Cid{testCid(t)}, Provider: prov, AdvisoryTTL: time.Hour}
+	if err := older.Sign(priv); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	newer := &client.ProvideRequest{Key: []cid.Cid{testCid(t)}, Provider: prov, AdvisoryTTL: 2 * time.Hour}
+	if err := newer.Sign(priv); err != nil {
+		t.Fatal(err)
+	}
+
+	rejectNewer := server.ProvideAuthorizerFunc(func(ctx context.Context, req *client.ProvideRequest) error {
+		if req.Timestamp == newer.Timestamp {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+	c, s := createClientAndServer(t, memory.NewService(), prov, priv, withServer(server.WithFreshnessPolicy(policy), server.WithProvideAuthorizer(rejectNewer)))
+	defer s.Close()
+	ctx := context.Background()
+
+	provide := func(req *client.ProvideRequest) error {
+		ch, err := c.ProvideSignedRecord(ctx, req)
+		if err != nil {
+			return err
+		}
+		for r := range ch {
+			if r.Err != nil {
+				return r.Err
+			}
+		}
+		return nil
+	}
+	if err := provide(newer); !errors.Is(err, client.ErrProvideUnauthorized) {
+		t.Fatalf("expecting the newer request to be rejected, got %v", err)
+	}
+	if err := provide(older); err != nil {
+		t.Fatalf("expecting a rejected request not to move the high-water mark, got %v", err)
+	}
+}
+
+func TestConcurrentReplaysAreRejected(t *testing.T) {
+	// without MaxAge, only the high-water mark stops replays
+	policy := server.FreshnessPolicy{HighWaterMark: true}
+	prov, priv := testProvider(t)
+	c, s := createClientAndServer(t, memory.NewService(), prov, priv, withServer(server.WithFreshnessPolicy(policy)))
+	defer s.Close()
+	ctx := context.Background()
+
+	req := &client.ProvideRequest{Key: []cid.Cid{testCid(t)}, Provider: prov, AdvisoryTTL: time.Hour}
+	if err := req.Sign(priv); err != nil {
+		t.Fatal(err)
+	}
+	const replays = 8
+	errs := make(chan error, replays)
+	for i := 0; i < replays; i++ {
+		go func() {
+			ch, err := c.ProvideSignedRecord(ctx, req)
+			if err == nil {
+				for r := range ch {
+					if r.Err != nil && err == nil {
+						err = r.Err
+					}
+				}
+			}
+			errs <- err
+		}()
+	}
+	var accepted int
+	for i := 0; i < replays; i++ {
+		err := <-errs
+		switch {
+		case err == nil:
+			accepted++
+		case !errors.Is(err, client.ErrProvideReplayed):
+			t.Errorf("expecting replays to be rejected, got %v", err)
+		}
+	}
+	if accepted != 1 {
+		t.Errorf("expecting exactly one of the concurrent requests to be accepted, got %d", accepted)
+	}
+}
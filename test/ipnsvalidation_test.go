@@ -0,0 +1,71 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ipfs/boxo/ipns"
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/ipfs/go-delegated-routing/server"
+	"github.com/ipfs/go-delegated-routing/server/memory"
+	"github.com/libp2p/go-libp2p/core/crypto"
+)
+
+func createIPNSRecord(t *testing.T, priv crypto.PrivKey, seq uint64, eol time.Time) []byte {
+	entry, err := ipns.Create(priv, []byte("/ipfs/bafkqaaa"), seq, eol, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := entry.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestPutIPNSValidation(t *testing.T) {
+	c, s := createClientAndServer(t, memory.NewService(), nil, nil, withServer(server.WithIPNSValidation()))
+	defer s.Close()
+	ctx := context.Background()
+
+	prov, priv := testProvider(t)
+	id := []byte(prov.Peer.ID)
+	eol := time.Now().Add(time.Hour)
+	if err := c.PutIPNS(ctx, id, createIPNSRecord(t, priv, 2, eol)); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		id     []byte
+		record []byte
+		expect error
+	}{
+		{"higher sequence", id, createIPNSRecord(t, priv, 3, eol), nil},
+		{"lower sequence", id, createIPNSRecord(t, priv, 1, eol), client.ErrIPNSStaleRecord},
+		{"lower sequence with a later eol", id, createIPNSRecord(t, priv, 1, eol.Add(time.Hour)), client.ErrIPNSStaleRecord},
+		{"same sequence with an earlier eol", id, createIPNSRecord(t, priv, 3, eol.Add(-time.Minute)), client.ErrIPNSStaleRecord},
+		{"same sequence with a later eol", id, createIPNSRecord(t, priv, 3, eol.Add(time.Hour)), nil},
+		{"expired", id, createIPNSRecord(t, priv, 4, time.Now().Add(-time.Hour)), client.ErrIPNSExpiredRecord},
+		{"another name", []byte(testPeerIDFromIPNS), createIPNSRecord(t, priv, 4, eol), client.ErrIPNSInvalidRecord},
+	}
+	for _, tc := range cases {
+		err := c.PutIPNS(ctx, tc.id, tc.record)
+		if tc.expect == nil {
+			if err != nil {
+				t.Errorf("%s: expecting the record to be accepted, got %v", tc.name, err)
+			}
+			continue
+		}
+		var rejection *client.RejectionError
+		if !errors.Is(err, tc.expect) || !errors.As(err, &rejection) {
+			t.Errorf("%s: expecting %v, got %v", tc.name, tc.expect, err)
+			continue
+		}
+		if client.MetricsErrStr(err) != "Rejected" {
+			t.Errorf("%s: expecting rejections to be classified apart from service errors, got %s", tc.name, client.MetricsErrStr(err))
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/ipfs/go-delegated-routing/server"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// manyProvidersService answers FindProviders with the configured number of results, each with the configured number of providers.
+type manyProvidersService struct {
+	testDelegatedRoutingService
+	results   int
+	providers int
+}
+
+func (s manyProvidersService) FindProviders(ctx context.Context, key cid.Cid) (<-chan client.FindProvidersAsyncResult, error) {
+	ch := make(chan client.FindProvidersAsyncResult)
+	go func() {
+		defer close(ch)
+		for i := 0; i < s.results; i++ {
+			infos := make([]peer.AddrInfo, s.providers)
+			for j := range infos {
+				infos[j] = peer.AddrInfo{ID: peer.ID(fmt.Sprintf("peer-%d-%d", i, j)), Addrs: testAddrInfo.Addrs}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- client.FindProvidersAsyncResult{AddrInfo: infos}:
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// withLimits returns the options enforcing clientLimits on the test client and serverLimits on the test server.
+func withLimits(clientLimits, serverLimits client.Limits) testOption {
+	return func(s *testSetup) {
+		withServer(server.WithLimits(serverLimits))(s)
+		withClient(client.WithLimits(clientLimits))(s)
+		s.transport.Limits = clientLimits
+	}
+}
+
+func expectLimitError(t *testing.T, err error, limit string) {
+	t.Helper()
+	var limitErr *client.LimitError
+	if !errors.Is(err, client.ErrLimitExceeded) || !errors.As(err, &limitErr) || limitErr.Limit != limit {
+		t.Fatalf("expecting a %s limit error, got %v", limit, err)
+	}
+}
+
+func TestClientStreamLimits(t *testing.T) {
+	svc := manyProvidersService{results: 5, providers: 1}
+
+	c, s := createClientAndServer(t, svc, nil, nil, withLimits(client.Limits{MaxResults: 2}, client.Limits{}))
+	defer s.Close()
+	_, err := c.FindProviders(context.Background(), testCid(t))
+	expectLimitError(t, err, "results")
+
+	c, s = createClientAndServer(t, svc, nil, nil, withLimits(client.Limits{MaxResultBytes: 32}, client.Limits{}))
+	defer s.Close()
+	ch, err := c.FindProvidersAsync(context.Background(), testCid(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last error
+	for r := range ch {
+		last = r.Err
+	}
+	expectLimitError(t, last, "result bytes")
+
+	// DAG-CBOR results are refused before they are decoded
+	withDagCBOR := func(s *testSetup) { s.transport.DagCBOR = true }
+	c, s = createClientAndServer(t, svc, nil, nil, withLimits(client.Limits{MaxResultBytes: 32}, client.Limits{}), withDagCBOR)
+	defer s.Close()
+	ch, err = c.FindProvidersAsync(context.Background(), testCid(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	last = nil
+	for r := range ch {
+		last = r.Err
+	}
+	expectLimitError(t, last, "result bytes")
+}
+
+func TestProvidersLimit(t *testing.T) {
+	svc := manyProvidersService{results: 1, providers: 5}
+
+	// the server splits the providers into results the client accepts
+	c, s := createClientAndServer(t, svc, nil, nil, withLimits(client.Limits{MaxProviders: 2}, client.Limits{MaxProviders: 2}))
+	defer s.Close()
+	ch, err := c.FindProvidersAsync(context.Background(), testCid(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	numResults, numProviders := 0, 0
+	for r := range ch {
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+		numResults++
+		numProviders += len(r.AddrInfo)
+	}
+	if numResults != 3 || numProviders != 5 {
+		t.Errorf("expecting 5 providers in 3 results, got %d providers in %d results", numProviders, numResults)
+	}
+
+	c, s = createClientAndServer(t, svc, nil, nil, withLimits(client.Limits{MaxProviders: 2}, client.Limits{}))
+	defer s.Close()
+	_, err = c.FindProviders(context.Background(), testCid(t))
+	expectLimitError(t, err, "providers")
+}
+
+func TestServerRequestLimit(t *testing.T) {
+	c, s := createClientAndServer(t, testDelegatedRoutingService{}, nil, nil, withLimits(client.Limits{}, client.Limits{MaxRequestBytes: 256}))
+	defer s.Close()
+
+	err := c.PutIPNS(context.Background(), []byte(testPeerIDFromIPNS), make([]byte, 1024))
+	var limitErr *client.LimitError
+	if !errors.Is(err, client.ErrLimitExceeded) || !errors.As(err, &limitErr) || limitErr.Limit != "request bytes" || limitErr.Max != 256 {
+		t.Fatalf("expecting the request to be rejected for its size, got %v", err)
+	}
+	if client.MetricsErrStr(err) != "LimitExceeded" {
+		t.Errorf("expecting the rejection to be classed LimitExceeded, got %s", client.MetricsErrStr(err))
+	}
+	// the rejection does not mark the method as unsupported
+	if err = c.PutIPNS(context.Background(), []byte(testPeerIDFromIPNS), testIPNSRecord[:8]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLimitTransportRefusesUndecodedResponses(t *testing.T) {
+	// installed inside CompressionTransport, LimitTransport would count compressed bytes
+	c, s := createClientAndServer(t, testDelegatedRoutingService{}, nil, nil,
+		withServer(server.WithCompression()),
+		withTransport(
+			func(next http.RoundTripper) http.RoundTripper {
+				return client.NewLimitTransport(next, client.DefaultLimits)
+			},
+			func(next http.RoundTripper) http.RoundTripper { return client.NewCompressionTransport(next) },
+		),
+	)
+	defer s.Close()
+
+	if _, err := c.FindProviders(context.Background(), testCid(t)); !errors.Is(err, client.ErrUndecodedResponse) {
+		t.Fatalf("expecting the compressed response to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/ipfs/go-delegated-routing/server/memory"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/routing"
+	"github.com/multiformats/go-multicodec"
+)
+
+func testProvider(t *testing.T) (*client.Provider, crypto.PrivKey) {
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &client.Provider{
+		Peer:          peer.AddrInfo{ID: id, Addrs: testAddrInfo.Addrs},
+		ProviderProto: []client.TransferProtocol{{Codec: multicodec.TransportBitswap}},
+	}, priv
+}
+
+func TestMemoryServiceProviders(t *testing.T) {
+	svc := memory.NewService()
+	prov, priv := testProvider(t)
+	c, s := createClientAndServer(t, svc, prov, priv)
+	defer s.Close()
+	ctx := context.Background()
+
+	short, long := testCid(t), cid.NewCidV1(cid.DagProtobuf, testCid(t).Hash())
+	if ttl, err := c.Provide(ctx, []cid.Cid{short}, 200*time.Millisecond); err != nil || ttl != 200*time.Millisecond {
+		t.Fatalf("expecting the requested ttl to be granted, got %v (%v)", ttl, err)
+	}
+
+	// providers are keyed by multihash, so a CID with another codec finds them as well
+	infos, err := c.FindProviders(ctx, long)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].ID != prov.Peer.ID || len(infos[0].Addrs) != 1 {
+		t.Fatalf("expecting %v, got %v", prov.Peer, infos)
+	}
+	info, err := c.FindPeer(ctx, prov.Peer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != prov.Peer.ID {
+		t.Errorf("expecting %v, got %v", prov.Peer.ID, info.ID)
+	}
+
+	// the provider expires after the granted ttl
+	time.Sleep(300 * time.Millisecond)
+	svc.Purge()
+	if infos, err := c.FindProviders(ctx, short); err != nil || len(infos) != 0 {
+		t.Fatalf("expecting no providers after expiry, got %v (%v)", infos, err)
+	}
+	if _, err := c.FindPeer(ctx, prov.Peer.ID); !errors.Is(err, routing.ErrNotFound) {
+		t.Fatalf("expecting the peer not to be found after expiry, got %v", err)
+	}
+}
+
+func TestMemoryServiceIPNS(t *testing.T) {
+	c, s := createClientAndServer(t, memory.NewService(), nil, nil)
+	defer s.Close()
+	ctx := context.Background()
+
+	if _, err := c.GetIPNS(ctx, []byte(testPeerIDFromIPNS)); !errors.Is(err, routing.ErrNotFound) {
+		t.Fatalf("expecting no record before the first put, got %v", err)
+	}
+	if err := c.PutIPNS(ctx, []byte(testPeerIDFromIPNS), testIPNSRecord); err != nil {
+		t.Fatal(err)
+	}
+	record, err := c.GetIPNS(ctx, []byte(testPeerIDFromIPNS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(record, testIPNSRecord) {
+		t.Errorf("expecting the stored record, got %x", record)
+	}
+
+	// a record of another name does not validate
+	prov, _ := testProvider(t)
+	if err := c.PutIPNS(ctx, []byte(prov.Peer.ID), testIPNSRecord); err == nil {
+		t.Fatal("expecting a record signed by another key to be rejected")
+	}
+}
@@ -0,0 +1,200 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/ipfs/go-delegated-routing/server/persistent"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/routing"
+	"github.com/multiformats/go-multiaddr"
+)
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPersistentServiceSurvivesRestart(t *testing.T) {
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	prov, priv := testProvider(t)
+	ctx := context.Background()
+
+	c, s := createClientAndServer(t, persistent.NewService(ds), prov, priv)
+	if _, err := c.Provide(ctx, []cid.Cid{testCid(t)}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.PutIPNS(ctx, []byte(testPeerIDFromIPNS), testIPNSRecord); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// a new service on the same datastore serves the stored records
+	c, s = createClientAndServer(t, persistent.NewService(ds), nil, nil)
+	defer s.Close()
+	infos, err := c.FindProviders(ctx, testCid(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].ID != prov.Peer.ID || len(infos[0].Addrs) != 1 || !infos[0].Addrs[0].Equal(testMultiaddr) {
+		t.Fatalf("expecting %v, got %v", prov.Peer, infos)
+	}
+	record, err := c.GetIPNS(ctx, []byte(testPeerIDFromIPNS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(record, testIPNSRecord) {
+		t.Errorf("expecting the stored record, got %x", record)
+	}
+}
+
+func TestPersistentServiceMaxTTLAndGC(t *testing.T) {
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	svc := persistent.NewService(ds, persistent.WithMaxAdvisoryTTL(100*time.Millisecond), persistent.WithGCInterval(50*time.Millisecond))
+	svc.Start()
+	defer svc.Close()
+	prov, priv := testProvider(t)
+	c, s := createClientAndServer(t, svc, prov, priv)
+	defer s.Close()
+	ctx := context.Background()
+
+	ttl, err := c.Provide(ctx, []cid.Cid{testCid(t)}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl != 100*time.Millisecond {
+		t.Fatalf("expecting the ttl to be capped to 100ms, got %v", ttl)
+	}
+	if _, err := c.FindPeer(ctx, prov.Peer.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// the expired record, and the peer which no longer provides anything, are collected in the background
+	waitFor(t, "the peer to be collected", func() bool {
+		_, err := c.FindPeer(ctx, prov.Peer.ID)
+		return errors.Is(err, routing.ErrNotFound)
+	})
+	if infos, err := c.FindProviders(ctx, testCid(t)); err != nil || len(infos) != 0 {
+		t.Fatalf("expecting no providers after expiry, got %v (%v)", infos, err)
+	}
+	n, err := ds.Query(ctx, query.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rest, _ := n.Rest(); len(rest) != 0 {
+		t.Errorf("expecting an empty datastore after collection, got %d entries", len(rest))
+	}
+}
+
+func TestPersistentServiceReplacesAddresses(t *testing.T) {
+	svc := persistent.NewService(dssync.MutexWrap(datastore.NewMapDatastore()))
+	prov, priv := testProvider(t)
+	ctx := context.Background()
+
+	signed := func(addr string) *client.ProvideRequest {
+		ma, err := multiaddr.NewMultiaddr(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := *prov
+		p.Peer = peer.AddrInfo{ID: prov.Peer.ID, Addrs: []multiaddr.Multiaddr{ma}}
+		req := &client.ProvideRequest{Key: []cid.Cid{testCid(t)}, Provider: &p, AdvisoryTTL: time.Hour}
+		if err := req.Sign(priv); err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+	provide := func(req *client.ProvideRequest) {
+		ch, err := svc.Provide(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for r := range ch {
+			if r.Err != nil {
+				t.Fatal(r.Err)
+			}
+		}
+	}
+	addrs := func() []multiaddr.Multiaddr {
+		ch, err := svc.FindPeer(ctx, prov.Peer.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := <-ch
+		if len(r.AddrInfo) != 1 {
+			t.Fatalf("expecting the peer to be found, got %v", r)
+		}
+		return r.AddrInfo[0].Addrs
+	}
+
+	// request timestamps have a resolution of a second
+	older := signed("/ip4/127.0.0.1/tcp/4001")
+	time.Sleep(1100 * time.Millisecond)
+	newer := signed("/ip4/127.0.0.1/tcp/4002")
+
+	provide(newer)
+	provide(older)
+	if a := addrs(); len(a) != 1 || a[0].String() != "/ip4/127.0.0.1/tcp/4002" {
+		t.Fatalf("expecting the addresses of the newer request to be kept, got %v", a)
+	}
+	provide(signed("/ip4/127.0.0.1/tcp/4003"))
+	if a := addrs(); len(a) != 1 || a[0].String() != "/ip4/127.0.0.1/tcp/4003" {
+		t.Fatalf("expecting the addresses to be replaced by the newest request, got %v", a)
+	}
+}
+
+func TestPersistentServiceKeepsNewerExpiry(t *testing.T) {
+	svc := persistent.NewService(dssync.MutexWrap(datastore.NewMapDatastore()))
+	prov, priv := testProvider(t)
+	ctx := context.Background()
+
+	signed := func(ttl time.Duration) *client.ProvideRequest {
+		req := &client.ProvideRequest{Key: []cid.Cid{testCid(t)}, Provider: prov, AdvisoryTTL: ttl}
+		if err := req.Sign(priv); err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+	provide := func(req *client.ProvideRequest) {
+		ch, err := svc.Provide(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for r := range ch {
+			if r.Err != nil {
+				t.Fatal(r.Err)
+			}
+		}
+	}
+
+	// request timestamps have a resolution of a second
+	older := signed(time.Hour)
+	time.Sleep(1100 * time.Millisecond)
+	provide(signed(100 * time.Millisecond))
+	provide(older)
+
+	time.Sleep(150 * time.Millisecond)
+	ch, err := svc.FindProviders(ctx, testCid(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r := range ch {
+		if len(r.AddrInfo) != 0 {
+			t.Fatalf("expecting the older request not to extend the expiry of the newer one, got %v", r.AddrInfo)
+		}
+	}
+}
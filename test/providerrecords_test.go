@@ -0,0 +1,148 @@
+package test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-delegated-routing/client"
+	proto "github.com/ipfs/go-delegated-routing/gen/proto"
+	"github.com/ipfs/go-delegated-routing/server/memory"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multicodec"
+)
+
+func TestFindProviderRecords(t *testing.T) {
+	s := httptest.NewServer(proto.DelegatedRouting_AsyncHandler(testServiceWithGraphSync{}))
+	defer s.Close()
+
+	q, err := proto.New_DelegatedRouting_Client(s.URL, proto.DelegatedRouting_Client_WithHTTPClient(s.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.NewClient(q, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the address info API only returns bitswap providers
+	infos, err := c.FindProviders(context.Background(), testCid(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].ID != testBitswapPeer {
+		t.Fatalf("expecting only the bitswap provider, got %v", infos)
+	}
+
+	provs, err := c.FindProviderRecords(context.Background(), testCid(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provs) != 2 {
+		t.Fatalf("expecting 2 providers, got %d", len(provs))
+	}
+	if provs[0].Peer.ID != testBitswapPeer || len(provs[0].ProviderProto) != 1 || provs[0].ProviderProto[0].Codec != multicodec.TransportBitswap {
+		t.Errorf("expecting a bitswap provider, got %v", provs[0])
+	}
+	gsProv := provs[1]
+	if gsProv.Peer.ID != testGraphSyncPeer || len(gsProv.ProviderProto) != 1 {
+		t.Fatalf("expecting a graphsync provider, got %v", gsProv)
+	}
+	gs, err := gsProv.ProviderProto[0].DecodeGraphSyncFILv1()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gs.PieceCID.Equals(testCid(t)) || !gs.VerifiedDeal || gs.FastRetrieval {
+		t.Errorf("unexpected graphsync metadata %v", gs)
+	}
+}
+
+func TestServiceProviderRecords(t *testing.T) {
+	prov, priv := testProvider(t)
+	gs := client.GraphSyncFILv1{PieceCID: testCid(t), VerifiedDeal: true}
+	gsProto, err := gs.TransferProtocol()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov.ProviderProto = append(prov.ProviderProto, gsProto)
+	c, s := createClientAndServer(t, memory.NewService(), prov, priv)
+	defer s.Close()
+	ctx := context.Background()
+
+	if _, err := c.Provide(ctx, []cid.Cid{testCid(t)}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	provs, err := c.FindProviderRecords(ctx, testCid(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provs) != 1 || len(provs[0].ProviderProto) != 2 || provs[0].ProviderProto[0].Codec != multicodec.TransportBitswap {
+		t.Fatalf("expecting a provider with the bitswap and graphsync protocols, got %v", provs)
+	}
+	decoded, err := provs[0].ProviderProto[1].DecodeGraphSyncFILv1()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *decoded != gs {
+		t.Errorf("expecting %v, got %v", gs, decoded)
+	}
+}
+
+func TestServiceProviderRecordsShim(t *testing.T) {
+	// services which only find addresses advertise bitswap providers
+	c, s := createClientAndServer(t, testDelegatedRoutingService{}, nil, nil)
+	defer s.Close()
+	provs, err := c.FindProviderRecords(context.Background(), testCid(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provs) == 0 {
+		t.Fatal("expecting providers")
+	}
+	for _, prov := range provs {
+		if len(prov.ProviderProto) != 1 || prov.ProviderProto[0].Codec != multicodec.TransportBitswap {
+			t.Errorf("expecting a bitswap provider, got %v", prov)
+		}
+	}
+}
+
+var (
+	testBitswapPeer   = peer.ID("bitswap-peer")
+	testGraphSyncPeer = peer.ID("graphsync-peer")
+)
+
+type testServiceWithGraphSync struct {
+	testServiceWithErrors
+}
+
+func (testServiceWithGraphSync) FindProviders(ctx context.Context, req *proto.FindProvidersRequest) (<-chan *proto.DelegatedRouting_FindProviders_AsyncResult, error) {
+	respCh := make(chan *proto.DelegatedRouting_FindProviders_AsyncResult)
+	go func() {
+		defer close(respCh)
+		respCh <- &proto.DelegatedRouting_FindProviders_AsyncResult{
+			Resp: &proto.FindProvidersResponse{
+				Providers: proto.ProvidersList{
+					proto.Provider{
+						ProviderNode: proto.Node{Peer: client.ToProtoPeer(peer.AddrInfo{ID: testBitswapPeer, Addrs: testAddrInfo.Addrs})},
+						ProviderProto: proto.TransferProtocolList{
+							proto.TransferProtocol{Bitswap: &proto.BitswapProtocol{}},
+						},
+					},
+					proto.Provider{
+						ProviderNode: proto.Node{Peer: client.ToProtoPeer(peer.AddrInfo{ID: testGraphSyncPeer, Addrs: testAddrInfo.Addrs})},
+						ProviderProto: proto.TransferProtocolList{
+							proto.TransferProtocol{GraphSyncFILv1: &proto.GraphSyncFILv1Protocol{
+								PieceCID:      proto.LinkToAny(cid.Cid(req.Key)),
+								VerifiedDeal:  true,
+								FastRetrieval: false,
+							}},
+						},
+					},
+				},
+			},
+		}
+	}()
+	return respCh, nil
+}
@@ -0,0 +1,166 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/ipfs/go-delegated-routing/server"
+	"github.com/ipfs/go-delegated-routing/server/memory"
+)
+
+// withRateLimitTransport makes the test client report throttled calls with a RateLimitError.
+var withRateLimitTransport testOption = func(s *testSetup) { s.transport.RateLimit = true }
+
+func TestRateLimitByRemoteIP(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	limit := server.RateLimit{Rate: 0.5, Burst: 2, Key: server.KeyByRemoteIP}
+	c, s := createClientAndServer(t, testDelegatedRoutingService{}, nil, nil,
+		withServer(server.WithRateLimit(limit), server.WithClock(clock)),
+		withRateLimitTransport, withClient(client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 1})),
+	)
+	defer s.Close()
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.FindProviders(ctx, testCid(t)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := c.FindProviders(ctx, testCid(t))
+	var rateLimitErr *client.RateLimitError
+	if !errors.Is(err, client.ErrRateLimited) || !errors.As(err, &rateLimitErr) || rateLimitErr.RetryAfter != 2*time.Second {
+		t.Fatalf("expecting the call to be throttled for 2s, got %v", err)
+	}
+	if client.MetricsErrStr(err) != "RateLimited" {
+		t.Errorf("expecting a throttled call to be classified as RateLimited, got %s", client.MetricsErrStr(err))
+	}
+
+	// without the transport, throttled calls are recognized but the delay is unknown
+	plain := createClient(t, s, nil, nil, withClient(client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 1})))
+	if _, err := plain.FindProviders(ctx, testCid(t)); !errors.As(err, &rateLimitErr) || rateLimitErr.RetryAfter != 0 {
+		t.Fatalf("expecting the call to be throttled, got %v", err)
+	}
+
+	clock.Set(clock.Now().Add(2 * time.Second))
+	if _, err := c.FindProviders(ctx, testCid(t)); err != nil {
+		t.Fatalf("expecting the bucket to be refilled, got %v", err)
+	}
+}
+
+func TestRateLimitRetryAfter(t *testing.T) {
+	limit := server.RateLimit{Rate: 1, Burst: 1, Key: server.KeyByRemoteIP}
+	c, s := createClientAndServer(t, testDelegatedRoutingService{}, nil, nil,
+		withServer(server.WithRateLimit(limit)),
+		withRateLimitTransport, withClient(client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond})),
+	)
+	defer s.Close()
+	ctx := context.Background()
+
+	if _, err := c.FindProviders(ctx, testCid(t)); err != nil {
+		t.Fatal(err)
+	}
+	// the retry waits for the second requested by the server, rather than the base delay of the policy
+	start := time.Now()
+	if _, err := c.FindProviders(ctx, testCid(t)); err != nil {
+		t.Fatalf("expecting the throttled call to succeed when retried, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expecting the retry to wait for Retry-After, retried after %v", elapsed)
+	}
+}
+
+func TestRateLimitByProvider(t *testing.T) {
+	limit := server.RateLimit{Rate: 0.001, Burst: 1, Key: server.KeyByProvider(server.KeyByRemoteIP)}
+	first, firstPriv := testProvider(t)
+	second, secondPriv := testProvider(t)
+	c1, s := createClientAndServer(t, memory.NewService(), first, firstPriv, withServer(server.WithRateLimit(limit)), withRateLimitTransport)
+	defer s.Close()
+	c2 := createClient(t, s, second, secondPriv, withRateLimitTransport)
+	ctx := context.Background()
+	if _, err := c1.Provide(ctx, []cid.Cid{testCid(t)}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c2.Provide(ctx, []cid.Cid{testCid(t)}, time.Hour); err != nil {
+		t.Fatalf("expecting providers to be throttled separately, got %v", err)
+	}
+	if _, err := c1.Provide(ctx, []cid.Cid{testCid(t)}, time.Hour); !errors.Is(err, client.ErrRateLimited) {
+		t.Fatalf("expecting the second provide of a peer to be throttled, got %v", err)
+	}
+	// other requests are keyed by remote IP
+	if _, err := c1.FindProviders(ctx, testCid(t)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRateLimitDoesNotOpenCircuit(t *testing.T) {
+	limit := server.RateLimit{Rate: 0.001, Burst: 1, Key: server.KeyByRemoteIP}
+	c, s := createClientAndServer(t, testDelegatedRoutingService{}, nil, nil,
+		withServer(server.WithRateLimit(limit)),
+		withRateLimitTransport,
+		withClient(
+			client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 1}),
+			client.WithCircuitBreaker(client.CircuitBreakerPolicy{FailureThreshold: 1, Cooldown: time.Hour}),
+		),
+	)
+	defer s.Close()
+	ctx := context.Background()
+
+	if _, err := c.FindProviders(ctx, testCid(t)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := c.FindProviders(ctx, testCid(t)); !errors.Is(err, client.ErrRateLimited) {
+			t.Fatalf("expecting the call to be throttled rather than cut by the circuit breaker, got %v", err)
+		}
+	}
+	if !c.Ready() {
+		t.Errorf("expecting throttled calls to leave the circuit closed")
+	}
+}
+
+func TestInvalidRateLimit(t *testing.T) {
+	for name, limit := range map[string]server.RateLimit{
+		"zero rate":  {Rate: 0, Burst: 1, Key: server.KeyByRemoteIP},
+		"zero burst": {Rate: 1, Burst: 0, Key: server.KeyByRemoteIP},
+		"no key":     {Rate: 1, Burst: 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expecting an invalid rate limit to be refused")
+				}
+			}()
+			server.WithRateLimit(limit)
+		})
+	}
+}
+
+func TestRateLimitsChargeAllOrNone(t *testing.T) {
+	byIP := server.RateLimit{Rate: 0.001, Burst: 2, Key: server.KeyByRemoteIP}
+	byProvider := server.RateLimit{Rate: 0.001, Burst: 1, Key: server.KeyByProvider(server.KeyByRemoteIP)}
+	first, firstPriv := testProvider(t)
+	second, secondPriv := testProvider(t)
+	c1, s := createClientAndServer(t, memory.NewService(), first, firstPriv,
+		withServer(server.WithRateLimit(byIP), server.WithRateLimit(byProvider)), withRateLimitTransport)
+	defer s.Close()
+	c2 := createClient(t, s, second, secondPriv, withRateLimitTransport)
+	ctx := context.Background()
+
+	if _, err := c1.Provide(ctx, []cid.Cid{testCid(t)}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c1.Provide(ctx, []cid.Cid{testCid(t)}, time.Hour); !errors.Is(err, client.ErrRateLimited) {
+		t.Fatalf("expecting the second provide of a peer to be throttled, got %v", err)
+	}
+	// the throttled provide left its token in the bucket of the remote IP
+	if _, err := c2.Provide(ctx, []cid.Cid{testCid(t)}, time.Hour); err != nil {
+		t.Fatalf("expecting the remote IP to have a token left, got %v", err)
+	}
+	if _, err := c2.FindProviders(ctx, testCid(t)); !errors.Is(err, client.ErrRateLimited) {
+		t.Fatalf("expecting the remote IP to be throttled, got %v", err)
+	}
+}
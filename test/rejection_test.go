@@ -0,0 +1,138 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-delegated-routing/client"
+	"github.com/ipfs/go-delegated-routing/server/memory"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// rejectingService rejects every call.
+type rejectingService struct{}
+
+var errMaintenance = errors.New("closed for maintenance")
+
+func (rejectingService) FindProviders(ctx context.Context, key cid.Cid) (<-chan client.FindProvidersAsyncResult, error) {
+	return nil, errMaintenance
+}
+
+func (rejectingService) FindPeer(ctx context.Context, id peer.ID) (<-chan client.FindPeerAsyncResult, error) {
+	return nil, client.ErrInvalidRequest.WithReason("unknown peer")
+}
+
+func (rejectingService) GetIPNS(ctx context.Context, id []byte) (<-chan client.GetIPNSAsyncResult, error) {
+	return nil, errMaintenance
+}
+
+func (rejectingService) PutIPNS(ctx context.Context, id []byte, record []byte) (<-chan client.PutIPNSAsyncResult, error) {
+	return nil, errMaintenance
+}
+
+func (rejectingService) Provide(ctx context.Context, req *client.ProvideRequest) (<-chan client.ProvideAsyncResult, error) {
+	return nil, errMaintenance
+}
+
+// resultRejectingService accepts every call and rejects it in the result stream.
+type resultRejectingService struct{}
+
+func rejectedResult[T any](r T) <-chan T {
+	ch := make(chan T, 1)
+	ch <- r
+	close(ch)
+	return ch
+}
+
+func (resultRejectingService) FindProviders(ctx context.Context, key cid.Cid) (<-chan client.FindProvidersAsyncResult, error) {
+	return rejectedResult(client.FindProvidersAsyncResult{Err: errMaintenance}), nil
+}
+
+func (resultRejectingService) FindPeer(ctx context.Context, id peer.ID) (<-chan client.FindPeerAsyncResult, error) {
+	return rejectedResult(client.FindPeerAsyncResult{Err: client.ErrInvalidRequest.WithReason("unknown peer")}), nil
+}
+
+func (resultRejectingService) GetIPNS(ctx context.Context, id []byte) (<-chan client.GetIPNSAsyncResult, error) {
+	return rejectedResult(client.GetIPNSAsyncResult{Err: errMaintenance}), nil
+}
+
+func (resultRejectingService) PutIPNS(ctx context.Context, id []byte, record []byte) (<-chan client.PutIPNSAsyncResult, error) {
+	return rejectedResult(client.PutIPNSAsyncResult{Err: errMaintenance}), nil
+}
+
+func (resultRejectingService) Provide(ctx context.Context, req *client.ProvideRequest) (<-chan client.ProvideAsyncResult, error) {
+	return rejectedResult(client.ProvideAsyncResult{Err: errMaintenance}), nil
+}
+
+func expectRejection(t *testing.T, method string, err error, expect *client.RejectionError, reason string) {
+	t.Helper()
+	var rejection *client.RejectionError
+	if !errors.Is(err, expect) || !errors.As(err, &rejection) || rejection.Reason != reason {
+		t.Errorf("%s: expecting %v with reason %q, got %v", method, expect, reason, err)
+	}
+}
+
+func TestServiceRejections(t *testing.T) {
+	prov, priv := testProvider(t)
+	c, s := createClientAndServer(t, rejectingService{}, prov, priv)
+	defer s.Close()
+	ctx := context.Background()
+
+	_, err := c.FindProviders(ctx, testCid(t))
+	expectRejection(t, "FindProviders", err, client.ErrServiceRejected, errMaintenance.Error())
+	_, err = c.FindPeer(ctx, prov.Peer.ID)
+	expectRejection(t, "FindPeer", err, client.ErrInvalidRequest, "unknown peer")
+	_, err = c.GetIPNS(ctx, []byte(testPeerIDFromIPNS))
+	expectRejection(t, "GetIPNS", err, client.ErrServiceRejected, errMaintenance.Error())
+	err = c.PutIPNS(ctx, []byte(testPeerIDFromIPNS), testIPNSRecord)
+	expectRejection(t, "PutIPNS", err, client.ErrServiceRejected, errMaintenance.Error())
+	_, err = c.Provide(ctx, []cid.Cid{testCid(t)}, time.Hour)
+	expectRejection(t, "Provide", err, client.ErrServiceRejected, errMaintenance.Error())
+}
+
+func TestServiceResultRejections(t *testing.T) {
+	prov, priv := testProvider(t)
+	c, s := createClientAndServer(t, resultRejectingService{}, prov, priv)
+	defer s.Close()
+	ctx := context.Background()
+
+	_, err := c.FindProviders(ctx, testCid(t))
+	expectRejection(t, "FindProviders", err, client.ErrServiceRejected, errMaintenance.Error())
+	_, err = c.FindPeer(ctx, prov.Peer.ID)
+	expectRejection(t, "FindPeer", err, client.ErrInvalidRequest, "unknown peer")
+	_, err = c.GetIPNS(ctx, []byte(testPeerIDFromIPNS))
+	expectRejection(t, "GetIPNS", err, client.ErrServiceRejected, errMaintenance.Error())
+	err = c.PutIPNS(ctx, []byte(testPeerIDFromIPNS), testIPNSRecord)
+	expectRejection(t, "PutIPNS", err, client.ErrServiceRejected, errMaintenance.Error())
+	_, err = c.Provide(ctx, []cid.Cid{testCid(t)}, time.Hour)
+	expectRejection(t, "Provide", err, client.ErrServiceRejected, errMaintenance.Error())
+}
+
+func TestProvideInvalidSignature(t *testing.T) {
+	prov, priv := testProvider(t)
+	c, s := createClientAndServer(t, memory.NewService(), prov, priv)
+	defer s.Close()
+	ctx := context.Background()
+
+	req := &client.ProvideRequest{Key: []cid.Cid{testCid(t)}, Provider: prov, AdvisoryTTL: time.Hour}
+	if err := req.Sign(priv); err != nil {
+		t.Fatal(err)
+	}
+	req.AdvisoryTTL = 2 * time.Hour
+	ch, err := c.ProvideSignedRecord(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := <-ch
+	if !ok || !errors.Is(r.Err, client.ErrInvalidSignature) {
+		t.Fatalf("expecting a tampered request to be rejected for its signature, got %v", r.Err)
+	}
+
+	// no providers is not an error
+	if infos, err := c.FindProviders(ctx, testCid(t)); err != nil || len(infos) != 0 {
+		t.Fatalf("expecting no providers, got %v (%v)", infos, err)
+	}
+}
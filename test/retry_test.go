@@ -0,0 +1,168 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-delegated-routing/client"
+	proto "github.com/ipfs/go-delegated-routing/gen/proto"
+	"github.com/ipld/edelweiss/values"
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagjson"
+	"github.com/multiformats/go-multihash"
+)
+
+// failFirst makes the test server fail the first numFailures requests with 503 Service Unavailable,
+// with cause in the Error header unless it is empty, and counts the requests in numRequests.
+func failFirst(numFailures int64, cause string, numRequests *int64) serverHandler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt64(numRequests, 1) <= numFailures {
+				if cause != "" {
+					w.Header()["Error"] = []string{cause}
+				}
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func testCid(t *testing.T) cid.Cid {
+	h, err := multihash.Sum([]byte("TEST"), multihash.SHA3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cid.NewCidV1(cid.Raw, h)
+}
+
+func TestRetryTransientErrors(t *testing.T) {
+	var numRequests int64
+	c, s := createClientAndServer(t, testDelegatedRoutingService{}, nil, nil,
+		withHandler(failFirst(2, "temporarily unavailable", &numRequests)),
+		withClient(client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})),
+	)
+	defer s.Close()
+
+	infos, err := c.FindProviders(context.Background(), testCid(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 {
+		t.Errorf("expecting 1 result, got %d", len(infos))
+	}
+	if n := atomic.LoadInt64(&numRequests); n != 3 {
+		t.Errorf("expecting 3 requests, got %d", n)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	const cooldown = 100 * time.Millisecond
+	var numRequests int64
+	c, s := createClientAndServer(t, testDelegatedRoutingService{}, nil, nil,
+		withHandler(failFirst(2, "temporarily unavailable", &numRequests)),
+		withClient(client.WithCircuitBreaker(client.CircuitBreakerPolicy{FailureThreshold: 2, Cooldown: cooldown})),
+	)
+	defer s.Close()
+	cr := client.NewContentRoutingClient(c)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.FindProviders(context.Background(), testCid(t)); err == nil {
+			t.Fatal("expecting an error")
+		}
+	}
+	if cr.Ready() {
+		t.Errorf("expecting the content routing client not to be ready while the circuit is open")
+	}
+	if _, err := c.FindProviders(context.Background(), testCid(t)); !errors.Is(err, client.ErrCircuitOpen) {
+		t.Errorf("expecting %v, got %v", client.ErrCircuitOpen, err)
+	}
+	if n := atomic.LoadInt64(&numRequests); n != 2 {
+		t.Errorf("expecting 2 requests, got %d", n)
+	}
+
+	// after the cooldown, a probe is let through and closes the circuit
+	time.Sleep(cooldown)
+	if !cr.Ready() {
+		t.Errorf("expecting the content routing client to be ready after the cooldown")
+	}
+	if _, err := c.FindProviders(context.Background(), testCid(t)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRetryServerErrorWithoutCause(t *testing.T) {
+	var numRequests int64
+	c, s := createClientAndServer(t, testDelegatedRoutingService{}, nil, nil,
+		withHandler(failFirst(1, "", &numRequests)),
+		withClient(client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond})),
+	)
+	defer s.Close()
+
+	if _, err := c.FindProviders(context.Background(), testCid(t)); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt64(&numRequests); n != 2 {
+		t.Errorf("expecting 2 requests, got %d", n)
+	}
+}
+
+func TestNoRetryOfRejections(t *testing.T) {
+	var numRequests int64
+	c, s := createClientAndServer(t, testDelegatedRoutingService{}, nil, nil,
+		withHandler(failFirst(1, client.ErrInvalidRequest.WithReason("unknown key").Error(), &numRequests)),
+		withClient(client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})),
+	)
+	defer s.Close()
+
+	if _, err := c.FindProviders(context.Background(), testCid(t)); !errors.Is(err, client.ErrInvalidRequest) {
+		t.Fatalf("expecting %v, got %v", client.ErrInvalidRequest, err)
+	}
+	if n := atomic.LoadInt64(&numRequests); n != 1 {
+		t.Errorf("expecting the rejection not to be retried, got %d requests", n)
+	}
+}
+
+// failStream makes the test server answer every request with a 200 OK response whose result stream holds
+// the error cause, as servers which predate rejections do, and counts the requests in numRequests.
+func failStream(t *testing.T, cause string, numRequests *int64) serverHandler {
+	msg, err := ipld.Encode(&proto.AnonInductive5{Error: &proto.DelegatedRouting_Error{Code: values.String(cause)}}, dagjson.Encode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt64(numRequests, 1)
+			w.Header().Set("Content-Type", client.MediaTypeDagJSON)
+			w.Write(append(msg, '\n'))
+		})
+	}
+}
+
+func TestNoRetryOfStreamErrors(t *testing.T) {
+	var numRequests int64
+	c, s := createClientAndServer(t, testDelegatedRoutingService{}, nil, nil,
+		withHandler(failStream(t, "temporarily unavailable", &numRequests)),
+		withClient(
+			client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}),
+			client.WithCircuitBreaker(client.CircuitBreakerPolicy{FailureThreshold: 1, Cooldown: time.Hour}),
+		),
+	)
+	defer s.Close()
+
+	if _, err := c.FindProviders(context.Background(), testCid(t)); err == nil {
+		t.Fatal("expecting an error")
+	}
+	if n := atomic.LoadInt64(&numRequests); n != 1 {
+		t.Errorf("expecting the error of the result stream not to be retried, got %d requests", n)
+	}
+	if !c.Ready() {
+		t.Errorf("expecting the error of the result stream not to open the circuit")
+	}
+}
@@ -72,6 +72,14 @@ func (testServiceWithErrors) FindProviders(ctx context.Context, req *proto.FindP
 	return respCh, nil
 }
 
+func (testServiceWithErrors) FindProvidersBatch(ctx context.Context, req *proto.FindProvidersBatchRequest) (<-chan *proto.DelegatedRouting_FindProvidersBatch_AsyncResult, error) {
+	return nil, fmt.Errorf(testSyncError)
+}
+
+func (testServiceWithErrors) FindPeer(ctx context.Context, req *proto.FindPeerRequest) (<-chan *proto.DelegatedRouting_FindPeer_AsyncResult, error) {
+	return nil, fmt.Errorf(testSyncError)
+}
+
 func (testServiceWithErrors) GetIPNS(ctx context.Context, req *proto.GetIPNSRequest) (<-chan *proto.DelegatedRouting_GetIPNS_AsyncResult, error) {
 	return nil, fmt.Errorf(testSyncError)
 }
@@ -0,0 +1,96 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-delegated-routing/client"
+	proto "github.com/ipfs/go-delegated-routing/gen/proto"
+	"github.com/ipfs/go-delegated-routing/server"
+	"go.opencensus.io/stats/view"
+)
+
+// viewCounts returns the number of measurements recorded by the view with the given name, by tag values.
+func viewCounts(t *testing.T, name string) map[string]int64 {
+	rows, err := view.RetrieveData(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int64{}
+	for _, row := range rows {
+		var values []string
+		for _, tag := range row.Tags {
+			values = append(values, tag.Value)
+		}
+		key := strings.Join(values, ",")
+		switch data := row.Data.(type) {
+		case *view.SumData:
+			counts[key] = int64(data.Value)
+		case *view.DistributionData:
+			counts[key] = data.Count
+		}
+	}
+	return counts
+}
+
+func TestServerMetrics(t *testing.T) {
+	if err := view.Register(server.DefaultViews...); err != nil {
+		t.Fatal(err)
+	}
+	defer view.Unregister(server.DefaultViews...)
+
+	s := httptest.NewServer(server.DelegatedRoutingAsyncHandler(testDelegatedRoutingService{}))
+	defer s.Close()
+
+	const ttl = 100 * time.Millisecond
+	hc := &http.Client{Transport: client.NewCacheTransport(nil, 16, ttl)}
+	q, err := proto.New_DelegatedRouting_Client(s.URL, proto.DelegatedRouting_Client_WithHTTPClient(hc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.NewClient(q, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// the second lookup revalidates the cached response, which is answered with 304 Not Modified
+	if _, err := c.FindProviders(ctx, testCid(t)); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(ttl)
+	if _, err := c.FindProviders(ctx, testCid(t)); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(s.URL, "application/json", strings.NewReader("not a request"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expecting 400 for an undecodable request, got %d", resp.StatusCode)
+	}
+
+	for _, x := range []struct {
+		view string
+		key  string
+		min  int64
+	}{
+		{"delegated_routing/server/requests", "None,FindProviders", 1},
+		{"delegated_routing/server/requests", "Schema,Unknown", 1},
+		{"delegated_routing/server/cache_hits", "FindProviders", 1},
+		{"delegated_routing/server/decode_failures", "Unknown", 1},
+		{"delegated_routing/server/time_to_first_result", "None,FindProviders", 2},
+		{"delegated_routing/server/stream_duration", "None,FindProviders", 2},
+		{"delegated_routing/server/stream_results", "None,FindProviders", 2},
+	} {
+		counts := viewCounts(t, x.view)
+		if counts[x.key] < x.min {
+			t.Errorf("expecting at least %d measurements of %s for %s, got %v", x.min, x.view, x.key, counts)
+		}
+	}
+}
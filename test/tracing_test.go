@@ -0,0 +1,91 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-delegated-routing/client"
+	proto "github.com/ipfs/go-delegated-routing/gen/proto"
+	"github.com/ipfs/go-delegated-routing/server"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracePropagation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	}()
+
+	s := httptest.NewServer(server.DelegatedRoutingAsyncHandler(testDelegatedRoutingService{}))
+	defer s.Close()
+	hc := &http.Client{Transport: client.NewTracingTransport(nil)}
+	q, err := proto.New_DelegatedRouting_Client(s.URL, proto.DelegatedRouting_Client_WithHTTPClient(hc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.NewClient(q, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.FindProviders(context.Background(), testCid(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Client.FindProviders", "ReadResponseStream", "DelegatedRoutingService.FindProviders"}
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	// server spans end after the response has been written
+	deadline := time.Now().Add(5 * time.Second)
+	for len(byName) < len(want) && time.Now().Before(deadline) {
+		for _, s := range recorder.Ended() {
+			byName[s.Name()] = s
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	root, ok := byName["Client.FindProviders"]
+	if !ok {
+		t.Fatalf("expecting a Client.FindProviders span, got %v", byName)
+	}
+	for _, name := range want {
+		s, ok := byName[name]
+		if !ok {
+			t.Fatalf("expecting a %s span", name)
+		}
+		if s.SpanContext().TraceID() != root.SpanContext().TraceID() {
+			t.Errorf("expecting the %s span to be part of the trace of the call", name)
+		}
+	}
+	// the server continues the trace of the HTTP exchange of the client
+	var clientHTTP, serverHTTP sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		if s.Name() != "HTTP GET" {
+			continue
+		}
+		if s.Parent().IsRemote() {
+			serverHTTP = s
+		} else {
+			clientHTTP = s
+		}
+	}
+	if clientHTTP == nil || serverHTTP == nil {
+		t.Fatal("expecting client and server spans for the HTTP exchange")
+	}
+	if serverHTTP.Parent().SpanID() != clientHTTP.SpanContext().SpanID() {
+		t.Errorf("expecting the server span to be a child of the client span")
+	}
+	if svc := byName["DelegatedRoutingService.FindProviders"]; svc.Parent().SpanID() != serverHTTP.SpanContext().SpanID() {
+		t.Errorf("expecting the service span to be a child of the server span")
+	}
+}